@@ -14,7 +14,16 @@ type Node interface {
 	Config() map[string]interface{}
 	GetHTTPBaseURI() string
 	GetBootstrapIP() string
+	// Status returns the current lifecycle status of the node
+	Status() NodeStatus
 	Stop(timeout time.Duration) error // TODO pass in [ctx] instead of [timeout]
+	// Restart stops the node if it is running and starts it again with the same config, staking key, data directory
+	// and ports. [stopTimeout] is the time to wait for the node to stop gracefully before killing it.
+	Restart(ctx context.Context, stopTimeout time.Duration) error
+	// Pause suspends a running node without stopping it, so that it appears frozen to its peers
+	Pause() error
+	// Resume continues a paused node
+	Resume() error
 }
 
 // Network provides an interface for configuring Nodes
@@ -29,6 +38,19 @@ type Network interface {
 	AddNode(ctx context.Context, config NodeConfig) (Node, error)
 	// RemoveNode stops and removes the node from the network
 	RemoveNode(name string, timeout time.Duration) error
+	// UpdateNode restarts the node [name] with [update] applied to its config, keeping its data directory, staking key
+	// and ports
+	UpdateNode(ctx context.Context, name string, update NodeUpdate, stopTimeout time.Duration) (Node, error)
+	// Snapshot saves the config and data of every node in the network under [name], so that an identical network can be
+	// recreated with RestoreSnapshot. The nodes are stopped while the snapshot is taken and started again afterwards.
+	Snapshot(ctx context.Context, name string) error
+	// Partition splits the nodes of the network into [partitions] that cannot communicate with each other. The nodes
+	// that are not listed form one additional partition.
+	Partition(ctx context.Context, partitions [][]string) error
+	// Heal removes all partitions and link faults from the network
+	Heal(ctx context.Context) error
+	// SetLinkFaults applies [faults] to the traffic sent from the node [from] to the node [to]
+	SetLinkFaults(ctx context.Context, from string, to string, faults LinkFaults) error
 	// Teardown stops the network and additionally tears down all of the resources associated with it
 	Teardown(ctx context.Context) error
 }
@@ -38,5 +60,13 @@ type NetworkOrchestrator interface {
 	CreateNetwork(name string) (Network, error)
 	GetNetworks() ([]Network, error)
 	GetNetwork(name string) (Network, error)
+	// AttachNetwork returns the network [name] after syncing its nodes with the backend. This supports attaching
+	// to networks that were created or modified outside of this orchestrator ie. by another client of a shared server.
+	AttachNetwork(ctx context.Context, name string) (Network, error)
+	// ListSnapshots returns the names of the snapshots that can be restored
+	ListSnapshots(ctx context.Context) ([]string, error)
+	// RestoreSnapshot recreates the network saved in [snapshot] under the name [name], or under the name of the saved
+	// network if [name] is empty.
+	RestoreSnapshot(ctx context.Context, snapshot string, name string) (Network, error)
 	Teardown(ctx context.Context) error
 }
@@ -3,11 +3,34 @@
 
 package backend
 
+import "encoding/json"
+
 type NodeConfig struct {
 	Name       string                 `json:"name"`       // Name of the node
 	Executable string                 `json:"executable"` // Executable - docker image in this context
 	Config     map[string]interface{} `json:"config"`     // Config string to be passed in via --config-file-content
 	NodeID     string                 `json:"nodeID"`     // If non-empty, this contains the pre-configured nodeID of the node
+	// Plugins maps the ID of each VM to install into the plugin directory of the node to the name of its binary in the
+	// executor registry
+	Plugins map[string]string `json:"plugins,omitempty"`
+	// ChainConfigs maps a chain ID or alias, ie. "C", to its config, which is written to the chain config directory of
+	// the node
+	ChainConfigs map[string]ChainConfig `json:"chainConfigs,omitempty"`
+	// SubnetConfigs maps the ID of a subnet to its config, which is written to the subnet config directory of the node.
+	// Each config may be given as a JSON object or as a string holding the file contents.
+	SubnetConfigs map[string]json.RawMessage `json:"subnetConfigs,omitempty"`
+	// Readiness is the readiness probe that AddNode waits on before returning the node. If nil, AddNode returns as soon
+	// as the backend has started the node.
+	Readiness *ReadinessConfig `json:"readiness,omitempty"`
+	// RestartPolicy determines whether the node is restarted when it crashes. If nil, a crashed node is left crashed.
+	RestartPolicy *RestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+// ChainConfig holds the config.json and upgrade.json of a chain. Each may be given as a JSON object or as a string
+// holding the file contents.
+type ChainConfig struct {
+	Config  json.RawMessage `json:"config,omitempty"`
+	Upgrade json.RawMessage `json:"upgrade,omitempty"`
 }
 
 func CopyConfig(config map[string]interface{}) map[string]interface{} {
@@ -0,0 +1,126 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+const (
+	chainConfigFileName  = "config.json"
+	chainUpgradeFileName = "upgrade.json"
+	subnetConfigFileExt  = ".json"
+
+	// The AvalancheGo config keys that chain and subnet configs are passed through, which are not imported from the
+	// config package of AvalancheGo to keep its dependencies out of the backend
+	chainConfigDirKey      = "chain-config-dir"
+	chainConfigContentKey  = "chain-config-content"
+	subnetConfigDirKey     = "subnet-config-dir"
+	subnetConfigContentKey = "subnet-config-content"
+)
+
+// validateConfigFiles returns an error if [chainConfigs] or [subnetConfigs] cannot be written to the config
+// directories of a node
+func validateConfigFiles(chainConfigs map[string]ChainConfig, subnetConfigs map[string]json.RawMessage) error {
+	for chain := range chainConfigs {
+		if chain == "" || chain == "." || chain == ".." || strings.ContainsAny(chain, `/\`) {
+			return fmt.Errorf("invalid chain %q in chain configs", chain)
+		}
+	}
+	for subnetID := range subnetConfigs {
+		if _, err := ids.FromString(subnetID); err != nil {
+			return fmt.Errorf("invalid subnet ID %q in subnet configs: %w", subnetID, err)
+		}
+	}
+	return nil
+}
+
+// WriteConfigFiles replaces the contents of [chainConfigDir] and [subnetConfigDir] with the chain and subnet configs of
+// [nodeDef] in the layout that AvalancheGo reads them from ie. <chainConfigDir>/C/config.json.
+func WriteConfigFiles(nodeDef NodeConfig, chainConfigDir string, subnetConfigDir string) error {
+	if err := validateConfigFiles(nodeDef.ChainConfigs, nodeDef.SubnetConfigs); err != nil {
+		return err
+	}
+	for _, dir := range []string{chainConfigDir, subnetConfigDir} {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	for chain, chainConfig := range nodeDef.ChainConfigs {
+		chainDir := filepath.Join(chainConfigDir, chain)
+		if err := os.MkdirAll(chainDir, 0o755); err != nil {
+			return err
+		}
+		if err := writeConfigFile(filepath.Join(chainDir, chainConfigFileName), chainConfig.Config); err != nil {
+			return err
+		}
+		if err := writeConfigFile(filepath.Join(chainDir, chainUpgradeFileName), chainConfig.Upgrade); err != nil {
+			return err
+		}
+	}
+	for subnetID, subnetConfig := range nodeDef.SubnetConfigs {
+		if err := writeConfigFile(filepath.Join(subnetConfigDir, subnetID+subnetConfigFileExt), subnetConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConfigDirs points [nodeConfig] at the directories that WriteConfigFiles wrote the chain and subnet configs of
+// [nodeDef] to, as seen by the node. The directories are only set if the node has chain or subnet configs
+// respectively, so that the node can otherwise use configs of its own.
+func SetConfigDirs(nodeDef NodeConfig, nodeConfig map[string]interface{}, chainConfigDir string, subnetConfigDir string) error {
+	dirs := []struct {
+		configs    int
+		dirKey     string
+		contentKey string
+		dir        string
+	}{
+		{len(nodeDef.ChainConfigs), chainConfigDirKey, chainConfigContentKey, chainConfigDir},
+		{len(nodeDef.SubnetConfigs), subnetConfigDirKey, subnetConfigContentKey, subnetConfigDir},
+	}
+	for _, dir := range dirs {
+		if dir.configs == 0 {
+			continue
+		}
+		for _, key := range []string{dir.dirKey, dir.contentKey} {
+			if _, ok := nodeConfig[key]; ok {
+				return fmt.Errorf("node %s cannot set %s along with chain or subnet configs, which are written to %s", nodeDef.Name, key, dir.dirKey)
+			}
+		}
+		nodeConfig[dir.dirKey] = dir.dir
+	}
+	return nil
+}
+
+// writeConfigFile writes [raw] to [path] unless it is empty. If [raw] is a JSON string, its contents are written
+// instead, so that configs can be given either as an object or as a string holding the config.
+func writeConfigFile(path string, raw json.RawMessage) error {
+	if isEmptyConfig(raw) {
+		return nil
+	}
+	content := []byte(raw)
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		content = []byte(s)
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// isEmptyConfig returns true if [raw] is missing or null
+func isEmptyConfig(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
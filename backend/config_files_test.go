@@ -0,0 +1,91 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSubnetID = "2bRCr6B4MiEfSjidDwxDpdCyviwnfUVqB2HGwhm947w9YYqb7r"
+
+func TestWriteConfigFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	chainConfigDir, subnetConfigDir := filepath.Join(dir, "chains"), filepath.Join(dir, "subnets")
+	nodeDef := backend.NodeConfig{
+		Name: "node0",
+		ChainConfigs: map[string]backend.ChainConfig{
+			"C": {Config: json.RawMessage(`{"eth-apis": ["public-eth"]}`), Upgrade: json.RawMessage(`"{}"`)},
+			"X": {Config: json.RawMessage(`"plain text"`)},
+		},
+		SubnetConfigs: map[string]json.RawMessage{
+			testSubnetID: json.RawMessage(`{"validatorOnly": true}`),
+		},
+	}
+	if err := backend.WriteConfigFiles(nodeDef, chainConfigDir, subnetConfigDir); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(chainConfigDir, "C", "config.json"):    `{"eth-apis": ["public-eth"]}`,
+		filepath.Join(chainConfigDir, "C", "upgrade.json"):   `{}`,
+		filepath.Join(chainConfigDir, "X", "config.json"):    `plain text`,
+		filepath.Join(subnetConfigDir, testSubnetID+".json"): `{"validatorOnly": true}`,
+	}
+	for path, expected := range files {
+		b, err := os.ReadFile(path)
+		if assert.NoError(err) {
+			assert.Equal(expected, string(b))
+		}
+	}
+	_, err := os.Stat(filepath.Join(chainConfigDir, "X", "upgrade.json"))
+	assert.True(os.IsNotExist(err), "expected no upgrade file for a chain without an upgrade")
+
+	// Writing the configs again replaces the configs that were written before.
+	nodeDef.ChainConfigs = map[string]backend.ChainConfig{"P": {Config: json.RawMessage(`{}`)}}
+	nodeDef.SubnetConfigs = nil
+	if err := backend.WriteConfigFiles(nodeDef, chainConfigDir, subnetConfigDir); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(chainConfigDir)
+	if assert.NoError(err) && assert.Len(entries, 1) {
+		assert.Equal("P", entries[0].Name())
+	}
+	entries, err = os.ReadDir(subnetConfigDir)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	invalid := []backend.NodeConfig{
+		{Name: "node0", ChainConfigs: map[string]backend.ChainConfig{"../C": {}}},
+		{Name: "node0", ChainConfigs: map[string]backend.ChainConfig{"": {}}},
+		{Name: "node0", SubnetConfigs: map[string]json.RawMessage{"subnet": json.RawMessage(`{}`)}},
+	}
+	for _, nodeDef := range invalid {
+		assert.Error(backend.WriteConfigFiles(nodeDef, chainConfigDir, subnetConfigDir))
+	}
+}
+
+func TestSetConfigDirs(t *testing.T) {
+	assert := assert.New(t)
+
+	nodeConfig := map[string]interface{}{}
+	assert.NoError(backend.SetConfigDirs(backend.NodeConfig{Name: "node0"}, nodeConfig, "/chains", "/subnets"))
+	assert.Empty(nodeConfig, "expected a node without chain or subnet configs to keep its own config directories")
+
+	nodeDef := backend.NodeConfig{
+		Name:         "node0",
+		ChainConfigs: map[string]backend.ChainConfig{"C": {Config: json.RawMessage(`{}`)}},
+	}
+	assert.NoError(backend.SetConfigDirs(nodeDef, nodeConfig, "/chains", "/subnets"))
+	assert.Equal(map[string]interface{}{"chain-config-dir": "/chains"}, nodeConfig)
+
+	nodeConfig = map[string]interface{}{"chain-config-content": "e30="}
+	assert.Error(backend.SetConfigDirs(nodeDef, nodeConfig, "/chains", "/subnets"), "expected chain configs to conflict with chain-config-content")
+}
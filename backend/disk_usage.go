@@ -0,0 +1,27 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+var _ DiskUsageReporter = &networkBackend{}
+
+// DiskUsageReporter is an optional interface that a NetworkConstructor can implement to report the disk space used by
+// its network. Networks implement it as well and report the disk usage of their constructor.
+type DiskUsageReporter interface {
+	// DiskUsage returns the number of bytes stored on disk by the nodes of the network
+	DiskUsage(ctx context.Context) (int64, error)
+}
+
+// DiskUsage returns the disk usage reported by the network constructor if it implements DiskUsageReporter.
+func (backend *networkBackend) DiskUsage(ctx context.Context) (int64, error) {
+	reporter, ok := backend.network.(DiskUsageReporter)
+	if !ok {
+		return 0, fmt.Errorf("network %s does not report its disk usage", backend.name)
+	}
+	return reporter.DiskUsage(ctx)
+}
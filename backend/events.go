@@ -0,0 +1,142 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// EventType describes the change to a network or node that an Event reports
+type EventType string
+
+const (
+	EventNetworkCreated  EventType = "network_created"
+	EventNetworkTornDown EventType = "network_torn_down"
+	EventNodeAdded       EventType = "node_added"
+	EventNodeStarted     EventType = "node_started"
+	EventNodeHealthy     EventType = "node_healthy"
+	EventNodeUnhealthy   EventType = "node_unhealthy"
+	EventNodePaused      EventType = "node_paused"
+	EventNodeResumed     EventType = "node_resumed"
+	EventNodeCrashed     EventType = "node_crashed"
+	EventNodeRestarting  EventType = "node_restarting"
+	EventNodeStopped     EventType = "node_stopped"
+	// EventNodeUpdated reports that a node was restarted with an updated config
+	EventNodeUpdated EventType = "node_updated"
+	// EventNetworkPartitioned, EventNetworkHealed and EventLinkFaultsSet report the faults injected into a network
+	EventNetworkPartitioned EventType = "network_partitioned"
+	EventNetworkHealed      EventType = "network_healed"
+	EventLinkFaultsSet      EventType = "link_faults_set"
+
+	// eventBufferSize is the number of events a subscriber may fall behind before it is dropped
+	eventBufferSize = 1024
+)
+
+var errSubscriberFellBehind = errors.New("event subscriber fell behind")
+
+// Event reports a change to a network or one of its nodes
+type Event struct {
+	Type    EventType `json:"type"`
+	Network string    `json:"network"`
+	// Node is the name of the node the event applies to, or empty for network events
+	Node string    `json:"node,omitempty"`
+	Time time.Time `json:"time"`
+	// Message describes the event in more detail ie. the error a node crashed with
+	Message string `json:"message,omitempty"`
+}
+
+// EventWatcher is an optional interface for orchestrators that publish the changes made to their networks as events
+type EventWatcher interface {
+	// WatchEvents calls [f] with every event published from now on until [ctx] is done or [f] returns an error
+	WatchEvents(ctx context.Context, f func(Event) error) error
+}
+
+// StatusNotifier is an optional interface for nodes that report changes to their status as they happen ie. a crash the
+// moment the node exits, rather than only when Status is called.
+type StatusNotifier interface {
+	// NotifyStatus registers [f] to be called with the current status of the node and then with every status the
+	// node transitions to, along with the error that caused it, if any. [f] must not block. Replaces any previously
+	// registered function.
+	NotifyStatus(f func(status NodeStatus, err error))
+}
+
+// EventBus delivers published events to every subscriber. Publishing never blocks: a subscriber that falls too far
+// behind is dropped.
+type EventBus struct {
+	lock        sync.Mutex
+	subscribers map[*eventSubscriber]struct{}
+}
+
+type eventSubscriber struct {
+	events chan Event
+	err    error
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+// Publish delivers [event] to every subscriber. The time of the event is set to now if it is not set.
+func (b *EventBus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber.events <- event:
+		default:
+			subscriber.err = errSubscriberFellBehind
+			close(subscriber.events)
+			delete(b.subscribers, subscriber)
+		}
+	}
+}
+
+// HasSubscribers returns true if any subscriber is currently receiving events
+func (b *EventBus) HasSubscribers() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.subscribers) > 0
+}
+
+// Subscribe calls [f] with every event published from now on until [ctx] is done or [f] returns an error.
+func (b *EventBus) Subscribe(ctx context.Context, f func(Event) error) error {
+	subscriber := &eventSubscriber{events: make(chan Event, eventBufferSize)}
+	b.lock.Lock()
+	b.subscribers[subscriber] = struct{}{}
+	b.lock.Unlock()
+
+	defer func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		if _, ok := b.subscribers[subscriber]; ok {
+			delete(b.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-subscriber.events:
+			if !ok {
+				return subscriber.err
+			}
+			if err := f(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
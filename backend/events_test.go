@@ -0,0 +1,169 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+// watchEvents subscribes to the events of [orchestrator] and returns a channel that receives every event apart from
+// health events, which depend on the timing of the health monitor. Returns once the subscription is active.
+func watchEvents(ctx context.Context, t *testing.T, orchestrator backend.NetworkOrchestrator) <-chan backend.Event {
+	t.Helper()
+
+	watcher, ok := orchestrator.(backend.EventWatcher)
+	if !ok {
+		t.Fatal("expected orchestrator to publish events")
+	}
+	events := make(chan backend.Event, 1024)
+	go func() {
+		_ = watcher.WatchEvents(ctx, func(event backend.Event) error {
+			if event.Type != backend.EventNodeHealthy && event.Type != backend.EventNodeUnhealthy {
+				events <- event
+			}
+			return nil
+		})
+	}()
+
+	// Create networks until one of them is observed, so that no events are missed once this returns.
+	for {
+		network, err := orchestrator.CreateNetwork("subscribed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := network.Teardown(ctx); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case event := <-events:
+			// The subscription may have started between creating and tearing down the network.
+			for event.Type != backend.EventNetworkTornDown {
+				event = <-events
+			}
+			return events
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// expectEvents fails the test unless the next events received from [events] match [expected] in order.
+func expectEvents(t *testing.T, events <-chan backend.Event, expected ...backend.Event) {
+	t.Helper()
+
+	for _, expectedEvent := range expected {
+		select {
+		case event := <-events:
+			assert.Equal(t, expectedEvent.Type, event.Type)
+			assert.Equal(t, expectedEvent.Network, event.Network)
+			assert.Equal(t, expectedEvent.Node, event.Node)
+			assert.False(t, event.Time.IsZero())
+		case <-time.After(deadlockTimeout):
+			t.Fatalf("timed out waiting for event %s", expectedEvent.Type)
+		}
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	events := watchEvents(ctx, t, orchestrator)
+
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"node0", "node1"} {
+		if _, err := network.AddNode(ctx, nodeConfig(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectEvents(t, events,
+		backend.Event{Type: backend.EventNetworkCreated, Network: "network"},
+		backend.Event{Type: backend.EventNodeAdded, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeStarted, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeAdded, Network: "network", Node: "node1"},
+		backend.Event{Type: backend.EventNodeStarted, Network: "network", Node: "node1"},
+	)
+
+	node, err := network.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Restart(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events,
+		backend.Event{Type: backend.EventNodePaused, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeResumed, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeStopped, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeStarted, Network: "network", Node: "node0"},
+	)
+
+	// A crash is published as soon as it happens, without polling the node.
+	constructor, _ := fake.Network("network")
+	constructor.Nodes()[1].Crash()
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeCrashed, Network: "network", Node: "node1"})
+
+	if err := network.RemoveNode("node0", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(ctx))
+	expectEvents(t, events,
+		backend.Event{Type: backend.EventNodeStopped, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNetworkTornDown, Network: "network"},
+	)
+	assert.NoError(orchestrator.Teardown(ctx))
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %s", event.Type)
+	default:
+	}
+}
+
+func TestEventBusDropsSlowSubscriber(t *testing.T) {
+	bus := backend.NewEventBus()
+	subscribed := make(chan struct{})
+	blocked := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- bus.Subscribe(context.Background(), func(event backend.Event) error {
+			if event.Type == backend.EventNetworkCreated {
+				close(subscribed)
+				return nil
+			}
+			<-blocked
+			return nil
+		})
+	}()
+	for !bus.HasSubscribers() {
+		time.Sleep(time.Millisecond)
+	}
+	bus.Publish(backend.Event{Type: backend.EventNetworkCreated})
+	<-subscribed
+
+	// Publishing must never block, even though the subscriber stopped reading.
+	for i := 0; i < 2048; i++ {
+		bus.Publish(backend.Event{Type: backend.EventNodeAdded})
+	}
+	close(blocked)
+	assert.Error(t, <-errs)
+	assert.False(t, bus.HasSubscribers())
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package fakebackend provides a scriptable backend.OrchestratorBackend, which tracks its networks and nodes in
+// memory, so that orchestration logic can be tested without running any nodes.
+package fakebackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+)
+
+var (
+	_ backend.OrchestratorBackend = &Backend{}
+	_ backend.NetworkConstructor  = &NetworkConstructor{}
+	_ backend.SnapshotRestorer    = &Backend{}
+	_ backend.Snapshotter         = &NetworkConstructor{}
+	_ backend.DiskUsageReporter   = &NetworkConstructor{}
+	_ backend.FaultInjector       = &NetworkConstructor{}
+	_ backend.NodeUpdater         = &NetworkConstructor{}
+
+	errBackendTornDown = errors.New("backend has been torn down")
+)
+
+// Hooks are called before the corresponding operation of the fake backend, which fails with the returned error if
+// it is non-nil. A hook can block to inject a delay. Nil hooks are skipped.
+type Hooks struct {
+	CreateNetwork   func(name string) error
+	AddNode         func(ctx context.Context, network string, config backend.NodeConfig) error
+	StopNode        func(network string, node string) error
+	TeardownNetwork func(ctx context.Context, network string) error
+	Snapshot        func(ctx context.Context, network string, snapshot string) error
+	RestoreSnapshot func(ctx context.Context, snapshot string, network string) error
+	Teardown        func(ctx context.Context) error
+}
+
+// Backend is a fake backend.OrchestratorBackend
+type Backend struct {
+	hooks Hooks
+
+	lock     sync.Mutex
+	networks map[string]*NetworkConstructor
+	// snapshots maps the name of each snapshot to the network it was taken of
+	snapshots map[string]snapshot
+	tornDown  bool
+}
+
+// snapshot is the state of a network saved by Snapshot
+type snapshot struct {
+	network string
+	configs []backend.NodeConfig
+}
+
+// New returns a fake backend that calls [hooks] before each operation
+func New(hooks Hooks) *Backend {
+	return &Backend{
+		hooks:     hooks,
+		networks:  make(map[string]*NetworkConstructor),
+		snapshots: make(map[string]snapshot),
+	}
+}
+
+func (b *Backend) CreateNetworkConstructor(name string) (backend.NetworkConstructor, error) {
+	if b.hooks.CreateNetwork != nil {
+		if err := b.hooks.CreateNetwork(name); err != nil {
+			return nil, err
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.tornDown {
+		return nil, errBackendTornDown
+	}
+	if network, exists := b.networks[name]; exists && !network.TornDown() {
+		return nil, fmt.Errorf("network %s already exists in the backend", name)
+	}
+	return b.createNetwork(name), nil
+}
+
+// createNetwork creates and tracks the network constructor [name]. Assumes the lock is held.
+func (b *Backend) createNetwork(name string) *NetworkConstructor {
+	network := &NetworkConstructor{
+		backend: b,
+		name:    name,
+	}
+	b.networks[name] = network
+	return network
+}
+
+// ListSnapshots returns the names of the snapshots taken of any network of the backend
+func (b *Backend) ListSnapshots(ctx context.Context) ([]string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	names := make([]string, 0, len(b.snapshots))
+	for name := range b.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RestoreSnapshotConstructor creates the network [name], or the network the snapshot was taken of if [name] is empty,
+// with a running node for each node that was running when the snapshot was taken.
+func (b *Backend) RestoreSnapshotConstructor(ctx context.Context, snapshotName string, name string) (string, backend.NetworkConstructor, []backend.Node, error) {
+	if b.hooks.RestoreSnapshot != nil {
+		if err := b.hooks.RestoreSnapshot(ctx, snapshotName, name); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.tornDown {
+		return "", nil, nil, errBackendTornDown
+	}
+	snapshot, exists := b.snapshots[snapshotName]
+	if !exists {
+		return "", nil, nil, fmt.Errorf("snapshot %s does not exist", snapshotName)
+	}
+	if name == "" {
+		name = snapshot.network
+	}
+	if network, exists := b.networks[name]; exists && !network.TornDown() {
+		return "", nil, nil, fmt.Errorf("network %s already exists in the backend", name)
+	}
+	network := b.createNetwork(name)
+	nodes := make([]backend.Node, 0, len(snapshot.configs))
+	for _, config := range snapshot.configs {
+		node := newNode(b, name, config)
+		network.nodes = append(network.nodes, node)
+		nodes = append(nodes, node)
+	}
+	return name, network, nodes, nil
+}
+
+// saveSnapshot saves [configs] as the snapshot [name] of [network]
+func (b *Backend) saveSnapshot(name string, network string, configs []backend.NodeConfig) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, exists := b.snapshots[name]; exists {
+		return fmt.Errorf("snapshot %s already exists", name)
+	}
+	b.snapshots[name] = snapshot{network: network, configs: configs}
+	return nil
+}
+
+func (b *Backend) Teardown(ctx context.Context) error {
+	if b.hooks.Teardown != nil {
+		if err := b.hooks.Teardown(ctx); err != nil {
+			return err
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.tornDown {
+		return errBackendTornDown
+	}
+	b.tornDown = true
+	return nil
+}
+
+// TornDown returns true if the backend has been torn down
+func (b *Backend) TornDown() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.tornDown
+}
+
+// Network returns the most recent network constructor created under [name]
+func (b *Backend) Network(name string) (*NetworkConstructor, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	network, exists := b.networks[name]
+	return network, exists
+}
+
+// NumNetworks returns the number of networks that have been created and not torn down
+func (b *Backend) NumNetworks() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	numNetworks := 0
+	for _, network := range b.networks {
+		if !network.TornDown() {
+			numNetworks++
+		}
+	}
+	return numNetworks
+}
@@ -0,0 +1,201 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package fakebackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+)
+
+// NetworkConstructor is a fake backend.NetworkConstructor, which keeps track of every node it has created.
+// Like the real backends, it does not prevent nodes from being added after it has been torn down, so that nodes
+// leaked by the network can be detected.
+type NetworkConstructor struct {
+	backend *Backend
+	name    string
+
+	lock      sync.Mutex
+	nodes     []*Node
+	teardowns int
+	diskUsage int64
+	// partitions and linkFaults record the faults injected into the network
+	partitions [][]string
+	linkFaults map[[2]string]backend.LinkFaults
+}
+
+func (c *NetworkConstructor) AddNode(ctx context.Context, config backend.NodeConfig) (backend.Node, error) {
+	if c.backend.hooks.AddNode != nil {
+		if err := c.backend.hooks.AddNode(ctx, c.name, config); err != nil {
+			return nil, err
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	node := newNode(c.backend, c.name, config)
+	c.nodes = append(c.nodes, node)
+	return node, nil
+}
+
+// UpdateNode applies [update] to the config of the running node [name] and restarts it
+func (c *NetworkConstructor) UpdateNode(ctx context.Context, name string, update backend.NodeUpdate, stopTimeout time.Duration) (backend.Node, error) {
+	var node *Node
+	for _, running := range c.RunningNodes() {
+		if running.GetName() == name {
+			node = running
+		}
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node %s is not running in network %s", name, c.name)
+	}
+	node.update(update)
+	if err := node.Restart(ctx, stopTimeout); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Snapshot saves the configs of the nodes that are running, which are recreated when the snapshot is restored
+func (c *NetworkConstructor) Snapshot(ctx context.Context, name string) error {
+	if c.backend.hooks.Snapshot != nil {
+		if err := c.backend.hooks.Snapshot(ctx, c.name, name); err != nil {
+			return err
+		}
+	}
+
+	nodes := c.RunningNodes()
+	configs := make([]backend.NodeConfig, 0, len(nodes))
+	for _, node := range nodes {
+		configs = append(configs, node.NodeConfig())
+	}
+	return c.backend.saveSnapshot(name, c.name, configs)
+}
+
+// DiskUsage returns the disk usage set by SetDiskUsage, which is 0 by default
+func (c *NetworkConstructor) DiskUsage(ctx context.Context) (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.diskUsage, nil
+}
+
+// SetDiskUsage sets the disk usage reported by the network to [bytes]
+func (c *NetworkConstructor) SetDiskUsage(bytes int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.diskUsage = bytes
+}
+
+// Partition records [partitions], which can be retrieved with Partitions
+func (c *NetworkConstructor) Partition(ctx context.Context, partitions [][]string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.partitions = partitions
+	return nil
+}
+
+// Heal clears the partitions and link faults of the network
+func (c *NetworkConstructor) Heal(ctx context.Context) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.partitions = nil
+	c.linkFaults = nil
+	return nil
+}
+
+// SetLinkFaults records [faults], which can be retrieved with LinkFaults
+func (c *NetworkConstructor) SetLinkFaults(ctx context.Context, from string, to string, faults backend.LinkFaults) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if faults == (backend.LinkFaults{}) {
+		delete(c.linkFaults, [2]string{from, to})
+		return nil
+	}
+	if c.linkFaults == nil {
+		c.linkFaults = make(map[[2]string]backend.LinkFaults)
+	}
+	c.linkFaults[[2]string{from, to}] = faults
+	return nil
+}
+
+// Partitions returns the partitions of the network, or nil if it is not partitioned
+func (c *NetworkConstructor) Partitions() [][]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.partitions
+}
+
+// LinkFaults returns the faults of the link from [from] to [to]
+func (c *NetworkConstructor) LinkFaults(from string, to string) backend.LinkFaults {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.linkFaults[[2]string{from, to}]
+}
+
+func (c *NetworkConstructor) Teardown(ctx context.Context) error {
+	if c.backend.hooks.TeardownNetwork != nil {
+		if err := c.backend.hooks.TeardownNetwork(ctx, c.name); err != nil {
+			return err
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.teardowns++
+	if c.teardowns > 1 {
+		return fmt.Errorf("network %s was torn down %d times", c.name, c.teardowns)
+	}
+	return nil
+}
+
+// TornDown returns true if the network constructor has been torn down
+func (c *NetworkConstructor) TornDown() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.teardowns > 0
+}
+
+// Teardowns returns the number of times the network constructor has been torn down
+func (c *NetworkConstructor) Teardowns() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.teardowns
+}
+
+// Nodes returns every node created by the network constructor, including nodes that have been stopped or that were
+// rejected by the network ie. due to a duplicate name.
+func (c *NetworkConstructor) Nodes() []*Node {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes := make([]*Node, len(c.nodes))
+	copy(nodes, c.nodes)
+	return nodes
+}
+
+// RunningNodes returns the nodes created by the network constructor that have not been stopped or crashed
+func (c *NetworkConstructor) RunningNodes() []*Node {
+	nodes := c.Nodes()
+	running := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if status := node.Status(); status != backend.NodeStopped && status != backend.NodeCrashed {
+			running = append(running, node)
+		}
+	}
+	return running
+}
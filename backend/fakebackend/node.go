@@ -0,0 +1,188 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package fakebackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+)
+
+var (
+	_ backend.Node           = &Node{}
+	_ backend.StatusNotifier = &Node{}
+
+	// ErrCrashed is the error that nodes report when they are crashed with Crash
+	ErrCrashed = errors.New("fake node crashed")
+)
+
+// Node is a fake backend.Node, which runs until it is stopped or crashed with Crash
+type Node struct {
+	backend *Backend
+	network string
+	config  backend.NodeConfig
+
+	lock   sync.Mutex
+	status backend.NodeStatus
+	notify func(backend.NodeStatus, error)
+	stops  int
+}
+
+func newNode(b *Backend, network string, config backend.NodeConfig) *Node {
+	return &Node{
+		backend: b,
+		network: network,
+		config:  config,
+		status:  backend.NodeRunning,
+	}
+}
+
+func (n *Node) GetName() string { return n.config.Name }
+
+func (n *Node) GetHTTPBaseURI() string {
+	return fmt.Sprintf("http://%s.%s.invalid", n.config.Name, n.network)
+}
+
+func (n *Node) GetBootstrapIP() string {
+	return fmt.Sprintf("%s.%s.invalid:9651", n.config.Name, n.network)
+}
+
+func (n *Node) Executable() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.config.Executable
+}
+
+func (n *Node) Config() map[string]interface{} {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return backend.CopyConfig(n.config.Config)
+}
+
+// NodeConfig returns the config the node is running with
+func (n *Node) NodeConfig() backend.NodeConfig {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.config
+}
+
+// update applies [update] to the config of the node. The name of the node never changes, so it is left in place to be
+// read without the lock.
+func (n *Node) update(update backend.NodeUpdate) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	updated := update.Apply(n.config)
+	n.config.Executable = updated.Executable
+	n.config.Config = updated.Config
+	n.config.Plugins = updated.Plugins
+	n.config.ChainConfigs = updated.ChainConfigs
+	n.config.SubnetConfigs = updated.SubnetConfigs
+}
+
+func (n *Node) Status() backend.NodeStatus {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.status
+}
+
+// Stop stops the node. The timeout is ignored, since a fake node stops immediately unless the StopNode hook blocks.
+func (n *Node) Stop(timeout time.Duration) error {
+	if n.backend.hooks.StopNode != nil {
+		if err := n.backend.hooks.StopNode(n.network, n.config.Name); err != nil {
+			return err
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.stops++
+	if n.status != backend.NodeCrashed {
+		n.setStatus(backend.NodeStopped, nil)
+	}
+	return nil
+}
+
+func (n *Node) Restart(ctx context.Context, stopTimeout time.Duration) error {
+	if err := n.Stop(stopTimeout); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.setStatus(backend.NodeRunning, nil)
+	return nil
+}
+
+func (n *Node) Pause() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.status != backend.NodeRunning {
+		return fmt.Errorf("cannot pause node %s with status %s", n.config.Name, n.status)
+	}
+	n.setStatus(backend.NodePaused, nil)
+	return nil
+}
+
+func (n *Node) Resume() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.status != backend.NodePaused {
+		return fmt.Errorf("cannot resume node %s with status %s", n.config.Name, n.status)
+	}
+	n.setStatus(backend.NodeRunning, nil)
+	return nil
+}
+
+func (n *Node) NotifyStatus(f func(status backend.NodeStatus, err error)) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.notify = f
+	f(n.status, nil)
+}
+
+// setStatus updates the status of the node and notifies the registered function. Assumes the lock is held.
+func (n *Node) setStatus(status backend.NodeStatus, err error) {
+	n.status = status
+	if n.notify != nil {
+		n.notify(status, err)
+	}
+}
+
+// Crash marks the node as crashed, as if it exited with an error without being asked to stop
+func (n *Node) Crash() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.setStatus(backend.NodeCrashed, ErrCrashed)
+}
+
+// Exit marks the node as crashed, as if it exited cleanly without being asked to stop
+func (n *Node) Exit() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.setStatus(backend.NodeCrashed, nil)
+}
+
+// StopCount returns the number of times the node has been stopped
+func (n *Node) StopCount() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.stops
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LinkFaults describes the network conditions applied to the traffic that one node sends to another. The zero value
+// is a healthy link.
+type LinkFaults struct {
+	// Latency delays all traffic on the link
+	Latency time.Duration `json:"latency,omitempty"`
+	// Jitter adds a random delay of up to Jitter on top of Latency. Traffic is never reordered.
+	Jitter time.Duration `json:"jitter,omitempty"`
+	// Bandwidth caps the throughput of the link in bytes per second if non-zero
+	Bandwidth int64 `json:"bandwidth,omitempty"`
+	// DropRate is the probability in [0, 1] that a chunk of traffic is dropped. Links carry TCP connections, which
+	// cannot lose data, so a drop resets the connection that carried the chunk.
+	DropRate float64 `json:"dropRate,omitempty"`
+}
+
+// Validate returns an error if the faults are out of range
+func (f LinkFaults) Validate() error {
+	switch {
+	case f.Latency < 0:
+		return fmt.Errorf("latency cannot be negative: %s", f.Latency)
+	case f.Jitter < 0:
+		return fmt.Errorf("jitter cannot be negative: %s", f.Jitter)
+	case f.Bandwidth < 0:
+		return fmt.Errorf("bandwidth cannot be negative: %d", f.Bandwidth)
+	case f.DropRate < 0 || f.DropRate > 1:
+		return fmt.Errorf("drop rate must be in [0, 1]: %v", f.DropRate)
+	}
+	return nil
+}
+
+// String describes the faults ie. "latency=100ms jitter=10ms"
+func (f LinkFaults) String() string {
+	parts := []string{}
+	if f.Latency > 0 {
+		parts = append(parts, fmt.Sprintf("latency=%s", f.Latency))
+	}
+	if f.Jitter > 0 {
+		parts = append(parts, fmt.Sprintf("jitter=%s", f.Jitter))
+	}
+	if f.Bandwidth > 0 {
+		parts = append(parts, fmt.Sprintf("bandwidth=%dB/s", f.Bandwidth))
+	}
+	if f.DropRate > 0 {
+		parts = append(parts, fmt.Sprintf("drop-rate=%v", f.DropRate))
+	}
+	if len(parts) == 0 {
+		return "healthy"
+	}
+	return strings.Join(parts, " ")
+}
+
+// FaultInjector is an optional interface that a NetworkConstructor can implement to disrupt the connections between
+// the nodes of its network.
+type FaultInjector interface {
+	// Partition splits the nodes of the network into [partitions], so that nodes can only communicate with the nodes
+	// in their own partition. The nodes that are not listed, including nodes added later, form one additional
+	// partition. Replaces any previous partitions.
+	Partition(ctx context.Context, partitions [][]string) error
+	// Heal removes all partitions and link faults from the network
+	Heal(ctx context.Context) error
+	// SetLinkFaults applies [faults] to the traffic sent from the node [from] to the node [to], replacing any previous
+	// faults of the link. The zero value of LinkFaults restores the link.
+	SetLinkFaults(ctx context.Context, from string, to string, faults LinkFaults) error
+}
+
+// faultInjector returns the FaultInjector of the network constructor
+func (backend *networkBackend) faultInjector() (FaultInjector, error) {
+	injector, ok := backend.network.(FaultInjector)
+	if !ok {
+		return nil, fmt.Errorf("network %s does not support fault injection", backend.name)
+	}
+	return injector, nil
+}
+
+// Partition splits the nodes of the network into [partitions] if its constructor implements FaultInjector. Every node
+// must exist and may only be listed once.
+func (backend *networkBackend) Partition(ctx context.Context, partitions [][]string) error {
+	injector, err := backend.faultInjector()
+	if err != nil {
+		return err
+	}
+
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+
+	if backend.tornDown {
+		return fmt.Errorf("cannot partition torn down network: %s", backend.name)
+	}
+	seen := make(map[string]struct{})
+	descriptions := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		if len(partition) == 0 {
+			return fmt.Errorf("cannot create empty partition in network %s", backend.name)
+		}
+		for _, name := range partition {
+			if _, ok := backend.nodes[name]; !ok {
+				return fmt.Errorf("cannot partition missing node %s in network %s", name, backend.name)
+			}
+			if _, ok := seen[name]; ok {
+				return fmt.Errorf("node %s is listed in more than one partition", name)
+			}
+			seen[name] = struct{}{}
+		}
+		descriptions = append(descriptions, "["+strings.Join(partition, ",")+"]")
+	}
+	if err := injector.Partition(ctx, partitions); err != nil {
+		return err
+	}
+	backend.events.Publish(Event{Type: EventNetworkPartitioned, Network: backend.name, Message: strings.Join(descriptions, " ")})
+	return nil
+}
+
+// Heal removes all partitions and link faults from the network if its constructor implements FaultInjector.
+func (backend *networkBackend) Heal(ctx context.Context) error {
+	injector, err := backend.faultInjector()
+	if err != nil {
+		return err
+	}
+
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+
+	if backend.tornDown {
+		return fmt.Errorf("cannot heal torn down network: %s", backend.name)
+	}
+	if err := injector.Heal(ctx); err != nil {
+		return err
+	}
+	backend.events.Publish(Event{Type: EventNetworkHealed, Network: backend.name})
+	return nil
+}
+
+// SetLinkFaults applies [faults] to the traffic sent from [from] to [to] if the network constructor implements
+// FaultInjector.
+func (backend *networkBackend) SetLinkFaults(ctx context.Context, from string, to string, faults LinkFaults) error {
+	injector, err := backend.faultInjector()
+	if err != nil {
+		return err
+	}
+	if err := faults.Validate(); err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("cannot set faults of link from node %s to itself", from)
+	}
+
+	backend.lock.RLock()
+	defer backend.lock.RUnlock()
+
+	if backend.tornDown {
+		return fmt.Errorf("cannot set link faults of torn down network: %s", backend.name)
+	}
+	for _, name := range []string{from, to} {
+		if _, ok := backend.nodes[name]; !ok {
+			return fmt.Errorf("cannot set link faults of missing node %s in network %s", name, backend.name)
+		}
+	}
+	if err := injector.SetLinkFaults(ctx, from, to, faults); err != nil {
+		return err
+	}
+	backend.events.Publish(Event{Type: EventLinkFaultsSet, Network: backend.name, Node: from, Message: fmt.Sprintf("%s -> %s: %s", from, to, faults)})
+	return nil
+}
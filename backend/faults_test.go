@@ -0,0 +1,58 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjection(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"node0", "node1", "node2"} {
+		if _, err := network.AddNode(ctx, nodeConfig(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	constructor, ok := fake.Network("network")
+	if !ok {
+		t.Fatal("network constructor not found")
+	}
+
+	assert.Error(network.Partition(ctx, [][]string{{"node0"}, {"node3"}}), "expected partition of missing node to fail")
+	assert.Error(network.Partition(ctx, [][]string{{"node0"}, {"node0", "node1"}}), "expected node in two partitions to fail")
+	assert.Error(network.Partition(ctx, [][]string{{}}), "expected empty partition to fail")
+	assert.Nil(constructor.Partitions())
+	assert.NoError(network.Partition(ctx, [][]string{{"node0"}, {"node1"}}))
+	assert.Equal([][]string{{"node0"}, {"node1"}}, constructor.Partitions())
+
+	faults := backend.LinkFaults{Latency: 100 * time.Millisecond, Jitter: 10 * time.Millisecond, Bandwidth: 1024, DropRate: 0.1}
+	assert.Error(network.SetLinkFaults(ctx, "node0", "node0", faults), "expected link to itself to fail")
+	assert.Error(network.SetLinkFaults(ctx, "node0", "node3", faults), "expected link to missing node to fail")
+	assert.Error(network.SetLinkFaults(ctx, "node0", "node1", backend.LinkFaults{DropRate: 2}), "expected invalid faults to fail")
+	assert.NoError(network.SetLinkFaults(ctx, "node0", "node1", faults))
+	assert.Equal(faults, constructor.LinkFaults("node0", "node1"))
+	assert.Equal(backend.LinkFaults{}, constructor.LinkFaults("node1", "node0"), "expected faults to only apply in one direction")
+
+	assert.NoError(network.Heal(ctx))
+	assert.Nil(constructor.Partitions())
+	assert.Equal(backend.LinkFaults{}, constructor.LinkFaults("node0", "node1"))
+
+	assert.NoError(network.Teardown(ctx))
+	assert.Error(network.Heal(ctx), "expected heal of torn down network to fail")
+}
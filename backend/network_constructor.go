@@ -13,7 +13,13 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-var _ Network = &networkBackend{}
+// healthMonitorInterval is the interval at which the health of each running node is checked while events are watched
+const healthMonitorInterval = 2 * time.Second
+
+var (
+	_ Network           = &networkBackend{}
+	_ ExecutableChecker = &networkBackend{}
+)
 
 // NetworkConstructor provides a thread safe interface for adding new nodes to a specific network
 // Note: a NetworkConstructor is created as a network specific instance and used to implement a more
@@ -25,21 +31,40 @@ type NetworkConstructor interface {
 
 type networkBackend struct {
 	lock sync.RWMutex
+	// snapshotLock is held for writing while a snapshot is taken and for reading while nodes are added, removed or
+	// updated and while the network is torn down, so that the nodes of the network do not change during a snapshot
+	// without blocking readers of the network. Must be grabbed before [lock].
+	snapshotLock sync.RWMutex
 
 	name    string
 	network NetworkConstructor
 
 	removeNetwork func() error
 	nodes         map[string]Node
+	events        *EventBus
+	// watchers cancels the health monitor and any pending restart of each node
+	watchers map[string]context.CancelFunc
+	// tornDown is set once the network has been torn down, so that nodes that finish starting afterwards are stopped
+	// instead of being tracked by the removed network.
+	tornDown bool
 }
 
-func newNetwork(name string, constructor NetworkConstructor, removeNetwork func() error) Network {
-	return &networkBackend{
+// newNetwork creates a network backed by [constructor]. [nodes] contains any nodes that are already running
+// in the network and should be tracked from the start. Changes to the network are published to [events].
+func newNetwork(name string, constructor NetworkConstructor, nodes []Node, events *EventBus, removeNetwork func() error) *networkBackend {
+	backend := &networkBackend{
 		name:          name,
 		network:       constructor,
 		removeNetwork: removeNetwork,
-		nodes:         make(map[string]Node),
+		nodes:         make(map[string]Node, len(nodes)),
+		events:        events,
+		watchers:      make(map[string]context.CancelFunc, len(nodes)),
+	}
+	for _, node := range nodes {
+		backend.nodes[node.GetName()] = node
+		backend.watchNode(node, false, nil)
 	}
+	return backend
 }
 
 func (backend *networkBackend) GetName() string {
@@ -70,17 +95,46 @@ func (backend *networkBackend) GetNode(name string) (Node, error) {
 }
 
 func (backend *networkBackend) AddNode(ctx context.Context, config NodeConfig) (Node, error) {
+	if config.Readiness != nil {
+		if err := config.Readiness.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if config.RestartPolicy != nil {
+		if err := config.RestartPolicy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateConfigFiles(config.ChainConfigs, config.SubnetConfigs); err != nil {
+		return nil, err
+	}
+
+	backend.snapshotLock.RLock()
+	defer backend.snapshotLock.RUnlock()
+
 	node, err := backend.network.AddNode(ctx, config)
 	if err != nil {
 		return nil, err
 	}
+	if config.Readiness != nil {
+		if err := backend.awaitReady(ctx, node, *config.Readiness); err != nil {
+			if stopErr := node.Stop(10 * time.Second); stopErr != nil {
+				zap.L().Error("failed to stop node", zap.String("name", config.Name), zap.Error(stopErr))
+			}
+			return nil, err
+		}
+	}
 
 	// Grab the lock after creating the node to allow parallelization on startup
 	backend.lock.Lock()
 	defer backend.lock.Unlock()
 
-	_, exists := backend.nodes[config.Name]
-	if exists {
+	if backend.tornDown {
+		err = fmt.Errorf("cannot add node %s to torn down network: %s", config.Name, backend.name)
+	} else if _, exists := backend.nodes[config.Name]; exists {
+		err = fmt.Errorf("cannot create duplicate node under name: %s", config.Name)
+	}
+	if err != nil {
 		// Start a goroutine to shut down the node, so we don't need to block here while
 		// holding the lock.
 		// We're going to return the original source of the error anyways.
@@ -89,14 +143,148 @@ func (backend *networkBackend) AddNode(ctx context.Context, config NodeConfig) (
 				zap.L().Error("failed to stop node", zap.String("name", config.Name))
 			}
 		}()
-		return nil, fmt.Errorf("cannot create duplicate node under name: %s", config.Name)
+		return nil, err
 	}
 
 	backend.nodes[config.Name] = node
+	backend.events.Publish(Event{Type: EventNodeAdded, Network: backend.name, Node: config.Name})
+	backend.watchNode(node, true, config.RestartPolicy)
 	return node, nil
 }
 
+// watchNode publishes the status changes and health of [node] as events. If [publishCurrent] is true, the current
+// status of the node is published as well. If [policy] is non-nil, the node is restarted according to it when it
+// crashes, which requires the node to implement StatusNotifier. Otherwise, the policy reported by the node is used if it
+// implements RestartPolicyReporter. Assumes the lock is held.
+func (backend *networkBackend) watchNode(node Node, publishCurrent bool, policy *RestartPolicy) {
+	name := node.GetName()
+	if reporter, ok := node.(RestartPolicyReporter); ok && policy == nil {
+		policy = reporter.RestartPolicy()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	backend.watchers[name] = cancel
+
+	if notifier, ok := node.(StatusNotifier); ok {
+		var restarter *nodeRestarter
+		if policy != nil {
+			restarter = &nodeRestarter{backend: backend, node: node, policy: *policy, ctx: ctx}
+		}
+		notifier.NotifyStatus(backend.statusListener(name, publishCurrent, restarter))
+	} else if publishCurrent && node.Status() == NodeRunning {
+		backend.events.Publish(Event{Type: EventNodeStarted, Network: backend.name, Node: name})
+	}
+	go backend.monitorHealth(ctx, node)
+}
+
+// unwatchNode stops monitoring the health of [node], cancels any pending restart, and publishes that it stopped if
+// the node cannot report it itself. Assumes the lock is held.
+func (backend *networkBackend) unwatchNode(node Node) {
+	name := node.GetName()
+	if cancel, ok := backend.watchers[name]; ok {
+		cancel()
+		delete(backend.watchers, name)
+	}
+	if _, ok := node.(StatusNotifier); !ok {
+		backend.events.Publish(Event{Type: EventNodeStopped, Network: backend.name, Node: name})
+	}
+}
+
+// statusListener returns a function that publishes the status changes of the node [name] as events and passes crashes
+// on to [restarter] if it is non-nil. The first status reported is the current status of the node, which is only
+// published if [publishCurrent] is true.
+func (backend *networkBackend) statusListener(name string, publishCurrent bool, restarter *nodeRestarter) func(NodeStatus, error) {
+	var (
+		lock     sync.Mutex
+		first    = true
+		previous NodeStatus
+	)
+	return func(status NodeStatus, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		changed := !first
+		publish := changed || publishCurrent
+		first = false
+		event := Event{Network: backend.name, Node: name}
+		switch status {
+		case NodeRunning:
+			event.Type = EventNodeStarted
+			if previous == NodePaused {
+				event.Type = EventNodeResumed
+			}
+		case NodePaused:
+			event.Type = EventNodePaused
+		case NodeStopped:
+			event.Type = EventNodeStopped
+		case NodeCrashed:
+			event.Type = EventNodeCrashed
+		default:
+			publish = false
+		}
+		previous = status
+		if !publish {
+			return
+		}
+		if err != nil {
+			event.Message = err.Error()
+		}
+		backend.events.Publish(event)
+		if changed && status == NodeCrashed && restarter != nil {
+			restarter.crashed(err)
+		}
+	}
+}
+
+// monitorHealth publishes an event each time the health of [node] changes until [ctx] is done. The node is only checked
+// while it is running and the events are being watched.
+func (backend *networkBackend) monitorHealth(ctx context.Context, node Node) {
+	ticker := time.NewTicker(healthMonitorInterval)
+	defer ticker.Stop()
+
+	known, healthy := false, false
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !backend.events.HasSubscribers() || node.Status() != NodeRunning {
+			known = false
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, healthMonitorInterval)
+		err := probeReadiness(probeCtx, node, ReadinessConfig{Level: ReadinessHealthy})
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if known && healthy == (err == nil) {
+			continue
+		}
+		known, healthy = true, err == nil
+
+		event := Event{Type: EventNodeHealthy, Network: backend.name, Node: node.GetName()}
+		if err != nil {
+			event.Type = EventNodeUnhealthy
+			event.Message = err.Error()
+		}
+		backend.events.Publish(event)
+	}
+}
+
+// awaitReady waits for [node] to reach the readiness level of [config], using the network constructor's probe if it
+// provides one.
+func (backend *networkBackend) awaitReady(ctx context.Context, node Node, config ReadinessConfig) error {
+	if prober, ok := backend.network.(ReadinessProber); ok {
+		return prober.AwaitReady(ctx, node, config)
+	}
+	return AwaitReady(ctx, node, config)
+}
+
 func (backend *networkBackend) RemoveNode(name string, timeout time.Duration) error {
+	backend.snapshotLock.RLock()
+	defer backend.snapshotLock.RUnlock()
 	backend.lock.Lock()
 	defer backend.lock.Unlock()
 
@@ -105,17 +293,43 @@ func (backend *networkBackend) RemoveNode(name string, timeout time.Duration) er
 		return fmt.Errorf("cannot remove non-existent node: %s", name)
 	}
 	delete(backend.nodes, name)
+	// Stop watching the node first, so that a restart in progress is cancelled rather than racing with Stop.
+	backend.unwatchNode(node)
 	return node.Stop(timeout)
 }
 
+// syncNodes replaces the tracked nodes with [nodes], which reflect the current state of the network in the backend.
+func (backend *networkBackend) syncNodes(nodes []Node) {
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+
+	for _, cancel := range backend.watchers {
+		cancel()
+	}
+	backend.nodes = make(map[string]Node, len(nodes))
+	backend.watchers = make(map[string]context.CancelFunc, len(nodes))
+	for _, node := range nodes {
+		backend.nodes[node.GetName()] = node
+		backend.watchNode(node, false, nil)
+	}
+}
+
 func (backend *networkBackend) Teardown(ctx context.Context) error {
+	backend.snapshotLock.RLock()
+	defer backend.snapshotLock.RUnlock()
 	backend.lock.Lock()
 	defer backend.lock.Unlock()
 
+	if backend.tornDown {
+		return fmt.Errorf("cannot teardown network %s more than once", backend.name)
+	}
+
 	// Shut down all of the nodes in the network before calling teardown on the constructor
 	eg := errgroup.Group{}
 	for name, node := range backend.nodes {
 		node := node
+		// Stop watching the node first, so that a restart in progress is cancelled rather than racing with Stop.
+		backend.unwatchNode(node)
 		eg.Go(func() error {
 			return node.Stop(10 * time.Second)
 		})
@@ -139,5 +353,6 @@ func (backend *networkBackend) Teardown(ctx context.Context) error {
 		return err
 	}
 
+	backend.tornDown = true
 	return nil
 }
@@ -0,0 +1,39 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+// LogStream identifies one of the output streams of a node
+type LogStream string
+
+const (
+	Stdout LogStream = "stdout"
+	Stderr LogStream = "stderr"
+)
+
+// ParseLogStream returns the LogStream named [name]. An empty name defaults to Stdout.
+func ParseLogStream(name string) (LogStream, error) {
+	switch LogStream(name) {
+	case "", Stdout:
+		return Stdout, nil
+	case Stderr:
+		return Stderr, nil
+	default:
+		return "", fmt.Errorf("unknown log stream %q, expected %q or %q", name, Stdout, Stderr)
+	}
+}
+
+// NodeLogger is an optional interface implemented by nodes that capture the output of their process.
+type NodeLogger interface {
+	// GetLogs returns the last [lines] lines written by the node to [stream]. If [lines] is 0, every retained line
+	// is returned.
+	GetLogs(ctx context.Context, stream LogStream, lines int) ([]string, error)
+	// TailLogs calls [f] with each line written by the node to [stream] from now on, until the node process exits,
+	// [ctx] is done, or [f] returns an error.
+	TailLogs(ctx context.Context, stream LogStream, f func(line string) error) error
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// NodeStatus describes where a node is in its lifecycle
+type NodeStatus int
+
+const (
+	// NodeStarting indicates the node has been started, but has not finished starting up yet
+	NodeStarting NodeStatus = iota
+	// NodeRunning indicates the node is running
+	NodeRunning
+	// NodeStopped indicates the node was stopped intentionally
+	NodeStopped
+	// NodePaused indicates the node has been suspended and can be resumed
+	NodePaused
+	// NodeCrashed indicates the node exited without being asked to stop
+	NodeCrashed
+	// NodeUnknown indicates the status of the node could not be determined ie. because a remote node did not report a
+	// valid status
+	NodeUnknown
+)
+
+var nodeStatusNames = map[NodeStatus]string{
+	NodeStarting: "starting",
+	NodeRunning:  "running",
+	NodeStopped:  "stopped",
+	NodePaused:   "paused",
+	NodeCrashed:  "crashed",
+	NodeUnknown:  "unknown",
+}
+
+func (s NodeStatus) String() string {
+	if name, ok := nodeStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// ParseNodeStatus returns the NodeStatus corresponding to [name], which must be the result of calling String on a NodeStatus.
+func ParseNodeStatus(name string) (NodeStatus, error) {
+	for status, statusName := range nodeStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown node status: %q", name)
+}
+
+// NodeStatusTracker tracks the status of a node that runs until it is asked to stop or crashes, and notifies the
+// function registered with NotifyStatus of every change. It is embedded by the nodes of the backends, which protect it
+// with their own lock, so that the status changes atomically with the rest of their state. Unless noted otherwise,
+// its methods assume the lock passed to NewNodeStatusTracker is held.
+type NodeStatusTracker struct {
+	lock sync.Locker
+	// onChange is called with every status change after notifying the registered function if non-nil
+	onChange func()
+
+	status NodeStatus
+	// notify is called with every status change if non-nil
+	notify func(NodeStatus, error)
+	// stopRequested is set when the node is asked to stop, so that an exit can be distinguished from a crash.
+	stopRequested bool
+	nodeStopped   chan struct{}
+	stopErr       error
+}
+
+// NewNodeStatusTracker returns a NodeStatusTracker protected by [lock]. [onChange] is called with every status change
+// if non-nil.
+func NewNodeStatusTracker(lock sync.Locker, onChange func()) NodeStatusTracker {
+	return NodeStatusTracker{
+		lock:     lock,
+		onChange: onChange,
+	}
+}
+
+// NotifyStatus registers [f] to be called with every status change and calls it with the current status. Acquires the
+// lock.
+func (t *NodeStatusTracker) NotifyStatus(f func(status NodeStatus, err error)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.notify = f
+	f(t.status, nil)
+}
+
+// CurrentStatus returns the status of the node
+func (t *NodeStatusTracker) CurrentStatus() NodeStatus { return t.status }
+
+// SetStatus updates the status of the node and notifies the registered function
+func (t *NodeStatusTracker) SetStatus(status NodeStatus, err error) {
+	t.status = status
+	if t.notify != nil {
+		t.notify(status, err)
+	}
+	if t.onChange != nil {
+		t.onChange()
+	}
+}
+
+// StartRun marks the start of a new run of the node with [status] and returns the channel that is closed once the run
+// has exited.
+func (t *NodeStatusTracker) StartRun(status NodeStatus) chan struct{} {
+	nodeStopped := make(chan struct{})
+	t.SetStatus(status, nil)
+	t.stopRequested = false
+	t.nodeStopped = nodeStopped
+	t.stopErr = nil
+	return nodeStopped
+}
+
+// MarkRunning marks the node as running if the run that exits with [nodeStopped] is still starting
+func (t *NodeStatusTracker) MarkRunning(nodeStopped chan struct{}) {
+	if t.nodeStopped == nodeStopped && t.status == NodeStarting {
+		t.SetStatus(NodeRunning, nil)
+	}
+}
+
+// RequestStop records that the node has been asked to stop and returns the channel that is closed once the current run
+// has exited. Returns false if the node is not running.
+func (t *NodeStatusTracker) RequestStop() (chan struct{}, bool) {
+	if t.status == NodeStopped || t.status == NodeCrashed {
+		return nil, false
+	}
+	t.stopRequested = true
+	return t.nodeStopped, true
+}
+
+// Exited marks the node [name] as stopped or crashed with [err] depending on whether it was asked to stop, and closes
+// [nodeStopped].
+func (t *NodeStatusTracker) Exited(name string, err error, nodeStopped chan struct{}) {
+	if t.stopRequested {
+		zap.L().Debug("node stopped", zap.String("name", name))
+		t.SetStatus(NodeStopped, nil)
+	} else {
+		zap.L().Error("node crashed", zap.String("name", name), zap.Error(err))
+		t.SetStatus(NodeCrashed, err)
+	}
+	t.stopErr = err
+	close(nodeStopped)
+}
+
+// MarkCrashed marks the node as crashed with [err] without a run, so that it can be restarted or removed
+func (t *NodeStatusTracker) MarkCrashed(err error) {
+	nodeStopped := make(chan struct{})
+	close(nodeStopped)
+	t.nodeStopped = nodeStopped
+	t.stopErr = err
+	t.SetStatus(NodeCrashed, err)
+}
+
+// StopRequested returns true if the node has been asked to stop since its current run started
+func (t *NodeStatusTracker) StopRequested() bool { return t.stopRequested }
+
+// StopErr returns the error that the last run exited with
+func (t *NodeStatusTracker) StopErr() error { return t.stopErr }
+
+// Stopped returns the channel that is closed once the current run of the node has exited
+func (t *NodeStatusTracker) Stopped() chan struct{} { return t.nodeStopped }
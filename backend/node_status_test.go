@@ -0,0 +1,54 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeStatusTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		lock     sync.Mutex
+		statuses []backend.NodeStatus
+		changes  int
+	)
+	tracker := backend.NewNodeStatusTracker(&lock, func() { changes++ })
+	tracker.NotifyStatus(func(status backend.NodeStatus, err error) {
+		statuses = append(statuses, status)
+	})
+
+	// An exit that was not requested is a crash.
+	nodeStopped := tracker.StartRun(backend.NodeStarting)
+	tracker.MarkRunning(nodeStopped)
+	tracker.Exited("node", errInjected, nodeStopped)
+	assert.Equal(backend.NodeCrashed, tracker.CurrentStatus())
+	assert.ErrorIs(tracker.StopErr(), errInjected)
+	_, running := tracker.RequestStop()
+	assert.False(running, "expected a crashed node not to be stopped")
+
+	// A requested exit is a stop, and a node that has exited is not marked as running.
+	nodeStopped = tracker.StartRun(backend.NodeStarting)
+	stopping, running := tracker.RequestStop()
+	assert.True(running)
+	tracker.Exited("node", nil, nodeStopped)
+	<-stopping
+	tracker.MarkRunning(nodeStopped)
+	assert.Equal(backend.NodeStopped, tracker.CurrentStatus())
+
+	assert.Equal([]backend.NodeStatus{
+		backend.NodeStarting,
+		backend.NodeStarting,
+		backend.NodeRunning,
+		backend.NodeCrashed,
+		backend.NodeStarting,
+		backend.NodeStopped,
+	}, statuses)
+	assert.Equal(5, changes)
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NodeUpdate is a change to the config of a node, which takes effect when the node is restarted by UpdateNode
+type NodeUpdate struct {
+	// Executable replaces the executable of the node if non-empty
+	Executable string `json:"executable,omitempty"`
+	// Config is merged into the config of the node. A key with a nil value is removed from the config.
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Plugins is merged into the plugins of the node. A VM ID with an empty executable is removed from the plugins.
+	Plugins map[string]string `json:"plugins,omitempty"`
+	// ChainConfigs is merged into the chain configs of the node. A chain with neither a config nor an upgrade is
+	// removed from the chain configs.
+	ChainConfigs map[string]ChainConfig `json:"chainConfigs,omitempty"`
+	// SubnetConfigs is merged into the subnet configs of the node. A subnet with a null config is removed from the
+	// subnet configs.
+	SubnetConfigs map[string]json.RawMessage `json:"subnetConfigs,omitempty"`
+}
+
+// Apply returns a copy of [config] with the update applied
+func (u NodeUpdate) Apply(config NodeConfig) NodeConfig {
+	if u.Executable != "" {
+		config.Executable = u.Executable
+	}
+	if len(u.Config) > 0 {
+		config.Config = CopyConfig(config.Config)
+		for key, value := range u.Config {
+			if value == nil {
+				delete(config.Config, key)
+			} else {
+				config.Config[key] = value
+			}
+		}
+	}
+	if len(u.Plugins) > 0 {
+		plugins := make(map[string]string, len(config.Plugins)+len(u.Plugins))
+		for vmID, executable := range config.Plugins {
+			plugins[vmID] = executable
+		}
+		for vmID, executable := range u.Plugins {
+			if executable == "" {
+				delete(plugins, vmID)
+			} else {
+				plugins[vmID] = executable
+			}
+		}
+		config.Plugins = plugins
+	}
+	if len(u.ChainConfigs) > 0 {
+		chainConfigs := make(map[string]ChainConfig, len(config.ChainConfigs)+len(u.ChainConfigs))
+		for chain, chainConfig := range config.ChainConfigs {
+			chainConfigs[chain] = chainConfig
+		}
+		for chain, chainConfig := range u.ChainConfigs {
+			if isEmptyConfig(chainConfig.Config) && isEmptyConfig(chainConfig.Upgrade) {
+				delete(chainConfigs, chain)
+			} else {
+				chainConfigs[chain] = chainConfig
+			}
+		}
+		config.ChainConfigs = chainConfigs
+	}
+	if len(u.SubnetConfigs) > 0 {
+		subnetConfigs := make(map[string]json.RawMessage, len(config.SubnetConfigs)+len(u.SubnetConfigs))
+		for subnetID, subnetConfig := range config.SubnetConfigs {
+			subnetConfigs[subnetID] = subnetConfig
+		}
+		for subnetID, subnetConfig := range u.SubnetConfigs {
+			if isEmptyConfig(subnetConfig) {
+				delete(subnetConfigs, subnetID)
+			} else {
+				subnetConfigs[subnetID] = subnetConfig
+			}
+		}
+		config.SubnetConfigs = subnetConfigs
+	}
+	return config
+}
+
+// NodeUpdater is an optional interface that a NetworkConstructor can implement to change the config of its nodes
+type NodeUpdater interface {
+	// UpdateNode restarts the node [name] with [update] applied to its config. The node keeps its data directory,
+	// staking key and ports. [stopTimeout] is the time to wait for the node to stop gracefully before killing it.
+	UpdateNode(ctx context.Context, name string, update NodeUpdate, stopTimeout time.Duration) (Node, error)
+}
+
+// CheckExecutable returns an error if the network constructor implements ExecutableChecker and its nodes cannot run the
+// executable registered as [name]. Constructors that cannot check their executables are assumed to be able to run it.
+func (backend *networkBackend) CheckExecutable(name string) error {
+	if checker, ok := backend.network.(ExecutableChecker); ok {
+		return checker.CheckExecutable(name)
+	}
+	return nil
+}
+
+// UpdateNode restarts the node [name] with [update] applied to its config if the network constructor implements
+// NodeUpdater.
+func (backend *networkBackend) UpdateNode(ctx context.Context, name string, update NodeUpdate, stopTimeout time.Duration) (Node, error) {
+	updater, ok := backend.network.(NodeUpdater)
+	if !ok {
+		return nil, fmt.Errorf("network %s does not support updating nodes", backend.name)
+	}
+	if err := validateConfigFiles(update.ChainConfigs, update.SubnetConfigs); err != nil {
+		return nil, err
+	}
+
+	backend.snapshotLock.RLock()
+	defer backend.snapshotLock.RUnlock()
+
+	backend.lock.RLock()
+	_, exists := backend.nodes[name]
+	tornDown := backend.tornDown
+	backend.lock.RUnlock()
+	if tornDown {
+		return nil, fmt.Errorf("cannot update node %s of torn down network: %s", name, backend.name)
+	}
+	if !exists {
+		return nil, fmt.Errorf("cannot update non-existent node: %s", name)
+	}
+
+	node, err := updater.UpdateNode(ctx, name, update, stopTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	backend.lock.Lock()
+	defer backend.lock.Unlock()
+
+	// A constructor may return a new node in place of the previous one, which must be watched instead.
+	if previous, exists := backend.nodes[name]; exists && previous != node {
+		backend.unwatchNode(previous)
+		backend.nodes[name] = node
+		backend.watchNode(node, true, nil)
+	}
+	backend.events.Publish(Event{Type: EventNodeUpdated, Network: backend.name, Node: name})
+	return node, nil
+}
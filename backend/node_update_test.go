@@ -0,0 +1,91 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeUpdateApply(t *testing.T) {
+	assert := assert.New(t)
+
+	config := backend.NodeConfig{
+		Name:       "node0",
+		Executable: "v1",
+		Config:     map[string]interface{}{"a": 1, "b": 2},
+		Plugins:    map[string]string{"vm0": "vm0-v1", "vm1": "vm1-v1"},
+		ChainConfigs: map[string]backend.ChainConfig{
+			"C": {Config: json.RawMessage(`{}`)},
+			"X": {Config: json.RawMessage(`{}`)},
+		},
+		SubnetConfigs: map[string]json.RawMessage{"subnet0": json.RawMessage(`{}`)},
+	}
+	updated := backend.NodeUpdate{
+		Executable: "v2",
+		Config:     map[string]interface{}{"a": nil, "c": 3},
+		Plugins:    map[string]string{"vm0": "", "vm2": "vm2-v1"},
+		ChainConfigs: map[string]backend.ChainConfig{
+			"C": {},
+			"P": {Upgrade: json.RawMessage(`{}`)},
+		},
+		SubnetConfigs: map[string]json.RawMessage{"subnet0": json.RawMessage(`null`), "subnet1": json.RawMessage(`{}`)},
+	}.Apply(config)
+	assert.Equal(backend.NodeConfig{
+		Name:       "node0",
+		Executable: "v2",
+		Config:     map[string]interface{}{"b": 2, "c": 3},
+		Plugins:    map[string]string{"vm1": "vm1-v1", "vm2": "vm2-v1"},
+		ChainConfigs: map[string]backend.ChainConfig{
+			"P": {Upgrade: json.RawMessage(`{}`)},
+			"X": {Config: json.RawMessage(`{}`)},
+		},
+		SubnetConfigs: map[string]json.RawMessage{"subnet1": json.RawMessage(`{}`)},
+	}, updated)
+	assert.Equal(map[string]interface{}{"a": 1, "b": 2}, config.Config, "expected the original config to be unchanged")
+	assert.Len(config.Plugins, 2, "expected the original plugins to be unchanged")
+	assert.Len(config.ChainConfigs, 2, "expected the original chain configs to be unchanged")
+
+	assert.Equal(config, backend.NodeUpdate{}.Apply(config), "expected an empty update to change nothing")
+}
+
+func TestUpdateNode(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AddNode(ctx, nodeConfig("node0")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = network.UpdateNode(ctx, "node1", backend.NodeUpdate{}, time.Second)
+	assert.Error(err, "expected update of missing node to fail")
+	_, err = network.UpdateNode(ctx, "node0", backend.NodeUpdate{ChainConfigs: map[string]backend.ChainConfig{"../C": {}}}, time.Second)
+	assert.Error(err, "expected update with an invalid chain to fail")
+
+	node, err := network.UpdateNode(ctx, "node0", backend.NodeUpdate{Config: map[string]interface{}{"key": "value"}}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("value", node.Config()["key"])
+	assert.Equal(backend.NodeRunning, node.Status())
+	fakeNode := node.(*fakebackend.Node)
+	assert.Equal(1, fakeNode.StopCount(), "expected the node to be restarted")
+
+	assert.NoError(network.Teardown(ctx))
+	_, err = network.UpdateNode(ctx, "node0", backend.NodeUpdate{}, time.Second)
+	assert.Error(err, "expected update of torn down network to fail")
+}
@@ -7,27 +7,55 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	_ NetworkOrchestrator = &orchestrator{}
+	_ EventWatcher        = &orchestrator{}
 )
 
-var _ NetworkOrchestrator = &orchestrator{}
+// attachTimeout bounds the requests made to a NetworkAttacher by GetNetworks and GetNetwork, which do not take a
+// context of their own
+const attachTimeout = 2 * time.Minute
 
 type OrchestratorBackend interface {
 	CreateNetworkConstructor(name string) (NetworkConstructor, error)
 	Teardown(ctx context.Context) error
 }
 
+// NetworkAttacher is an optional interface that an OrchestratorBackend can implement when networks may be created
+// in the backend without going through this orchestrator ie. by another client of a shared server.
+// If the backend implements NetworkAttacher, the orchestrator will query the backend for networks it does not know
+// about and begin tracking them.
+type NetworkAttacher interface {
+	// ListNetworks returns the names of every network that currently exists in the backend
+	ListNetworks(ctx context.Context) ([]string, error)
+	// AttachNetworkConstructor returns a NetworkConstructor for the existing network [name] along with the nodes
+	// that are currently running in it.
+	AttachNetworkConstructor(ctx context.Context, name string) (NetworkConstructor, []Node, error)
+}
+
 type orchestrator struct {
 	// invariant: never call a function on one of the networks while holding this lock.
 	lock sync.RWMutex
 
-	networks map[string]Network
-	backend  OrchestratorBackend
+	networks map[string]*networkBackend
+	// restoring holds the names reserved by the snapshots that are being restored, which cannot be created or attached
+	// until the restore finishes
+	restoring map[string]struct{}
+	backend   OrchestratorBackend
+	events    *EventBus
 }
 
 func NewOrchestrator(backend OrchestratorBackend) NetworkOrchestrator {
 	return &orchestrator{
-		networks: make(map[string]Network),
-		backend:  backend,
+		networks:  make(map[string]*networkBackend),
+		restoring: make(map[string]struct{}),
+		backend:   backend,
+		events:    NewEventBus(),
 	}
 }
 
@@ -35,8 +63,7 @@ func (o *orchestrator) CreateNetwork(name string) (Network, error) {
 	o.lock.Lock()
 	defer o.lock.Unlock()
 
-	_, exists := o.networks[name]
-	if exists {
+	if o.exists(name) {
 		return nil, fmt.Errorf("cannot create duplicate network under name: %s", name)
 	}
 
@@ -45,37 +72,220 @@ func (o *orchestrator) CreateNetwork(name string) (Network, error) {
 		return nil, err
 	}
 
-	network := newNetwork(name, networkConstructor, func() error {
-		_, err := o.removeNetwork(name)
-		return err
-	})
-	o.networks[name] = network
+	network := o.trackNetwork(name, networkConstructor, nil)
+	o.events.Publish(Event{Type: EventNetworkCreated, Network: name})
 	return network, nil
 }
 
+// GetNetworks returns every network tracked by the orchestrator.
+// If the backend implements NetworkAttacher, the backend is treated as the source of truth: networks created
+// elsewhere are attached and networks that no longer exist in the backend are no longer tracked. A network that
+// cannot be attached is skipped, so that it does not hide the others.
 func (o *orchestrator) GetNetworks() ([]Network, error) {
+	attacher, ok := o.backend.(NetworkAttacher)
+	if !ok {
+		o.lock.RLock()
+		defer o.lock.RUnlock()
+
+		networks := make([]Network, 0, len(o.networks))
+		for _, network := range o.networks {
+			networks = append(networks, network)
+		}
+		return networks, nil
+	}
+
+	// Only the networks that are tracked before the backend is queried can be forgotten, so that a network created
+	// concurrently is not mistaken for a network that has been removed from the backend.
 	o.lock.RLock()
-	defer o.lock.RUnlock()
+	tracked := make(map[string]*networkBackend, len(o.networks))
+	for name, network := range o.networks {
+		tracked[name] = network
+	}
+	o.lock.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+	defer cancel()
+	names, err := attacher.ListNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]struct{}, len(names))
+	var untracked []string
+	for _, name := range names {
+		listed[name] = struct{}{}
+		if _, exists := tracked[name]; !exists {
+			untracked = append(untracked, name)
+		}
+	}
+	// A tracked network that is missing from the listing is only forgotten once the backend confirms that it cannot be
+	// attached, so that a single stale listing does not drop it.
+	var missing []string
+	for name := range tracked {
+		if _, exists := listed[name]; !exists {
+			missing = append(missing, name)
+		}
+	}
+	attachments := attachNetworks(ctx, attacher, append(untracked, missing...))
 
-	networks := make([]Network, 0, len(o.networks))
-	for _, network := range o.networks {
-		networks = append(networks, network)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	networks := make([]Network, 0, len(names))
+	for _, name := range names {
+		if network, exists := o.networks[name]; exists {
+			networks = append(networks, network)
+			continue
+		}
+		attachment, ok := attachments[name]
+		if !ok {
+			// The network was tracked when the backend was queried and has been torn down since.
+			continue
+		}
+		if _, restoring := o.restoring[name]; restoring {
+			// The network is tracked by the restore of the snapshot it was listed for once it finishes.
+			continue
+		}
+		if attachment.err != nil {
+			zap.L().Warn("skipping network that cannot be attached", zap.String("network", name), zap.Error(attachment.err))
+			continue
+		}
+		networks = append(networks, o.trackNetwork(name, attachment.constructor, attachment.nodes))
+	}
+	for _, name := range missing {
+		network, exists := o.networks[name]
+		if !exists || network != tracked[name] {
+			continue
+		}
+		if attachments[name].err == nil || ctx.Err() != nil {
+			networks = append(networks, network)
+			continue
+		}
+		zap.L().Info("forgetting network that no longer exists in the backend", zap.String("network", name), zap.Error(attachments[name].err))
+		delete(o.networks, name)
 	}
 	return networks, nil
 }
 
+// GetNetwork returns the network tracked under [name].
+// If the network is not tracked and the backend implements NetworkAttacher, the orchestrator attempts to
+// attach to the network from the backend.
 func (o *orchestrator) GetNetwork(name string) (Network, error) {
 	o.lock.RLock()
-	defer o.lock.RUnlock()
+	network, exists := o.networks[name]
+	o.lock.RUnlock()
+	if exists {
+		return network, nil
+	}
+
+	attacher, ok := o.backend.(NetworkAttacher)
+	if !ok {
+		return nil, fmt.Errorf("cannot get non-existent network: %s", name)
+	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+	defer cancel()
+	networkConstructor, nodes, err := attacher.AttachNetworkConstructor(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get non-existent network %s: %w", name, err)
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	// The network may have been attached concurrently, in which case the tracked network is kept.
+	if network, exists := o.networks[name]; exists {
+		return network, nil
+	}
+	if _, restoring := o.restoring[name]; restoring {
+		return nil, fmt.Errorf("cannot get network %s while it is being restored", name)
+	}
+	return o.trackNetwork(name, networkConstructor, nodes), nil
+}
+
+// AttachNetwork returns the network [name] with its nodes synced from the backend, attaching to the network if it is
+// not tracked yet.
+// If the backend does not implement NetworkAttacher, the orchestrator is the source of truth for its networks, so
+// this is equivalent to GetNetwork.
+func (o *orchestrator) AttachNetwork(ctx context.Context, name string) (Network, error) {
+	attacher, ok := o.backend.(NetworkAttacher)
+	if !ok {
+		return o.GetNetwork(name)
+	}
+
+	networkConstructor, nodes, err := attacher.AttachNetworkConstructor(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach to network %s: %w", name, err)
+	}
+
+	o.lock.Lock()
 	network, exists := o.networks[name]
+	if _, restoring := o.restoring[name]; !exists && restoring {
+		o.lock.Unlock()
+		return nil, fmt.Errorf("cannot attach to network %s while it is being restored", name)
+	}
 	if !exists {
-		return nil, fmt.Errorf("cannot get non-existent network: %s", name)
+		network = o.trackNetwork(name, networkConstructor, nodes)
 	}
+	o.lock.Unlock()
 
+	// Sync the nodes of an already tracked network after releasing the lock to maintain the invariant that we never
+	// call a function on one of the networks while holding the orchestrator lock.
+	if exists {
+		network.syncNodes(nodes)
+	}
 	return network, nil
 }
 
+// attachment is the result of attaching a network from the backend
+type attachment struct {
+	constructor NetworkConstructor
+	nodes       []Node
+	err         error
+}
+
+// attachNetworks attaches each of [names] from [attacher] concurrently. Must not be called with the lock held, since
+// attaching may require requests to a remote backend or restarting the nodes of the network.
+func attachNetworks(ctx context.Context, attacher NetworkAttacher, names []string) map[string]attachment {
+	var (
+		lock        sync.Mutex
+		wg          sync.WaitGroup
+		attachments = make(map[string]attachment, len(names))
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			constructor, nodes, err := attacher.AttachNetworkConstructor(ctx, name)
+			lock.Lock()
+			defer lock.Unlock()
+			attachments[name] = attachment{constructor: constructor, nodes: nodes, err: err}
+		}(name)
+	}
+	wg.Wait()
+	return attachments
+}
+
+// exists returns whether a network is tracked or being restored under [name]. Assumes the lock is held.
+func (o *orchestrator) exists(name string) bool {
+	if _, exists := o.networks[name]; exists {
+		return true
+	}
+	_, restoring := o.restoring[name]
+	return restoring
+}
+
+// trackNetwork creates a network from [networkConstructor] and [nodes] and adds it to the networks map.
+// Assumes the lock is held.
+func (o *orchestrator) trackNetwork(name string, networkConstructor NetworkConstructor, nodes []Node) *networkBackend {
+	network := newNetwork(name, networkConstructor, nodes, o.events, func() error {
+		_, err := o.removeNetwork(name)
+		return err
+	})
+	o.networks[name] = network
+	return network
+}
+
 // removeNetwork grabs the lock to remove the network under [name] from the networks map and returns
 // the removed network or an error if it can't be found.
 func (o *orchestrator) removeNetwork(name string) (Network, error) {
@@ -87,9 +297,16 @@ func (o *orchestrator) removeNetwork(name string) (Network, error) {
 		return nil, fmt.Errorf("cannot teardown non-existent network: %s", name)
 	}
 	delete(o.networks, name)
+	o.events.Publish(Event{Type: EventNetworkTornDown, Network: name})
 	return network, nil
 }
 
+// WatchEvents calls [f] with every event published by the orchestrator and its networks from now on until [ctx] is
+// done or [f] returns an error.
+func (o *orchestrator) WatchEvents(ctx context.Context, f func(Event) error) error {
+	return o.events.Subscribe(ctx, f)
+}
+
 // Teardown calls teardown on the underlying backend and is responsible for cleaning up everything when the orchestrator
 // shuts down.
 // Requires that all of the networks have already been torn down.
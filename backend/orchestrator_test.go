@@ -0,0 +1,548 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	concurrency = 25
+	// deadlockTimeout is the time to wait for concurrent operations to finish before assuming they have deadlocked
+	deadlockTimeout = 10 * time.Second
+)
+
+var errInjected = errors.New("injected failure")
+
+// runConcurrently calls [f] from [n] goroutines at once and fails the test if they do not all return
+// within deadlockTimeout.
+func runConcurrently(t *testing.T, n int, f func(i int)) {
+	t.Helper()
+
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			f(i)
+		}()
+	}
+	close(start)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(deadlockTimeout):
+		t.Fatal("concurrent operations did not finish, possible deadlock")
+	}
+}
+
+func nodeConfig(name string) backend.NodeConfig {
+	return backend.NodeConfig{
+		Name:       name,
+		Executable: "fake",
+		Config:     map[string]interface{}{},
+	}
+}
+
+func TestCreateNetworkDuplicateName(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+
+	var created int32
+	runConcurrently(t, concurrency, func(int) {
+		if _, err := orchestrator.CreateNetwork("network"); err == nil {
+			atomic.AddInt32(&created, 1)
+		}
+	})
+	assert.EqualValues(1, created, "expected exactly one network to be created under a duplicate name")
+	assert.Equal(1, fake.NumNetworks())
+
+	network, err := orchestrator.GetNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(context.Background()))
+	assert.Equal(0, fake.NumNetworks())
+
+	// The name can be reused once the network has been torn down.
+	network, err = orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(context.Background()))
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestCreateNetworkFailure(t *testing.T) {
+	assert := assert.New(t)
+	var fail int32 = 1
+	fake := fakebackend.New(fakebackend.Hooks{
+		CreateNetwork: func(string) error {
+			if atomic.LoadInt32(&fail) == 1 {
+				return errInjected
+			}
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+
+	_, err := orchestrator.CreateNetwork("network")
+	assert.ErrorIs(err, errInjected)
+	_, err = orchestrator.GetNetwork("network")
+	assert.Error(err, "expected a network that failed to be created to not be tracked")
+
+	atomic.StoreInt32(&fail, 0)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(context.Background()))
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestAddNodeDuplicateName(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var added int32
+	runConcurrently(t, concurrency, func(int) {
+		if _, err := network.AddNode(context.Background(), nodeConfig("node")); err == nil {
+			atomic.AddInt32(&added, 1)
+		}
+	})
+	assert.EqualValues(1, added, "expected exactly one node to be added under a duplicate name")
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(nodes, 1)
+
+	// Every node that was rejected as a duplicate must be stopped, so that it does not leak.
+	constructor, _ := fake.Network("network")
+	assert.Len(constructor.Nodes(), concurrency)
+	assert.Eventually(func() bool {
+		return len(constructor.RunningNodes()) == 1
+	}, deadlockTimeout, 10*time.Millisecond, "expected duplicate nodes to be stopped")
+
+	assert.NoError(network.Teardown(context.Background()))
+	assert.Empty(constructor.RunningNodes())
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestAddNodeFailure(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{
+		AddNode: func(_ context.Context, _ string, config backend.NodeConfig) error {
+			if config.Name == "bad" {
+				return errInjected
+			}
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = network.AddNode(context.Background(), nodeConfig("bad"))
+	assert.ErrorIs(err, errInjected)
+	_, err = network.GetNode("bad")
+	assert.Error(err, "expected a node that failed to start to not be tracked")
+
+	if _, err := network.AddNode(context.Background(), nodeConfig("good")); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(context.Background()))
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestConcurrentAddAndRemoveNodes(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{
+		// Delay starting nodes, so that adds and removes interleave.
+		AddNode: func(context.Context, string, backend.NodeConfig) error {
+			time.Sleep(time.Millisecond)
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each goroutine adds a node and removes every other node it added, while other goroutines read the network.
+	runConcurrently(t, concurrency, func(i int) {
+		name := fmt.Sprintf("node%d", i)
+		if _, err := network.AddNode(context.Background(), nodeConfig(name)); err != nil {
+			t.Error(err)
+			return
+		}
+		if _, err := network.GetNodes(); err != nil {
+			t.Error(err)
+		}
+		if i%2 == 0 {
+			if err := network.RemoveNode(name, time.Second); err != nil {
+				t.Error(err)
+			}
+			assert.Error(network.RemoveNode(name, time.Second), "expected removing a removed node to fail")
+		}
+	})
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(nodes, concurrency/2)
+	for _, node := range nodes {
+		assert.Equal(backend.NodeRunning, node.Status())
+	}
+
+	constructor, _ := fake.Network("network")
+	for _, node := range constructor.Nodes() {
+		var i int
+		if _, err := fmt.Sscanf(node.GetName(), "node%d", &i); err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			assert.Equal(1, node.StopCount(), "expected removed node %s to be stopped once", node.GetName())
+		} else {
+			assert.Equal(0, node.StopCount(), "expected node %s to still be running", node.GetName())
+		}
+	}
+
+	assert.NoError(network.Teardown(context.Background()))
+	for _, node := range constructor.Nodes() {
+		assert.Equal(1, node.StopCount(), "expected node %s to be stopped exactly once", node.GetName())
+	}
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestTeardownDuringAddNode(t *testing.T) {
+	assert := assert.New(t)
+	addStarted := make(chan struct{})
+	finishAdd := make(chan struct{})
+	fake := fakebackend.New(fakebackend.Hooks{
+		AddNode: func(context.Context, string, backend.NodeConfig) error {
+			close(addStarted)
+			<-finishAdd
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addErr := make(chan error, 1)
+	go func() {
+		_, err := network.AddNode(context.Background(), nodeConfig("node"))
+		addErr <- err
+	}()
+	<-addStarted
+
+	// Tear down the network while the node is starting. The node finishes starting after the network has been torn
+	// down, so it must be stopped instead of being tracked by the removed network.
+	assert.NoError(network.Teardown(context.Background()))
+	close(finishAdd)
+	assert.Error(<-addErr, "expected adding a node to a torn down network to fail")
+
+	constructor, _ := fake.Network("network")
+	assert.Eventually(func() bool {
+		return len(constructor.RunningNodes()) == 0
+	}, deadlockTimeout, 10*time.Millisecond, "expected node started during teardown to be stopped")
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestNetworkTeardownTwice(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tornDown int32
+	runConcurrently(t, concurrency, func(int) {
+		if err := network.Teardown(context.Background()); err == nil {
+			atomic.AddInt32(&tornDown, 1)
+		}
+	})
+	assert.EqualValues(1, tornDown, "expected exactly one teardown of the network to succeed")
+
+	constructor, _ := fake.Network("network")
+	assert.Equal(1, constructor.Teardowns(), "expected the network constructor to be torn down exactly once")
+	_, err = orchestrator.GetNetwork("network")
+	assert.Error(err)
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestNetworkTeardownFailure(t *testing.T) {
+	assert := assert.New(t)
+	var fail int32 = 1
+	fake := fakebackend.New(fakebackend.Hooks{
+		StopNode: func(_ string, node string) error {
+			if node == "node0" && atomic.LoadInt32(&fail) == 1 {
+				return errInjected
+			}
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := network.AddNode(context.Background(), nodeConfig(fmt.Sprintf("node%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A network that fails to tear down remains tracked, so that the teardown can be retried.
+	assert.ErrorIs(network.Teardown(context.Background()), errInjected)
+	_, err = orchestrator.GetNetwork("network")
+	assert.NoError(err)
+	assert.Error(orchestrator.Teardown(context.Background()), "expected orchestrator teardown to fail with an active network")
+
+	atomic.StoreInt32(&fail, 0)
+	assert.NoError(network.Teardown(context.Background()))
+	assert.NoError(orchestrator.Teardown(context.Background()))
+	assert.True(fake.TornDown())
+}
+
+func TestTeardownWithCrashedNodes(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := network.AddNode(context.Background(), nodeConfig(fmt.Sprintf("node%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	constructor, _ := fake.Network("network")
+	crashed := constructor.Nodes()[1]
+	crashed.Crash()
+	node, err := network.GetNode(crashed.GetName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(backend.NodeCrashed, node.Status())
+
+	assert.NoError(network.Teardown(context.Background()))
+	assert.Equal(backend.NodeCrashed, crashed.Status(), "expected a crashed node to remain crashed")
+	assert.Empty(constructor.RunningNodes())
+	assert.NoError(orchestrator.Teardown(context.Background()))
+}
+
+func TestConcurrentNetworkLifecycles(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{
+		// Delay stopping nodes, so that networks are torn down while other networks are listed and created.
+		StopNode: func(string, string) error {
+			time.Sleep(time.Millisecond)
+			return nil
+		},
+	})
+	orchestrator := backend.NewOrchestrator(fake)
+
+	// Each network's Teardown calls back into the orchestrator to remove itself, while other goroutines hold the
+	// orchestrator lock to create and list networks. This deadlocks if the lock ordering invariant is violated.
+	runConcurrently(t, concurrency, func(i int) {
+		network, err := orchestrator.CreateNetwork(fmt.Sprintf("network%d", i))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for j := 0; j < 3; j++ {
+			if _, err := network.AddNode(context.Background(), nodeConfig(fmt.Sprintf("node%d", j))); err != nil {
+				t.Error(err)
+			}
+		}
+		if _, err := orchestrator.GetNetworks(); err != nil {
+			t.Error(err)
+		}
+		if err := network.Teardown(context.Background()); err != nil {
+			t.Error(err)
+		}
+		if _, err := orchestrator.GetNetwork(network.GetName()); err == nil {
+			t.Errorf("expected torn down network %s to be removed", network.GetName())
+		}
+	})
+
+	networks, err := orchestrator.GetNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(networks)
+	assert.Equal(0, fake.NumNetworks())
+	assert.NoError(orchestrator.Teardown(context.Background()))
+	assert.True(fake.TornDown())
+}
+
+func TestOrchestratorTeardownWithActiveNetworks(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(orchestrator.Teardown(context.Background()))
+	assert.False(fake.TornDown(), "expected the backend to remain up while a network is active")
+
+	assert.NoError(network.Teardown(context.Background()))
+	assert.NoError(orchestrator.Teardown(context.Background()))
+	assert.True(fake.TornDown())
+}
+
+// attachingBackend is a fake backend that implements NetworkAttacher with [listNetworks] and [attachNetwork]
+type attachingBackend struct {
+	*fakebackend.Backend
+	listNetworks  func(ctx context.Context) ([]string, error)
+	attachNetwork func(ctx context.Context, name string) (backend.NetworkConstructor, error)
+}
+
+func (b *attachingBackend) ListNetworks(ctx context.Context) ([]string, error) {
+	return b.listNetworks(ctx)
+}
+
+func (b *attachingBackend) AttachNetworkConstructor(ctx context.Context, name string) (backend.NetworkConstructor, []backend.Node, error) {
+	constructor, err := b.attachNetwork(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return constructor, nil, nil
+}
+
+func TestGetNetworksAttachesOutsideLock(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	var (
+		listed      = []string{"network", "slow", "broken"}
+		attaching   = make(chan struct{})
+		releaseSlow = make(chan struct{})
+	)
+	orchestrator := backend.NewOrchestrator(&attachingBackend{
+		Backend: fake,
+		listNetworks: func(ctx context.Context) ([]string, error) {
+			return listed, nil
+		},
+		attachNetwork: func(ctx context.Context, name string) (backend.NetworkConstructor, error) {
+			switch name {
+			case "broken":
+				return nil, errInjected
+			case "slow":
+				close(attaching)
+				<-releaseSlow
+				return fake.CreateNetworkConstructor(name)
+			}
+			constructor, ok := fake.Network(name)
+			if !ok {
+				return nil, fmt.Errorf("network %s does not exist", name)
+			}
+			return constructor, nil
+		},
+	})
+	if _, err := orchestrator.CreateNetwork("network"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan []backend.Network)
+	go func() {
+		networks, err := orchestrator.GetNetworks()
+		assert.NoError(err, "expected a network that cannot be attached to be skipped")
+		result <- networks
+	}()
+
+	// Tracked networks remain available while another network is being attached.
+	<-attaching
+	_, err := orchestrator.GetNetwork("network")
+	assert.NoError(err)
+	close(releaseSlow)
+
+	names := make([]string, 0, 2)
+	for _, network := range <-result {
+		names = append(names, network.GetName())
+	}
+	assert.ElementsMatch([]string{"network", "slow"}, names)
+
+	// A tracked network that is omitted from a listing is kept while the backend can still attach to it.
+	listed = []string{"slow"}
+	networks, err := orchestrator.GetNetworks()
+	assert.NoError(err)
+	assert.Len(networks, 2)
+	_, err = orchestrator.GetNetwork("network")
+	assert.NoError(err)
+}
+
+func TestGetNetworkAttachesOutsideLock(t *testing.T) {
+	assert := assert.New(t)
+	fake := fakebackend.New(fakebackend.Hooks{})
+	var (
+		attaching = make(chan struct{})
+		release   = make(chan struct{})
+	)
+	orchestrator := backend.NewOrchestrator(&attachingBackend{
+		Backend: fake,
+		listNetworks: func(ctx context.Context) ([]string, error) {
+			return nil, nil
+		},
+		attachNetwork: func(ctx context.Context, name string) (backend.NetworkConstructor, error) {
+			close(attaching)
+			<-release
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errors.New("expected attach to be bounded by a deadline")
+			}
+			return fake.CreateNetworkConstructor(name)
+		},
+	})
+
+	result := make(chan error)
+	go func() {
+		_, err := orchestrator.GetNetwork("slow")
+		result <- err
+	}()
+
+	// Other networks can be created while a network is being attached.
+	<-attaching
+	_, err := orchestrator.CreateNetwork("network")
+	assert.NoError(err)
+	close(release)
+	assert.NoError(<-result)
+}
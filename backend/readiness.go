@@ -0,0 +1,149 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+)
+
+// ReadinessLevel is the point in its startup that a node must reach before AddNode returns
+type ReadinessLevel string
+
+const (
+	// ReadinessStarted only waits for the backend to start the node, which is the default
+	ReadinessStarted ReadinessLevel = "started"
+	// ReadinessHTTP waits for the HTTP port of the node to accept connections
+	ReadinessHTTP ReadinessLevel = "http"
+	// ReadinessInfo waits for the info API of the node to respond
+	ReadinessInfo ReadinessLevel = "info"
+	// ReadinessHealthy waits for the health API of the node to report healthy
+	ReadinessHealthy ReadinessLevel = "healthy"
+	// ReadinessBootstrapped waits for the node to report that it has bootstrapped each of the configured chains
+	ReadinessBootstrapped ReadinessLevel = "bootstrapped"
+
+	defaultReadinessPollInterval = 250 * time.Millisecond
+)
+
+var (
+	// DefaultReadinessChains are the chains that must be bootstrapped for ReadinessBootstrapped if none are configured
+	DefaultReadinessChains = []string{"P", "X", "C"}
+
+	errNodeUnhealthy = errors.New("node reported unhealthy")
+)
+
+// ReadinessConfig configures the readiness probe that AddNode waits on before returning a node.
+type ReadinessConfig struct {
+	Level ReadinessLevel `json:"level"`
+	// Chains are the IDs or aliases of the chains that must be bootstrapped for ReadinessBootstrapped.
+	// Defaults to DefaultReadinessChains if empty.
+	Chains []string `json:"chains,omitempty"`
+	// PollInterval is the time between probes. Defaults to 250ms if 0.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+}
+
+// ReadinessProber is implemented by network constructors that probe the readiness of their nodes themselves, ie.
+// because the nodes are started and probed by a remote orchestrator.
+type ReadinessProber interface {
+	AwaitReady(ctx context.Context, node Node, config ReadinessConfig) error
+}
+
+// Validate returns an error if the readiness level is unknown
+func (c ReadinessConfig) Validate() error {
+	switch c.Level {
+	case "", ReadinessStarted, ReadinessHTTP, ReadinessInfo, ReadinessHealthy, ReadinessBootstrapped:
+		return nil
+	default:
+		return fmt.Errorf("unknown readiness level %q", c.Level)
+	}
+}
+
+// AwaitReady probes [node] until it reaches the readiness level of [config]. Returns an error if [ctx] is done or the
+// node stops before it is ready.
+func AwaitReady(ctx context.Context, node Node, config ReadinessConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Level == "" || config.Level == ReadinessStarted {
+		return nil
+	}
+	pollInterval := config.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultReadinessPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if status := node.Status(); status == NodeStopped || status == NodeCrashed {
+			return fmt.Errorf("node %s %s before reaching readiness level %s", node.GetName(), status, config.Level)
+		}
+		err := probeReadiness(ctx, node, config)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("node %s did not reach readiness level %s: %w (last probe: %v)", node.GetName(), config.Level, ctx.Err(), err)
+		}
+	}
+}
+
+// probeReadiness returns nil if [node] has reached the readiness level of [config]
+func probeReadiness(ctx context.Context, node Node, config ReadinessConfig) error {
+	uri := node.GetHTTPBaseURI()
+	switch config.Level {
+	case ReadinessHTTP:
+		u, err := url.Parse(uri)
+		if err != nil {
+			return err
+		}
+		dialer := net.Dialer{Timeout: time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case ReadinessInfo:
+		_, err := info.NewClient(uri).GetNodeID(ctx)
+		return err
+	case ReadinessHealthy:
+		reply, err := health.NewClient(uri).Health(ctx)
+		if err != nil {
+			return err
+		}
+		if !reply.Healthy {
+			return errNodeUnhealthy
+		}
+		return nil
+	case ReadinessBootstrapped:
+		chains := config.Chains
+		if len(chains) == 0 {
+			chains = DefaultReadinessChains
+		}
+		client := info.NewClient(uri)
+		for _, chain := range chains {
+			bootstrapped, err := client.IsBootstrapped(ctx, chain)
+			if err != nil {
+				return err
+			}
+			if !bootstrapped {
+				return fmt.Errorf("chain %s is not bootstrapped", chain)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown readiness level %q", config.Level)
+	}
+}
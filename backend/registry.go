@@ -4,11 +4,31 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 )
 
-var _ ExecutorRegistry = &executorRegistry{}
+var (
+	_ ExecutorRegistry = &executorRegistry{}
+	_ ExecutorManager  = &orchestrator{}
+
+	errExecutorsNotSupported = errors.New("orchestrator backend does not support managing executors")
+)
+
+// Executor is an executable registered in an ExecutorRegistry
+type Executor struct {
+	Name string `json:"name"`
+	// Path is the command that is executed, ie. the path of a binary or the name of a docker image
+	Path string `json:"path"`
+	// Version is the version reported by the executable, which is empty if it was not detected on registration
+	Version string `json:"version,omitempty"`
+	// SHA256 is the hex encoded SHA-256 checksum of the executable, which is empty if it was not computed on
+	// registration
+	SHA256 string `json:"sha256,omitempty"`
+}
 
 // ExecutorRegistry provides a simple interface for registering execution commands under a given name
 // This is provided to a specific network orchestrator in order to tell it the correct executable command
@@ -17,35 +37,85 @@ var _ ExecutorRegistry = &executorRegistry{}
 type ExecutorRegistry interface {
 	RegisterExecutor(name string, executor string) error
 	GetExecutor(name string) (string, bool)
+	// LookupExecutor returns the executor [name] along with its version and checksum
+	LookupExecutor(name string) (Executor, bool)
+	// AddExecutor registers [executor] under its name along with its version and checksum
+	AddExecutor(executor Executor) error
+	// UnregisterExecutor removes the executor [name]. Nodes that were started with it keep running it.
+	UnregisterExecutor(name string) error
+	// ListExecutors returns the registered executors sorted by name
+	ListExecutors() []Executor
+}
+
+// ExecutorManager is an optional interface that an OrchestratorBackend can implement to change the executables in its
+// registry while it runs. The orchestrator implements it as well and forwards to its backend.
+type ExecutorManager interface {
+	// RegisterExecutor registers the executable at [path] under [name] and returns it along with its detected version
+	// and checksum. [name] defaults to the version of the executable. If [sha256] is set, the executable is rejected
+	// unless its checksum matches.
+	RegisterExecutor(ctx context.Context, name string, path string, sha256 string) (Executor, error)
+	// DiscoverExecutors registers each build of AvalancheGo found in [dir] under its version, and returns the
+	// executors that were registered
+	DiscoverExecutors(ctx context.Context, dir string) ([]Executor, error)
+	// ListExecutors returns the registered executors sorted by name
+	ListExecutors(ctx context.Context) ([]Executor, error)
+	// UnregisterExecutor removes the executor [name]. Nodes that were started with it keep running it.
+	UnregisterExecutor(ctx context.Context, name string) error
+}
+
+// ExecutableReporter is an optional interface for nodes that know the name of the executable they run, so that nodes
+// running a given version can be found ie. to upgrade them to another version.
+type ExecutableReporter interface {
+	// Executable returns the name that the executable of the node is registered under
+	Executable() string
+}
+
+// ExecutableChecker is an optional interface that a NetworkConstructor can implement to check that an executable can be
+// used by its nodes before they are switched to it ie. by a rolling upgrade.
+type ExecutableChecker interface {
+	// CheckExecutable returns an error if the nodes of the network cannot run the executable registered as [name]
+	CheckExecutable(name string) error
 }
 
 type executorRegistry struct {
 	lock     sync.RWMutex
-	registry map[string]string
+	registry map[string]Executor
 }
 
 func NewExecutorRegistry(registry map[string]string) ExecutorRegistry {
+	executors := make(map[string]Executor, len(registry))
+	for name, executor := range registry {
+		executors[name] = Executor{Name: name, Path: executor}
+	}
 	return &executorRegistry{
-		registry: registry,
+		registry: executors,
 	}
 }
 
 func NewEmptyExecutorRegistry() ExecutorRegistry {
 	return &executorRegistry{
-		registry: make(map[string]string),
+		registry: make(map[string]Executor),
 	}
 }
 
 func (e *executorRegistry) RegisterExecutor(name, executor string) error {
+	return e.AddExecutor(Executor{Name: name, Path: executor})
+}
+
+func (e *executorRegistry) AddExecutor(executor Executor) error {
+	if executor.Name == "" {
+		return fmt.Errorf("cannot register executor %s without a name", executor.Path)
+	}
+
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
-	_, exists := e.registry[name]
+	_, exists := e.registry[executor.Name]
 	if exists {
-		return fmt.Errorf("cannot register duplicate executor under the name %s", name)
+		return fmt.Errorf("cannot register duplicate executor under the name %s", executor.Name)
 	}
 
-	e.registry[name] = executor
+	e.registry[executor.Name] = executor
 	return nil
 }
 
@@ -53,6 +123,75 @@ func (e *executorRegistry) GetExecutor(name string) (string, bool) {
 	e.lock.RLock()
 	defer e.lock.RUnlock()
 
+	executor, ok := e.registry[name]
+	return executor.Path, ok
+}
+
+func (e *executorRegistry) LookupExecutor(name string) (Executor, bool) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
 	executor, ok := e.registry[name]
 	return executor, ok
 }
+
+func (e *executorRegistry) UnregisterExecutor(name string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, exists := e.registry[name]; !exists {
+		return fmt.Errorf("cannot unregister non-existent executor: %s", name)
+	}
+	delete(e.registry, name)
+	return nil
+}
+
+func (e *executorRegistry) ListExecutors() []Executor {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	executors := make([]Executor, 0, len(e.registry))
+	for _, executor := range e.registry {
+		executors = append(executors, executor)
+	}
+	sort.Slice(executors, func(i, j int) bool {
+		return executors[i].Name < executors[j].Name
+	})
+	return executors
+}
+
+// RegisterExecutor registers the executable at [path] under [name] if the backend implements ExecutorManager
+func (o *orchestrator) RegisterExecutor(ctx context.Context, name string, path string, sha256 string) (Executor, error) {
+	manager, ok := o.backend.(ExecutorManager)
+	if !ok {
+		return Executor{}, errExecutorsNotSupported
+	}
+	return manager.RegisterExecutor(ctx, name, path, sha256)
+}
+
+// DiscoverExecutors registers the builds found in [dir] if the backend implements ExecutorManager
+func (o *orchestrator) DiscoverExecutors(ctx context.Context, dir string) ([]Executor, error) {
+	manager, ok := o.backend.(ExecutorManager)
+	if !ok {
+		return nil, errExecutorsNotSupported
+	}
+	return manager.DiscoverExecutors(ctx, dir)
+}
+
+// ListExecutors returns the registered executors if the backend implements ExecutorManager
+func (o *orchestrator) ListExecutors(ctx context.Context) ([]Executor, error) {
+	manager, ok := o.backend.(ExecutorManager)
+	if !ok {
+		return nil, errExecutorsNotSupported
+	}
+	return manager.ListExecutors(ctx)
+}
+
+// UnregisterExecutor removes the executor [name] if the backend implements ExecutorManager
+func (o *orchestrator) UnregisterExecutor(ctx context.Context, name string) error {
+	manager, ok := o.backend.(ExecutorManager)
+	if !ok {
+		return errExecutorsNotSupported
+	}
+	return manager.UnregisterExecutor(ctx, name)
+}
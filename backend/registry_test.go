@@ -0,0 +1,54 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := backend.NewExecutorRegistry(map[string]string{"avalanchego": "/bin/avalanchego"})
+	assert.NoError(registry.AddExecutor(backend.Executor{Name: "v1.7.9", Path: "/bin/v1.7.9", Version: "v1.7.9", SHA256: "abc"}))
+	assert.Error(registry.AddExecutor(backend.Executor{Name: "v1.7.9", Path: "/bin/other"}), "expected duplicate executor to be rejected")
+	assert.Error(registry.RegisterExecutor("avalanchego", "/bin/other"), "expected duplicate executor to be rejected")
+	assert.Error(registry.AddExecutor(backend.Executor{Path: "/bin/other"}), "expected executor without a name to be rejected")
+
+	path, ok := registry.GetExecutor("v1.7.9")
+	assert.True(ok)
+	assert.Equal("/bin/v1.7.9", path)
+	assert.Equal([]backend.Executor{
+		{Name: "avalanchego", Path: "/bin/avalanchego"},
+		{Name: "v1.7.9", Path: "/bin/v1.7.9", Version: "v1.7.9", SHA256: "abc"},
+	}, registry.ListExecutors())
+
+	assert.NoError(registry.UnregisterExecutor("v1.7.9"))
+	assert.Error(registry.UnregisterExecutor("v1.7.9"), "expected unregistering a missing executor to fail")
+	_, ok = registry.GetExecutor("v1.7.9")
+	assert.False(ok)
+	assert.NoError(registry.RegisterExecutor("v1.7.9", "/bin/v1.7.9-rc"), "expected the name to be free after unregistering")
+}
+
+func TestExecutorManagerNotSupported(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	manager, ok := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{})).(backend.ExecutorManager)
+	if !assert.True(ok, "expected the orchestrator to implement ExecutorManager") {
+		return
+	}
+	_, err := manager.RegisterExecutor(ctx, "v1.7.10", "/bin/avalanchego", "")
+	assert.Error(err)
+	_, err = manager.DiscoverExecutors(ctx, "/bin")
+	assert.Error(err)
+	_, err = manager.ListExecutors(ctx)
+	assert.Error(err)
+	assert.Error(manager.UnregisterExecutor(ctx, "v1.7.10"))
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RestartMode determines which exits of a node cause it to be restarted
+type RestartMode string
+
+const (
+	// RestartNever leaves a crashed node crashed, which is the default
+	RestartNever RestartMode = "never"
+	// RestartOnFailure restarts a node that exits with an error, up to MaxRetries times
+	RestartOnFailure RestartMode = "on-failure"
+	// RestartAlways restarts a node whenever it exits without being asked to stop
+	RestartAlways RestartMode = "always"
+
+	defaultRestartBackoff    = time.Second
+	defaultMaxRestartBackoff = time.Minute
+	// restartResetAfter is how long a restarted node must keep running for its restarts to stop counting towards
+	// MaxRetries and the backoff
+	restartResetAfter = 30 * time.Second
+	// restartTimeout bounds the time spent restarting a node
+	restartTimeout = 2 * time.Minute
+)
+
+// RestartPolicy configures whether and how a node that crashes is restarted
+type RestartPolicy struct {
+	Mode RestartMode `json:"mode"`
+	// MaxRetries is the number of consecutive restarts RestartOnFailure makes before leaving the node crashed.
+	// 0 retries without limit. Ignored by RestartAlways.
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// Backoff is the delay before the first restart, which doubles with every consecutive restart. Defaults to 1s if 0.
+	Backoff time.Duration `json:"backoff,omitempty"`
+	// MaxBackoff caps the delay between restarts. Defaults to 1m if 0.
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty"`
+}
+
+// RestartPolicyReporter is an optional interface for nodes that know the restart policy they were added with, so that
+// the policy is kept when the node is attached to a network ie. after the network has been restored from disk.
+type RestartPolicyReporter interface {
+	// RestartPolicy returns the restart policy of the node, or nil if it has none
+	RestartPolicy() *RestartPolicy
+}
+
+// Validate returns an error if the restart mode is unknown or any of the limits are negative
+func (p RestartPolicy) Validate() error {
+	switch p.Mode {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("unknown restart mode %q", p.Mode)
+	}
+	if p.MaxRetries < 0 || p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("restart policy limits must not be negative")
+	}
+	return nil
+}
+
+// shouldRestart returns true if a node that crashed with [err] after [restarts] consecutive restarts should be
+// restarted. [err] is nil if the node exited cleanly.
+func (p RestartPolicy) shouldRestart(err error, restarts int) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil && (p.MaxRetries == 0 || restarts < p.MaxRetries)
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before restarting a node that has already been restarted [restarts] consecutive times
+func (p RestartPolicy) backoff(restarts int) time.Duration {
+	delay, maxDelay := p.Backoff, p.MaxBackoff
+	if delay == 0 {
+		delay = defaultRestartBackoff
+	}
+	if maxDelay == 0 {
+		maxDelay = defaultMaxRestartBackoff
+	}
+	for i := 0; i < restarts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// nodeRestarter restarts a node according to its restart policy each time it crashes, until its context is done.
+type nodeRestarter struct {
+	backend *networkBackend
+	node    Node
+	policy  RestartPolicy
+	// ctx is cancelled with the backend lock held once the node is no longer watched by the network
+	ctx context.Context
+
+	lock sync.Mutex
+	// restarts is the number of consecutive restarts, which is reset once the node keeps running for restartResetAfter
+	restarts    int
+	lastRestart time.Time
+	// pending is set while a restart is scheduled, so that a node that crashes while starting is not restarted twice
+	pending bool
+}
+
+// crashed schedules a restart of the node if its policy allows it. [err] is the error the node crashed with.
+func (r *nodeRestarter) crashed(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pending || r.ctx.Err() != nil {
+		return
+	}
+	if !r.lastRestart.IsZero() && time.Since(r.lastRestart) >= restartResetAfter {
+		r.restarts = 0
+	}
+	name := r.node.GetName()
+	if !r.policy.shouldRestart(err, r.restarts) {
+		zap.L().Warn("not restarting crashed node", zap.String("name", name), zap.Int("restarts", r.restarts), zap.Error(err))
+		return
+	}
+
+	delay := r.policy.backoff(r.restarts)
+	r.restarts++
+	r.lastRestart = time.Now().Add(delay)
+	r.pending = true
+	r.backend.events.Publish(Event{
+		Type:    EventNodeRestarting,
+		Network: r.backend.name,
+		Node:    name,
+		Message: fmt.Sprintf("restart %d in %s", r.restarts, delay),
+	})
+	go r.restart(delay)
+}
+
+// restart restarts the node after [delay] unless it stops being tracked first.
+func (r *nodeRestarter) restart(delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.ctx.Done():
+		return
+	}
+
+	r.lock.Lock()
+	r.pending = false
+	r.lock.Unlock()
+	// The node may have been restarted manually in the meantime
+	if r.ctx.Err() != nil || r.node.Status() != NodeCrashed {
+		return
+	}
+
+	// The network lock is not held while restarting, since a restart may take up to restartTimeout. Instead, the restart
+	// is bound to the context that is cancelled once the node is no longer watched, ie. when it is removed.
+	ctx, cancel := context.WithTimeout(r.ctx, restartTimeout)
+	defer cancel()
+	name := r.node.GetName()
+	zap.L().Info("restarting crashed node", zap.String("name", name))
+	if err := r.node.Restart(ctx, 10*time.Second); err != nil {
+		zap.L().Error("failed to restart crashed node", zap.String("name", name), zap.Error(err))
+		// A node that fails to start counts as crashing again, so that it is retried according to its policy. Nodes that
+		// exit on startup have already reported the crash themselves.
+		if r.node.Status() == NodeCrashed {
+			r.crashed(err)
+		}
+		return
+	}
+
+	// The node may have been removed from the network while it was restarting, in which case it must not be left
+	// running.
+	r.backend.lock.RLock()
+	tracked := r.backend.nodes[name] == r.node
+	r.backend.lock.RUnlock()
+	if !tracked {
+		zap.L().Info("stopping restarted node that was removed", zap.String("name", name))
+		if err := r.node.Stop(10 * time.Second); err != nil {
+			zap.L().Warn("failed to stop restarted node that was removed", zap.String("name", name), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+// addRestartingNode creates a network with a single node with the restart policy [policy] and returns the fake node
+// backing it once its events have been observed.
+func addRestartingNode(ctx context.Context, t *testing.T, policy backend.RestartPolicy) (backend.Network, *fakebackend.Node, <-chan backend.Event) {
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	events := watchEvents(ctx, t, orchestrator)
+
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := nodeConfig("node0")
+	config.RestartPolicy = &policy
+	if _, err := network.AddNode(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, events,
+		backend.Event{Type: backend.EventNetworkCreated, Network: "network"},
+		backend.Event{Type: backend.EventNodeAdded, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeStarted, Network: "network", Node: "node0"},
+	)
+
+	constructor, _ := fake.Network("network")
+	return network, constructor.Nodes()[0], events
+}
+
+// expectRestart fails the test unless the next events received from [events] report that node0 crashed and was
+// restarted for the [restart]th time after [delay].
+func expectRestart(t *testing.T, events <-chan backend.Event, restart int, delay time.Duration) {
+	t.Helper()
+
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeCrashed, Network: "network", Node: "node0"})
+	select {
+	case event := <-events:
+		assert.Equal(t, backend.EventNodeRestarting, event.Type)
+		assert.Equal(t, fmt.Sprintf("restart %d in %s", restart, delay), event.Message)
+	case <-time.After(deadlockTimeout):
+		t.Fatal("timed out waiting for restart")
+	}
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeStarted, Network: "network", Node: "node0"})
+}
+
+// expectNoEvents fails the test if any event is received from [events] within [timeout].
+func expectNoEvents(t *testing.T, events <-chan backend.Event, timeout time.Duration) {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %s", event.Type)
+	case <-time.After(timeout):
+	}
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network, node, events := addRestartingNode(ctx, t, backend.RestartPolicy{
+		Mode:       backend.RestartOnFailure,
+		MaxRetries: 3,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 3 * time.Millisecond,
+	})
+
+	// The backoff doubles with each consecutive restart up to the maximum.
+	for i, delay := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond} {
+		node.Crash()
+		expectRestart(t, events, i+1, delay)
+		assert.Equal(backend.NodeRunning, node.Status())
+	}
+
+	// Once the retries are used up, the node is left crashed and reported as such.
+	node.Crash()
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeCrashed, Network: "network", Node: "node0"})
+	expectNoEvents(t, events, 100*time.Millisecond)
+	trackedNode, err := network.GetNode("node0")
+	assert.NoError(err)
+	assert.Equal(backend.NodeCrashed, trackedNode.Status())
+	assert.NoError(network.Teardown(ctx))
+}
+
+func TestRestartOnFailureIgnoresCleanExit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network, node, events := addRestartingNode(ctx, t, backend.RestartPolicy{
+		Mode:    backend.RestartOnFailure,
+		Backoff: time.Millisecond,
+	})
+
+	node.Exit()
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeCrashed, Network: "network", Node: "node0"})
+	expectNoEvents(t, events, 100*time.Millisecond)
+	assert.Equal(t, backend.NodeCrashed, node.Status())
+	assert.NoError(t, network.Teardown(ctx))
+}
+
+func TestRestartAlways(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network, node, events := addRestartingNode(ctx, t, backend.RestartPolicy{
+		Mode:       backend.RestartAlways,
+		MaxRetries: 1,
+		Backoff:    time.Millisecond,
+		MaxBackoff: time.Millisecond,
+	})
+
+	// MaxRetries does not apply to RestartAlways.
+	node.Exit()
+	expectRestart(t, events, 1, time.Millisecond)
+	node.Crash()
+	expectRestart(t, events, 2, time.Millisecond)
+
+	// Stopping the node is not a crash, so it is not restarted.
+	assert.NoError(t, network.RemoveNode("node0", time.Second))
+	expectEvents(t, events, backend.Event{Type: backend.EventNodeStopped, Network: "network", Node: "node0"})
+	expectNoEvents(t, events, 100*time.Millisecond)
+	assert.Equal(t, backend.NodeStopped, node.Status())
+	assert.NoError(t, network.Teardown(ctx))
+}
+
+func TestRemoveNodeCancelsRestart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network, node, events := addRestartingNode(ctx, t, backend.RestartPolicy{
+		Mode:    backend.RestartAlways,
+		Backoff: 50 * time.Millisecond,
+	})
+
+	node.Crash()
+	expectEvents(t, events,
+		backend.Event{Type: backend.EventNodeCrashed, Network: "network", Node: "node0"},
+		backend.Event{Type: backend.EventNodeRestarting, Network: "network", Node: "node0"},
+	)
+	assert.NoError(t, network.RemoveNode("node0", time.Second))
+	expectNoEvents(t, events, 200*time.Millisecond)
+	assert.Equal(t, backend.NodeCrashed, node.Status())
+	assert.Equal(t, 1, node.StopCount())
+	assert.NoError(t, network.Teardown(ctx))
+}
+
+func TestAddNodeInvalidRestartPolicy(t *testing.T) {
+	ctx := context.Background()
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := nodeConfig("node0")
+	config.RestartPolicy = &backend.RestartPolicy{Mode: "sometimes"}
+	_, err = network.AddNode(ctx, config)
+	assert.Error(t, err)
+
+	constructor, _ := fake.Network("network")
+	assert.Empty(t, constructor.Nodes())
+	assert.NoError(t, network.Teardown(ctx))
+}
+
+func TestRestartDoesNotBlockNetwork(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		restarting = make(chan struct{})
+		release    = make(chan struct{})
+		crashed    int32
+	)
+	fake := fakebackend.New(fakebackend.Hooks{
+		// Block the restart of the crashed node, which stops the node before starting it again.
+		StopNode: func(network string, node string) error {
+			if atomic.CompareAndSwapInt32(&crashed, 1, 0) {
+				close(restarting)
+				<-release
+			}
+			return nil
+		},
+	})
+	network, err := backend.NewOrchestrator(fake).CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := nodeConfig("node0")
+	config.RestartPolicy = &backend.RestartPolicy{Mode: backend.RestartAlways, Backoff: time.Millisecond}
+	if _, err := network.AddNode(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	constructor, _ := fake.Network("network")
+	node := constructor.Nodes()[0]
+
+	atomic.StoreInt32(&crashed, 1)
+	node.Crash()
+	select {
+	case <-restarting:
+	case <-time.After(deadlockTimeout):
+		t.Fatal("timed out waiting for restart")
+	}
+
+	// The network can be modified while the node is restarting, and the node is not left running once it is removed.
+	_, err = network.AddNode(ctx, nodeConfig("node1"))
+	assert.NoError(err)
+	assert.NoError(network.RemoveNode("node0", time.Second))
+	close(release)
+	assert.Eventually(func() bool {
+		return node.Status() == backend.NodeStopped
+	}, deadlockTimeout, 10*time.Millisecond, "expected the removed node to be stopped once its restart completed")
+	assert.NoError(network.Teardown(ctx))
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+var errSnapshotsNotSupported = errors.New("orchestrator backend does not support snapshots")
+
+// Snapshotter is an optional interface that a NetworkConstructor can implement to save the state of its network, so
+// that the network can be recreated later by a SnapshotRestorer.
+type Snapshotter interface {
+	// Snapshot saves the config and data of every node in the network under [name]. The nodes are stopped while their
+	// data is saved and started again afterwards.
+	Snapshot(ctx context.Context, name string) error
+}
+
+// SnapshotRestorer is an optional interface that an OrchestratorBackend can implement to recreate networks from the
+// snapshots taken by their constructors.
+type SnapshotRestorer interface {
+	// ListSnapshots returns the names of the snapshots that can be restored
+	ListSnapshots(ctx context.Context) ([]string, error)
+	// RestoreSnapshotConstructor recreates the network saved in [snapshot] under the name [network], or under the name
+	// of the saved network if [network] is empty. Returns the name of the network along with its constructor and the
+	// nodes that were restored.
+	RestoreSnapshotConstructor(ctx context.Context, snapshot string, network string) (string, NetworkConstructor, []Node, error)
+}
+
+// Snapshot saves the state of the network under [name] if its constructor implements Snapshotter. Nodes cannot be added,
+// removed or updated while the snapshot is taken, but the network can still be read.
+func (backend *networkBackend) Snapshot(ctx context.Context, name string) error {
+	snapshotter, ok := backend.network.(Snapshotter)
+	if !ok {
+		return fmt.Errorf("network %s does not support snapshots", backend.name)
+	}
+
+	backend.snapshotLock.Lock()
+	defer backend.snapshotLock.Unlock()
+
+	backend.lock.RLock()
+	tornDown := backend.tornDown
+	backend.lock.RUnlock()
+	if tornDown {
+		return fmt.Errorf("cannot snapshot torn down network: %s", backend.name)
+	}
+	return snapshotter.Snapshot(ctx, name)
+}
+
+// ListSnapshots returns the names of the snapshots that can be restored if the backend implements SnapshotRestorer.
+func (o *orchestrator) ListSnapshots(ctx context.Context) ([]string, error) {
+	restorer, ok := o.backend.(SnapshotRestorer)
+	if !ok {
+		return nil, errSnapshotsNotSupported
+	}
+	return restorer.ListSnapshots(ctx)
+}
+
+// RestoreSnapshot recreates the network saved in [snapshot] under the name [name], or under the name of the saved
+// network if [name] is empty, if the backend implements SnapshotRestorer. The name is reserved while the snapshot is
+// restored, so that a network with the same name cannot be created concurrently without holding the lock.
+func (o *orchestrator) RestoreSnapshot(ctx context.Context, snapshot string, name string) (Network, error) {
+	restorer, ok := o.backend.(SnapshotRestorer)
+	if !ok {
+		return nil, errSnapshotsNotSupported
+	}
+
+	// The name of the saved network is only known once the snapshot is restored, so an empty name is reserved once it
+	// has been resolved instead.
+	if name != "" {
+		o.lock.Lock()
+		if o.exists(name) {
+			o.lock.Unlock()
+			return nil, fmt.Errorf("cannot create duplicate network under name: %s", name)
+		}
+		o.restoring[name] = struct{}{}
+		o.lock.Unlock()
+	}
+	restored, networkConstructor, nodes, err := restorer.RestoreSnapshotConstructor(ctx, snapshot, name)
+
+	o.lock.Lock()
+	if name != "" {
+		delete(o.restoring, name)
+	}
+	if err != nil {
+		o.lock.Unlock()
+		return nil, fmt.Errorf("cannot restore snapshot %s: %w", snapshot, err)
+	}
+	if tracked, exists := o.networks[restored]; exists {
+		if _, ok := o.backend.(NetworkAttacher); ok {
+			// The backend does not allow duplicate networks, so the restored network was attached concurrently.
+			o.lock.Unlock()
+			tracked.syncNodes(nodes)
+			return tracked, nil
+		}
+	}
+	if o.exists(restored) {
+		o.lock.Unlock()
+		// Tear down the restored network outside of the lock, since the name was taken while it was restored.
+		if err := networkConstructor.Teardown(ctx); err != nil {
+			zap.L().Error("failed to teardown restored network", zap.String("network", restored), zap.Error(err))
+		}
+		return nil, fmt.Errorf("cannot create duplicate network under name: %s", restored)
+	}
+	network := o.trackNetwork(restored, networkConstructor, nodes)
+	o.lock.Unlock()
+
+	o.events.Publish(Event{Type: EventNetworkCreated, Network: restored, Message: fmt.Sprintf("restored from snapshot %s", snapshot)})
+	return network, nil
+}
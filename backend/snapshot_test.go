@@ -0,0 +1,224 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backend_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+// nodeNames returns the sorted names of the nodes of [network]
+func nodeNames(t *testing.T, network backend.Network) []string {
+	t.Helper()
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{}))
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"node0", "node1", "node2"} {
+		if _, err := network.AddNode(ctx, nodeConfig(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := network.RemoveNode("node2", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Snapshot(ctx, "snapshot"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(network.Snapshot(ctx, "snapshot"), "expected duplicate snapshot to fail")
+	snapshots, err := orchestrator.ListSnapshots(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"snapshot"}, snapshots)
+
+	// The snapshot is restored under the name of the saved network by default, which is still running.
+	_, err = orchestrator.RestoreSnapshot(ctx, "snapshot", "")
+	assert.Error(err, "expected restoring over a running network to fail")
+	_, err = orchestrator.RestoreSnapshot(ctx, "missing", "copy")
+	assert.Error(err, "expected restoring a missing snapshot to fail")
+
+	events := watchEvents(ctx, t, orchestrator)
+	restored, err := orchestrator.RestoreSnapshot(ctx, "snapshot", "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("copy", restored.GetName())
+	assert.Equal([]string{"node0", "node1"}, nodeNames(t, restored))
+	expectEvents(t, events, backend.Event{Type: backend.EventNetworkCreated, Network: "copy"})
+	tracked, err := orchestrator.GetNetwork("copy")
+	assert.NoError(err)
+	assert.Equal(restored, tracked)
+
+	assert.NoError(network.Teardown(ctx))
+	restored, err = orchestrator.RestoreSnapshot(ctx, "snapshot", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("network", restored.GetName())
+	assert.Equal([]string{"node0", "node1"}, nodeNames(t, restored))
+
+	networks, err := orchestrator.GetNetworks()
+	assert.NoError(err)
+	for _, network := range networks {
+		assert.NoError(network.Teardown(ctx))
+	}
+	assert.NoError(orchestrator.Teardown(ctx))
+}
+
+func TestRestoreSnapshotReservesName(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	restoring := make(chan struct{})
+	release := make(chan struct{})
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{
+		RestoreSnapshot: func(ctx context.Context, snapshot string, network string) error {
+			close(restoring)
+			<-release
+			return nil
+		},
+	}))
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AddNode(ctx, nodeConfig("node0")); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Snapshot(ctx, "snapshot"); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		network backend.Network
+		err     error
+	}
+	restored := make(chan result, 1)
+	go func() {
+		network, err := orchestrator.RestoreSnapshot(ctx, "snapshot", "copy")
+		restored <- result{network: network, err: err}
+	}()
+	<-restoring
+
+	// The orchestrator remains usable while the snapshot is restored, but the name of the restored network is taken.
+	networks, err := orchestrator.GetNetworks()
+	assert.NoError(err)
+	assert.Len(networks, 1)
+	_, err = orchestrator.CreateNetwork("copy")
+	assert.Error(err, "expected creating a network under the name being restored to fail")
+	other, err := orchestrator.CreateNetwork("other")
+	assert.NoError(err)
+
+	close(release)
+	res := <-restored
+	if !assert.NoError(res.err) {
+		return
+	}
+	assert.Equal("copy", res.network.GetName())
+	assert.Equal([]string{"node0"}, nodeNames(t, res.network))
+
+	for _, network := range []backend.Network{network, other, res.network} {
+		assert.NoError(network.Teardown(ctx))
+	}
+	assert.NoError(orchestrator.Teardown(ctx))
+}
+
+func TestSnapshotDoesNotBlockReads(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshotting := make(chan struct{})
+	release := make(chan struct{})
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{
+		Snapshot: func(context.Context, string, string) error {
+			close(snapshotting)
+			<-release
+			return nil
+		},
+	}))
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AddNode(ctx, nodeConfig("node0")); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotDone := make(chan error, 1)
+	go func() {
+		snapshotDone <- network.Snapshot(ctx, "snapshot")
+	}()
+	<-snapshotting
+
+	// The nodes of the network can be read during the snapshot, but nodes cannot be added until it finishes.
+	assert.Equal([]string{"node0"}, nodeNames(t, network))
+	added := make(chan error, 1)
+	go func() {
+		_, err := network.AddNode(ctx, nodeConfig("node1"))
+		added <- err
+	}()
+	select {
+	case <-added:
+		t.Fatal("expected adding a node to wait for the snapshot")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(<-snapshotDone)
+	assert.NoError(<-added)
+	assert.Equal([]string{"node0", "node1"}, nodeNames(t, network))
+
+	assert.NoError(network.Teardown(ctx))
+	assert.NoError(orchestrator.Teardown(ctx))
+}
+
+func TestSnapshotFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{
+		Snapshot: func(context.Context, string, string) error {
+			return errInjected
+		},
+	}))
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(network.Snapshot(ctx, "snapshot"), errInjected)
+	snapshots, err := orchestrator.ListSnapshots(ctx)
+	assert.NoError(err)
+	assert.Empty(snapshots)
+
+	assert.NoError(network.Teardown(ctx))
+	assert.Error(network.Snapshot(ctx, "snapshot"), "expected snapshot of torn down network to fail")
+	assert.NoError(orchestrator.Teardown(ctx))
+}
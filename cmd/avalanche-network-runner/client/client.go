@@ -3,20 +3,150 @@
 
 package client
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/e2e"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/client"
+	"github.com/aaronbuchwald/avalanche-network-runner/networks"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	logLevel        string
+	endpoint        string
+	dialTimeout     time.Duration
+	security        client.SecurityConfig
+	tenant          string
+	startTimeout    time.Duration
+	healthCheckFreq time.Duration
+	networkName     string
+	topologyFile    string
+	numNodes        int
+	executable      string
+	nodeExecutables map[string]string
+)
 
 func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "start",
-		Short: "start network",
+		Use:   "start [options]",
+		Short: "Start a network on the server.",
 		RunE:  startFunc,
 	}
 
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", zapcore.InfoLevel.String(), "log level")
+	cmd.PersistentFlags().StringVar(&endpoint, "endpoint", "0.0.0.0:8080", "server endpoint")
+	cmd.PersistentFlags().DurationVar(&dialTimeout, "dial-timeout", 10*time.Second, "server dial timeout")
+	client.RegisterSecurityFlags(cmd.PersistentFlags(), &security)
+	cmd.PersistentFlags().StringVar(&tenant, "tenant", "", "Tenant whose namespace the networks are managed in. Defaults to every network on the server.")
+	cmd.PersistentFlags().DurationVar(&startTimeout, "timeout", 2*time.Minute, "Timeout for starting the network and waiting for every node to report healthy.")
+	cmd.PersistentFlags().DurationVar(&healthCheckFreq, "health-check-frequency", 5*time.Second, "Frequency to poll the nodes for health while waiting for the network to start.")
+	cmd.PersistentFlags().StringVar(&networkName, "network-name", "", "Name of the network to create. Defaults to a generated name.")
+	cmd.PersistentFlags().StringVar(&topologyFile, "topology-file", "", "Path to a YAML or JSON topology file describing the network. Defaults to the five node local network.")
+	cmd.PersistentFlags().IntVar(&numNodes, "num-nodes", 0, "Number of validators to start with generated staking keys and a generated genesis. Defaults to the five node local network.")
+	cmd.PersistentFlags().StringVar(&executable, "executable", constants.NormalExecution, "Name of the registered executable to use for the nodes of the local network.")
+	cmd.PersistentFlags().StringToStringVar(&nodeExecutables, "node-executables", nil, "Overrides the registered executable of individual nodes to start a network that mixes versions, as node=executable ie. node0=v1.7.9. Can be repeated.")
+
 	return cmd
 }
 
-// TODO: define and implement the CLI interface
-// do we just care about a single network?
 func startFunc(cmd *cobra.Command, args []string) error {
+	topology, err := loadTopology()
+	if err != nil {
+		return err
+	}
+	if err := setNodeExecutables(topology, nodeExecutables); err != nil {
+		return err
+	}
+	if networkName != "" {
+		topology.Name = networkName
+	}
+	if topology.Name == "" {
+		topology.Name = fmt.Sprintf("network-%v", time.Now().Unix())
+	}
+
+	cli, err := client.New(client.Config{
+		LogLevel:       logLevel,
+		Endpoint:       endpoint,
+		DialTimeout:    dialTimeout,
+		Tenant:         tenant,
+		SecurityConfig: security,
+	})
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	zap.L().Info("Starting network", zap.String("name", topology.Name), zap.Int("nodes", len(topology.Nodes)))
+	network, err := networks.StartTopology(ctx, cli, topology)
+	if err != nil {
+		return err
+	}
+
+	if err := e2e.AwaitHealthy(ctx, network, healthCheckFreq); err != nil {
+		// Tear down the network, so that an unhealthy network is not left running on the server.
+		if teardownErr := network.Teardown(context.Background()); teardownErr != nil {
+			zap.L().Error("Failed to tear down unhealthy network", zap.String("name", topology.Name), zap.Error(teardownErr))
+		}
+		return fmt.Errorf("network %s failed to become healthy: %w", topology.Name, err)
+	}
+	zap.L().Info("Network became healthy", zap.String("name", topology.Name))
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		return err
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].GetName() < nodes[j].GetName()
+	})
+	for _, node := range nodes {
+		fmt.Printf("%s: %s\n", node.GetName(), node.GetHTTPBaseURI())
+	}
+	return nil
+}
+
+// loadTopology returns the topology loaded from [topologyFile] if it was specified, a network of [numNodes]
+// generated validators if it was specified, or the topology of the default local network otherwise.
+func loadTopology() (*networks.Topology, error) {
+	switch {
+	case topologyFile != "" && numNodes != 0:
+		return nil, errors.New("cannot specify both --topology-file and --num-nodes")
+	case topologyFile != "":
+		return networks.LoadTopology(topologyFile)
+	case numNodes != 0:
+		networkConfig, err := networks.CreateNetworkConfig(executable, numNodes)
+		if err != nil {
+			return nil, err
+		}
+		return networks.NewTopology(networkConfig), nil
+	default:
+		return networks.NewTopology(networks.CreateLocalNetworkConfig(executable)), nil
+	}
+}
+
+// setNodeExecutables overrides the executable of each node of [topology] named in [executables]
+func setNodeExecutables(topology *networks.Topology, executables map[string]string) error {
+	for name, executable := range executables {
+		found := false
+		for i, node := range topology.Nodes {
+			if node.Name == name && node.Count <= 1 {
+				topology.Nodes[i].Executable = executable
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("cannot set executable of node %s, which is not a single node of the topology", name)
+		}
+	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/cmd/avalanche-network-runner/client"
+	"github.com/aaronbuchwald/avalanche-network-runner/cmd/avalanche-network-runner/network"
 	"github.com/aaronbuchwald/avalanche-network-runner/cmd/avalanche-network-runner/ping"
 	"github.com/aaronbuchwald/avalanche-network-runner/cmd/avalanche-network-runner/server"
 	"github.com/spf13/cobra"
@@ -28,6 +29,7 @@ func init() {
 		server.NewCommand(),
 		ping.NewCommand(),
 		client.NewCommand(),
+		network.NewCommand(),
 	)
 }
 
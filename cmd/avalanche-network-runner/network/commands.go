@@ -0,0 +1,782 @@
+// Copyright (C) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodeExecutable    string
+	nodeConfigFile    string
+	nodeConfig        string
+	nodeID            string
+	nodeReadiness     string
+	readinessChains   []string
+	restartMode       string
+	maxRestarts       int
+	restartBackoff    time.Duration
+	maxBackoff        time.Duration
+	nodeStopTimeout   time.Duration
+	logStream         string
+	logLines          int64
+	followLogs        bool
+	linkLatency       time.Duration
+	linkJitter        time.Duration
+	linkBandwidth     int64
+	linkDropRate      float64
+	symmetricFaults   bool
+	nodePlugins       map[string]string
+	chainConfigsFile  string
+	subnetConfigsFile string
+	subnetVMs         []string
+	subnetGenesis     []string
+	chainNames        []string
+	validators        []string
+	batchSize         int
+	healthGate        string
+	healthGateChains  []string
+	executorName      string
+	executorSHA256    string
+)
+
+func newCreateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create [network]",
+		Short: "Create a new empty network.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: args[0]}); err != nil {
+					return err
+				}
+				return printResult(args[0], "", fmt.Sprintf("created network %s", args[0]))
+			})
+		},
+	}
+}
+
+func newListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the networks running on the server.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.ListNetworks(ctx, &rpcpb.ListNetworksRequest{})
+				if err != nil {
+					return err
+				}
+				return printNetworks(res.Networks)
+			})
+		},
+	}
+}
+
+func newNodesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "nodes [network]",
+		Short: "List the nodes of a network.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.GetNodes(ctx, &rpcpb.GetNodesRequest{Network: args[0]})
+				if err != nil {
+					return err
+				}
+				return printNodes(res.Nodes)
+			})
+		},
+	}
+}
+
+func newNodeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "node [network] [node]",
+		Short: "Inspect a single node of a network.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.GetNode(ctx, &rpcpb.GetNodeRequest{Network: args[0], Name: args[1]})
+				if err != nil {
+					return err
+				}
+				return printNode(res.Node)
+			})
+		},
+	}
+}
+
+func newAddNodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-node [network] [node]",
+		Short: "Add a new node to a network.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadNodeConfig()
+			if err != nil {
+				return err
+			}
+			readiness := &backend.ReadinessConfig{
+				Level:  backend.ReadinessLevel(nodeReadiness),
+				Chains: readinessChains,
+			}
+			if err := readiness.Validate(); err != nil {
+				return err
+			}
+			restartPolicy := &backend.RestartPolicy{
+				Mode:       backend.RestartMode(restartMode),
+				MaxRetries: maxRestarts,
+				Backoff:    restartBackoff,
+				MaxBackoff: maxBackoff,
+			}
+			if err := restartPolicy.Validate(); err != nil {
+				return err
+			}
+			chainConfigs, subnetConfigs, err := loadConfigFiles()
+			if err != nil {
+				return err
+			}
+			configBytes, err := json.Marshal(backend.NodeConfig{
+				Name:          args[1],
+				Executable:    nodeExecutable,
+				Config:        config,
+				NodeID:        nodeID,
+				ChainConfigs:  chainConfigs,
+				SubnetConfigs: subnetConfigs,
+				Readiness:     readiness,
+				RestartPolicy: restartPolicy,
+			})
+			if err != nil {
+				return err
+			}
+
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.AddNode(ctx, &rpcpb.AddNodeRequest{Network: args[0], Config: configBytes})
+				if err != nil {
+					return err
+				}
+				return printNodes([]*rpcpb.NodeInfo{res.Node})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&nodeExecutable, "executable", constants.NormalExecution, "Name of the registered executable to start the node with.")
+	cmd.Flags().StringVar(&nodeConfigFile, "config-file", "", "Path to a JSON file containing the AvalancheGo config of the node.")
+	cmd.Flags().StringVar(&nodeConfig, "config", "", "JSON encoded AvalancheGo config of the node. Cannot be used with --config-file.")
+	cmd.Flags().StringVar(&nodeID, "node-id", "", "Pre-configured NodeID of the node, if known.")
+	registerConfigFileFlags(cmd)
+	cmd.Flags().StringVar(&nodeReadiness, "readiness", string(backend.ReadinessStarted), "Wait for the node to reach this readiness level before returning: started, http, info, healthy or bootstrapped. Bounded by --request-timeout.")
+	cmd.Flags().StringSliceVar(&readinessChains, "readiness-chains", nil, "Chains that must be bootstrapped for --readiness=bootstrapped. Defaults to P, X and C.")
+	cmd.Flags().StringVar(&restartMode, "restart", string(backend.RestartNever), "Restart the node when it crashes: never, on-failure or always.")
+	cmd.Flags().IntVar(&maxRestarts, "max-restarts", 0, "Consecutive restarts made by --restart=on-failure before leaving the node crashed. 0 retries without limit.")
+	cmd.Flags().DurationVar(&restartBackoff, "restart-backoff", time.Second, "Delay before the first restart of a crashed node, which doubles with each consecutive restart.")
+	cmd.Flags().DurationVar(&maxBackoff, "max-restart-backoff", time.Minute, "Maximum delay between restarts of a crashed node.")
+	return cmd
+}
+
+func newStopNodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop-node [network] [node]",
+		Short: "Stop a node and remove it from a network.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				_, err := orchestratorc.NodeStop(ctx, &rpcpb.NodeStopRequest{
+					Network: args[0],
+					Name:    args[1],
+					Timeout: int64(nodeStopTimeout),
+				})
+				if err != nil {
+					return err
+				}
+				return printResult(args[0], args[1], fmt.Sprintf("stopped node %s", args[1]))
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&nodeStopTimeout, "stop-timeout", 10*time.Second, "Time to wait for the node to shut down gracefully before killing it.")
+	return cmd
+}
+
+func newRestartNodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart-node [network] [node]",
+		Short: "Restart a node with the same config.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.NodeRestart(ctx, &rpcpb.NodeRestartRequest{
+					Network: args[0],
+					Name:    args[1],
+					Timeout: int64(nodeStopTimeout),
+				})
+				if err != nil {
+					return err
+				}
+				return printNodes([]*rpcpb.NodeInfo{res.Node})
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&nodeStopTimeout, "stop-timeout", 10*time.Second, "Time to wait for the node to shut down gracefully before killing it.")
+	return cmd
+}
+
+func newPauseNodeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause-node [network] [node]",
+		Short: "Suspend a running node until it is resumed.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.NodePause(ctx, &rpcpb.NodePauseRequest{
+					Network: args[0],
+					Name:    args[1],
+				})
+				if err != nil {
+					return err
+				}
+				return printNodes([]*rpcpb.NodeInfo{res.Node})
+			})
+		},
+	}
+}
+
+func newResumeNodeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume-node [network] [node]",
+		Short: "Resume a paused node.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.NodeResume(ctx, &rpcpb.NodeResumeRequest{
+					Network: args[0],
+					Name:    args[1],
+				})
+				if err != nil {
+					return err
+				}
+				return printNodes([]*rpcpb.NodeInfo{res.Node})
+			})
+		},
+	}
+}
+
+func newLogsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs [network] [node]",
+		Short: "Print the output captured from a node.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.GetNodeLogs(ctx, &rpcpb.GetNodeLogsRequest{
+					Network: args[0],
+					Name:    args[1],
+					Stream:  logStream,
+					Lines:   logLines,
+				})
+				if err != nil {
+					return err
+				}
+				if outputFormat == jsonOutput && !followLogs {
+					return printJSON(res.Lines)
+				}
+				for _, line := range res.Lines {
+					fmt.Println(line)
+				}
+				if !followLogs {
+					return nil
+				}
+
+				tailClient, err := orchestratorc.TailNodeLogs(ctx, &rpcpb.TailNodeLogsRequest{
+					Network: args[0],
+					Name:    args[1],
+					Stream:  logStream,
+				})
+				if err != nil {
+					return err
+				}
+				for {
+					res, err := tailClient.Recv()
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					fmt.Println(res.Line)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&logStream, "stream", string(backend.Stdout), "Output stream to print: stdout or stderr.")
+	cmd.Flags().Int64Var(&logLines, "lines", 100, "Number of lines to print from the end of the log. 0 prints every retained line.")
+	cmd.Flags().BoolVar(&followLogs, "follow", false, "Keep printing new lines until the node exits or the request times out.")
+	return cmd
+}
+
+func newWatchCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch [network]",
+		Short: "Print network and node events as they happen, optionally for a single network.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			network := ""
+			if len(args) == 1 {
+				network = args[0]
+			}
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				watchClient, err := orchestratorc.WatchEvents(ctx, &rpcpb.WatchEventsRequest{Network: network})
+				if err != nil {
+					return err
+				}
+				for {
+					res, err := watchClient.Recv()
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					if err := printEvent(res.Event); err != nil {
+						return err
+					}
+				}
+			})
+		},
+	}
+}
+
+func newTeardownCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "teardown [network]",
+		Short: "Stop every node of a network and tear it down.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.Teardown(ctx, &rpcpb.TeardownRequest{Network: args[0]}); err != nil {
+					return err
+				}
+				return printResult(args[0], "", fmt.Sprintf("tore down network %s", args[0]))
+			})
+		},
+	}
+}
+
+func newSnapshotCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "snapshot [network] [snapshot]",
+		Short: "Save the config and data of every node of a network, which are stopped while the snapshot is taken.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.SnapshotNetwork(ctx, &rpcpb.SnapshotNetworkRequest{Network: args[0], Snapshot: args[1]}); err != nil {
+					return err
+				}
+				return printResult(args[0], "", fmt.Sprintf("saved snapshot %s of network %s", args[1], args[0]))
+			})
+		},
+	}
+}
+
+func newRestoreSnapshotCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore-snapshot [snapshot] [network]",
+		Short: "Create a network from a snapshot, named after the saved network unless a name is given.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			network := ""
+			if len(args) == 2 {
+				network = args[1]
+			}
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.RestoreSnapshot(ctx, &rpcpb.RestoreSnapshotRequest{Snapshot: args[0], Network: network})
+				if err != nil {
+					return err
+				}
+				return printNodes(res.Network.Nodes)
+			})
+		},
+	}
+}
+
+func newSnapshotsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "snapshots",
+		Short: "List the snapshots that can be restored.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.ListSnapshots(ctx, &rpcpb.ListSnapshotsRequest{})
+				if err != nil {
+					return err
+				}
+				return printSnapshots(res.Snapshots)
+			})
+		},
+	}
+}
+
+func newPartitionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "partition [network] [nodes]...",
+		Short: "Split the nodes of a network into partitions of comma separated nodes that cannot reach each other. The remaining nodes form one more partition.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &rpcpb.PartitionNetworkRequest{Network: args[0]}
+			for _, nodes := range args[1:] {
+				req.Partitions = append(req.Partitions, &rpcpb.Partition{Nodes: strings.Split(nodes, ",")})
+			}
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.PartitionNetwork(ctx, req); err != nil {
+					return err
+				}
+				return printResult(args[0], "", fmt.Sprintf("partitioned network %s into %s", args[0], strings.Join(args[1:], " ")))
+			})
+		},
+	}
+}
+
+func newHealCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "heal [network]",
+		Short: "Remove every partition and link fault from a network.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.HealNetwork(ctx, &rpcpb.HealNetworkRequest{Network: args[0]}); err != nil {
+					return err
+				}
+				return printResult(args[0], "", fmt.Sprintf("healed network %s", args[0]))
+			})
+		},
+	}
+}
+
+func newLinkFaultsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "link-faults [network] [from] [to]",
+		Short: "Apply latency, jitter, a bandwidth cap or packet drops to the traffic sent from one node to another. Without flags the link is restored.",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			faults := &rpcpb.LinkFaults{
+				Latency:   int64(linkLatency),
+				Jitter:    int64(linkJitter),
+				Bandwidth: linkBandwidth,
+				DropRate:  linkDropRate,
+			}
+			links := [][2]string{{args[1], args[2]}}
+			if symmetricFaults {
+				links = append(links, [2]string{args[2], args[1]})
+			}
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				for _, link := range links {
+					if _, err := orchestratorc.SetLinkFaults(ctx, &rpcpb.SetLinkFaultsRequest{
+						Network: args[0],
+						From:    link[0],
+						To:      link[1],
+						Faults:  faults,
+					}); err != nil {
+						return err
+					}
+				}
+				message := fmt.Sprintf("set faults of link from %s to %s", args[1], args[2])
+				if symmetricFaults {
+					message = fmt.Sprintf("set faults of links between %s and %s", args[1], args[2])
+				}
+				return printResult(args[0], args[1], message)
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&linkLatency, "latency", 0, "Delay of all traffic on the link.")
+	cmd.Flags().DurationVar(&linkJitter, "jitter", 0, "Maximum random delay added on top of the latency.")
+	cmd.Flags().Int64Var(&linkBandwidth, "bandwidth", 0, "Maximum throughput of the link in bytes per second. 0 is unlimited.")
+	cmd.Flags().Float64Var(&linkDropRate, "drop-rate", 0, "Probability in [0, 1] that a chunk of traffic is dropped, which resets the connection that carried it.")
+	cmd.Flags().BoolVar(&symmetricFaults, "symmetric", false, "Apply the faults to the traffic in both directions.")
+	return cmd
+}
+
+func newUpdateNodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update-node [network] [node]",
+		Short: "Restart a node with changes to its executable, config or plugins. The node keeps its data directory and ports.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadNodeConfig()
+			if err != nil {
+				return err
+			}
+			chainConfigs, subnetConfigs, err := loadConfigFiles()
+			if err != nil {
+				return err
+			}
+			updateBytes, err := json.Marshal(backend.NodeUpdate{
+				Executable:    nodeExecutable,
+				Config:        config,
+				Plugins:       nodePlugins,
+				ChainConfigs:  chainConfigs,
+				SubnetConfigs: subnetConfigs,
+			})
+			if err != nil {
+				return err
+			}
+
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.UpdateNode(ctx, &rpcpb.UpdateNodeRequest{
+					Network: args[0],
+					Name:    args[1],
+					Update:  updateBytes,
+					Timeout: int64(nodeStopTimeout),
+				})
+				if err != nil {
+					return err
+				}
+				return printNodes([]*rpcpb.NodeInfo{res.Node})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&nodeExecutable, "executable", "", "Name of the registered executable to restart the node with. Defaults to the current executable.")
+	cmd.Flags().StringVar(&nodeConfigFile, "config-file", "", "Path to a JSON file containing AvalancheGo config to merge into the config of the node. A null value removes the key.")
+	cmd.Flags().StringVar(&nodeConfig, "config", "", "JSON encoded AvalancheGo config to merge into the config of the node. Cannot be used with --config-file.")
+	registerConfigFileFlags(cmd)
+	cmd.Flags().StringToStringVar(&nodePlugins, "plugin", nil, "Install the registered binary of a VM as the plugin of a VM ID, as vmID=name. An empty name removes the plugin. Can be repeated.")
+	cmd.Flags().DurationVar(&nodeStopTimeout, "stop-timeout", 10*time.Second, "Time to wait for the node to shut down gracefully before killing it.")
+	return cmd
+}
+
+func newDeploySubnetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy-subnet [network]",
+		Short: "Create a subnet validated by nodes of a network and deploy a blockchain of a custom VM to it for each --vm.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(subnetVMs) == 0 {
+				return fmt.Errorf("at least one --vm must be specified")
+			}
+			if len(subnetGenesis) != len(subnetVMs) {
+				return fmt.Errorf("expected a --genesis for each of %d --vm, found %d", len(subnetVMs), len(subnetGenesis))
+			}
+			if len(chainNames) != 0 && len(chainNames) != len(subnetVMs) {
+				return fmt.Errorf("expected a --chain-name for each of %d --vm, found %d", len(subnetVMs), len(chainNames))
+			}
+			blockchains := make([]*rpcpb.BlockchainSpec, 0, len(subnetVMs))
+			for i, vm := range subnetVMs {
+				genesis, err := os.ReadFile(subnetGenesis[i])
+				if err != nil {
+					return fmt.Errorf("failed to read genesis file %s: %w", subnetGenesis[i], err)
+				}
+				name := vm
+				if len(chainNames) != 0 {
+					name = chainNames[i]
+				}
+				blockchains = append(blockchains, &rpcpb.BlockchainSpec{
+					Name:    name,
+					Vm:      vm,
+					Genesis: genesis,
+				})
+			}
+
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.DeploySubnet(ctx, &rpcpb.DeploySubnetRequest{
+					Network:     args[0],
+					Validators:  validators,
+					Blockchains: blockchains,
+				})
+				if err != nil {
+					return err
+				}
+				return printSubnet(res)
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&subnetVMs, "vm", nil, "Name of the registered binary of a VM to deploy a blockchain of. Can be repeated.")
+	cmd.Flags().StringSliceVar(&subnetGenesis, "genesis", nil, "Path to the genesis file of the blockchain of the --vm at the same position.")
+	cmd.Flags().StringSliceVar(&chainNames, "chain-name", nil, "Alphanumeric name of the blockchain of the --vm at the same position. Defaults to the name of the VM.")
+	cmd.Flags().StringSliceVar(&validators, "validators", nil, "Nodes that validate the subnet. Defaults to every node of the network.")
+	return cmd
+}
+
+func newRollingUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rolling-upgrade [network] [from-executable] [to-executable]",
+		Short: "Restart the nodes of a network that run an executable on another executable one batch at a time. Bounded by --request-timeout.",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.RollingUpgrade(ctx, &rpcpb.RollingUpgradeRequest{
+					Network:          args[0],
+					FromExecutable:   args[1],
+					ToExecutable:     args[2],
+					BatchSize:        int32(batchSize),
+					HealthGate:       healthGate,
+					HealthGateChains: healthGateChains,
+				})
+				if err != nil {
+					return err
+				}
+				return printNodes(res.Nodes)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&batchSize, "batch-size", 1, "Number of nodes to upgrade at once.")
+	cmd.Flags().StringVar(&healthGate, "health-gate", string(backend.ReadinessHealthy), "Readiness level that every node must reach between batches: started, http, info, healthy or bootstrapped.")
+	cmd.Flags().StringSliceVar(&healthGateChains, "health-gate-chains", nil, "Chains that must be bootstrapped for the bootstrapped health gate. Defaults to P, X and C.")
+	return cmd
+}
+
+func newExecutorsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "executors",
+		Short: "List the executables registered on the server along with their versions and checksums.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.ListExecutors(ctx, &rpcpb.ListExecutorsRequest{})
+				if err != nil {
+					return err
+				}
+				return printExecutors(res.Executors)
+			})
+		},
+	}
+}
+
+func newRegisterExecutorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "register-executor [path]",
+		Short: "Register the executable at a path on the server under a name that nodes can run it by.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.RegisterExecutor(ctx, &rpcpb.RegisterExecutorRequest{
+					Name:   executorName,
+					Path:   args[0],
+					Sha256: executorSHA256,
+				})
+				if err != nil {
+					return err
+				}
+				return printExecutors([]*rpcpb.ExecutorInfo{res.Executor})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&executorName, "name", "", "Name to register the executable under. Defaults to the version reported by the executable ie. v1.7.10.")
+	cmd.Flags().StringVar(&executorSHA256, "sha256", "", "Expected hex encoded SHA-256 checksum of the executable. The executable is rejected if its checksum differs.")
+	return cmd
+}
+
+func newDiscoverExecutorsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "discover-executors [directory]",
+		Short: "Register each build of AvalancheGo found in a directory on the server under its version.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				res, err := orchestratorc.DiscoverExecutors(ctx, &rpcpb.DiscoverExecutorsRequest{Directory: args[0]})
+				if err != nil {
+					return err
+				}
+				return printExecutors(res.Executors)
+			})
+		},
+	}
+}
+
+func newUnregisterExecutorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unregister-executor [name]",
+		Short: "Remove an executable from the server and list the remaining executables. Nodes that run it keep running.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRequest(func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error {
+				if _, err := orchestratorc.UnregisterExecutor(ctx, &rpcpb.UnregisterExecutorRequest{Name: args[0]}); err != nil {
+					return err
+				}
+				res, err := orchestratorc.ListExecutors(ctx, &rpcpb.ListExecutorsRequest{})
+				if err != nil {
+					return err
+				}
+				return printExecutors(res.Executors)
+			})
+		},
+	}
+}
+
+// registerConfigFileFlags registers the flags that load the chain and subnet configs of a node with loadConfigFiles
+func registerConfigFileFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&chainConfigsFile, "chain-configs-file", "", `Path to a JSON file mapping chain aliases or IDs to their config and upgrade ie. {"C": {"config": {...}, "upgrade": {...}}}.`)
+	cmd.Flags().StringVar(&subnetConfigsFile, "subnet-configs-file", "", `Path to a JSON file mapping subnet IDs to their config ie. {"<subnetID>": {"validatorOnly": true}}.`)
+}
+
+// loadConfigFiles returns the chain and subnet configs read from [chainConfigsFile] and [subnetConfigsFile], which are
+// nil if their file is not set.
+func loadConfigFiles() (map[string]backend.ChainConfig, map[string]json.RawMessage, error) {
+	var (
+		chainConfigMap  map[string]backend.ChainConfig
+		subnetConfigMap map[string]json.RawMessage
+	)
+	for _, file := range []struct {
+		path string
+		v    interface{}
+	}{
+		{chainConfigsFile, &chainConfigMap},
+		{subnetConfigsFile, &subnetConfigMap},
+	} {
+		if file.path == "" {
+			continue
+		}
+		b, err := os.ReadFile(file.path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read config file %s: %w", file.path, err)
+		}
+		if err := json.Unmarshal(b, file.v); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file %s: %w", file.path, err)
+		}
+	}
+	return chainConfigMap, subnetConfigMap, nil
+}
+
+// loadNodeConfig returns the node config passed in through either [nodeConfig] or [nodeConfigFile].
+func loadNodeConfig() (map[string]interface{}, error) {
+	var configBytes []byte
+	switch {
+	case nodeConfig != "" && nodeConfigFile != "":
+		return nil, fmt.Errorf("cannot specify both --config and --config-file")
+	case nodeConfig != "":
+		configBytes = []byte(nodeConfig)
+	case nodeConfigFile != "":
+		b, err := os.ReadFile(nodeConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", nodeConfigFile, err)
+		}
+		configBytes = b
+	default:
+		return make(map[string]interface{}), nil
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse node config: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,343 @@
+// Copyright (C) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/client"
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+var (
+	logLevel       string
+	endpoint       string
+	dialTimeout    time.Duration
+	security       client.SecurityConfig
+	tenant         string
+	requestTimeout time.Duration
+	outputFormat   string
+)
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network [command]",
+		Short: "Manage the networks running on a network runner server.",
+	}
+
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", zapcore.WarnLevel.String(), "log level")
+	cmd.PersistentFlags().StringVar(&endpoint, "endpoint", "0.0.0.0:8080", "server endpoint")
+	cmd.PersistentFlags().DurationVar(&dialTimeout, "dial-timeout", 10*time.Second, "server dial timeout")
+	cmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 2*time.Minute, "client request timeout")
+	cmd.PersistentFlags().StringVar(&outputFormat, "output", tableOutput, "Output format: table or json.")
+	client.RegisterSecurityFlags(cmd.PersistentFlags(), &security)
+	cmd.PersistentFlags().StringVar(&tenant, "tenant", "", "Tenant whose namespace the networks are managed in. Defaults to every network on the server.")
+
+	cmd.AddCommand(
+		newCreateCommand(),
+		newListCommand(),
+		newNodesCommand(),
+		newNodeCommand(),
+		newAddNodeCommand(),
+		newStopNodeCommand(),
+		newRestartNodeCommand(),
+		newPauseNodeCommand(),
+		newResumeNodeCommand(),
+		newLogsCommand(),
+		newWatchCommand(),
+		newSnapshotCommand(),
+		newRestoreSnapshotCommand(),
+		newSnapshotsCommand(),
+		newPartitionCommand(),
+		newHealCommand(),
+		newLinkFaultsCommand(),
+		newUpdateNodeCommand(),
+		newDeploySubnetCommand(),
+		newRollingUpgradeCommand(),
+		newExecutorsCommand(),
+		newRegisterExecutorCommand(),
+		newDiscoverExecutorsCommand(),
+		newUnregisterExecutorCommand(),
+		newTeardownCommand(),
+	)
+	return cmd
+}
+
+// runRequest dials the server and calls [f] with the orchestrator client and a context that expires
+// after [requestTimeout].
+func runRequest(f func(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient) error) error {
+	if outputFormat != tableOutput && outputFormat != jsonOutput {
+		return fmt.Errorf("invalid output format %q, expected %q or %q", outputFormat, tableOutput, jsonOutput)
+	}
+
+	cli, err := client.New(client.Config{
+		LogLevel:       logLevel,
+		Endpoint:       endpoint,
+		DialTimeout:    dialTimeout,
+		Tenant:         tenant,
+		SecurityConfig: security,
+	})
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	return f(ctx, cli.OrchestratorClient())
+}
+
+// nodeOutput is the printable representation of a node returned by the server.
+type nodeOutput struct {
+	Name        string          `json:"name"`
+	URI         string          `json:"uri"`
+	BootstrapIP string          `json:"bootstrapIP"`
+	Status      string          `json:"status"`
+	Executable  string          `json:"executable,omitempty"`
+	Config      json.RawMessage `json:"config,omitempty"`
+}
+
+func newNodeOutput(nodeInfo *rpcpb.NodeInfo) nodeOutput {
+	return nodeOutput{
+		Name:        nodeInfo.Name,
+		URI:         nodeInfo.Uri,
+		BootstrapIP: nodeInfo.Bootstrapip,
+		Status:      nodeInfo.Status,
+		Executable:  nodeInfo.Executable,
+		Config:      nodeInfo.Config,
+	}
+}
+
+// networkOutput is the printable representation of a network returned by the server.
+type networkOutput struct {
+	Name  string       `json:"name"`
+	Nodes []nodeOutput `json:"nodes"`
+}
+
+// printNetworks prints [networkInfos] sorted by name in the requested output format.
+func printNetworks(networkInfos []*rpcpb.NetworkInfo) error {
+	networks := make([]networkOutput, 0, len(networkInfos))
+	for _, networkInfo := range networkInfos {
+		nodes := make([]nodeOutput, 0, len(networkInfo.Nodes))
+		for _, nodeInfo := range networkInfo.Nodes {
+			nodes = append(nodes, newNodeOutput(nodeInfo))
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Name < nodes[j].Name
+		})
+		networks = append(networks, networkOutput{
+			Name:  networkInfo.Name,
+			Nodes: nodes,
+		})
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Name < networks[j].Name
+	})
+
+	if outputFormat == jsonOutput {
+		return printJSON(networks)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNODES")
+	for _, network := range networks {
+		fmt.Fprintf(w, "%s\t%d\n", network.Name, len(network.Nodes))
+	}
+	return w.Flush()
+}
+
+// printNodes prints [nodeInfos] sorted by name in the requested output format.
+func printNodes(nodeInfos []*rpcpb.NodeInfo) error {
+	nodes := make([]nodeOutput, 0, len(nodeInfos))
+	for _, nodeInfo := range nodeInfos {
+		nodes = append(nodes, newNodeOutput(nodeInfo))
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	if outputFormat == jsonOutput {
+		return printJSON(nodes)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tEXECUTABLE\tURI\tBOOTSTRAP IP")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", node.Name, node.Status, node.Executable, node.URI, node.BootstrapIP)
+	}
+	return w.Flush()
+}
+
+// printNode prints the full details of a single node, including its config, in the requested output format.
+func printNode(nodeInfo *rpcpb.NodeInfo) error {
+	node := newNodeOutput(nodeInfo)
+	if outputFormat == jsonOutput {
+		return printJSON(node)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\t%s\n", node.Name)
+	fmt.Fprintf(w, "STATUS\t%s\n", node.Status)
+	fmt.Fprintf(w, "EXECUTABLE\t%s\n", node.Executable)
+	fmt.Fprintf(w, "URI\t%s\n", node.URI)
+	fmt.Fprintf(w, "BOOTSTRAP IP\t%s\n", node.BootstrapIP)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(node.Config, &config); err != nil {
+		return fmt.Errorf("failed to unmarshal config of node %s: %w", node.Name, err)
+	}
+	configBytes, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("CONFIG\n%s\n", configBytes)
+	return nil
+}
+
+// printSnapshots prints the names of [snapshots] sorted in the requested output format.
+func printSnapshots(snapshots []string) error {
+	sort.Strings(snapshots)
+	if outputFormat == jsonOutput {
+		if snapshots == nil {
+			snapshots = []string{}
+		}
+		return printJSON(snapshots)
+	}
+
+	fmt.Println("SNAPSHOT")
+	for _, snapshot := range snapshots {
+		fmt.Println(snapshot)
+	}
+	return nil
+}
+
+// printSubnet prints the subnet deployed by DeploySubnet and the RPC endpoints of its blockchains in the requested
+// output format.
+func printSubnet(subnet *rpcpb.DeploySubnetResponse) error {
+	if outputFormat == jsonOutput {
+		return printJSON(subnet)
+	}
+
+	fmt.Printf("SUBNET\t%s\n", subnet.SubnetId)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCKCHAIN\tID\tVM ID\tNODE\tRPC URL")
+	for _, blockchain := range subnet.Blockchains {
+		nodes := make([]string, 0, len(blockchain.RpcUrls))
+		for node := range blockchain.RpcUrls {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", blockchain.Name, blockchain.BlockchainId, blockchain.VmId, node, blockchain.RpcUrls[node])
+		}
+	}
+	return w.Flush()
+}
+
+// executorOutput is the printable representation of an executor registered on the server.
+type executorOutput struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+// printExecutors prints [executorInfos] sorted by name in the requested output format.
+func printExecutors(executorInfos []*rpcpb.ExecutorInfo) error {
+	executors := make([]executorOutput, 0, len(executorInfos))
+	for _, executorInfo := range executorInfos {
+		executors = append(executors, executorOutput{
+			Name:    executorInfo.Name,
+			Path:    executorInfo.Path,
+			Version: executorInfo.Version,
+			SHA256:  executorInfo.Sha256,
+		})
+	}
+	sort.Slice(executors, func(i, j int) bool {
+		return executors[i].Name < executors[j].Name
+	})
+
+	if outputFormat == jsonOutput {
+		return printJSON(executors)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tSHA256\tPATH")
+	for _, executor := range executors {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", executor.Name, executor.Version, executor.SHA256, executor.Path)
+	}
+	return w.Flush()
+}
+
+// printResult prints a short message describing the result of an operation that does not return any data.
+func printResult(network string, node string, message string) error {
+	if outputFormat == jsonOutput {
+		return printJSON(struct {
+			Network string `json:"network"`
+			Node    string `json:"node,omitempty"`
+			Result  string `json:"result"`
+		}{
+			Network: network,
+			Node:    node,
+			Result:  message,
+		})
+	}
+
+	fmt.Println(message)
+	return nil
+}
+
+// printEvent prints [event] on a single line, so that events can be followed as they are received.
+func printEvent(event *rpcpb.Event) error {
+	timestamp := time.Unix(0, event.Timestamp)
+	if outputFormat == jsonOutput {
+		b, err := json.Marshal(struct {
+			Type    string    `json:"type"`
+			Network string    `json:"network"`
+			Node    string    `json:"node,omitempty"`
+			Time    time.Time `json:"time"`
+			Message string    `json:"message,omitempty"`
+		}{
+			Type:    event.Type,
+			Network: event.Network,
+			Node:    event.Node,
+			Time:    timestamp,
+			Message: event.Message,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	fmt.Printf("%s\t%s\t%s\t%s\t%s\n", timestamp.Format(time.RFC3339), event.Type, event.Network, event.Node, event.Message)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
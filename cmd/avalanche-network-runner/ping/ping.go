@@ -17,6 +17,7 @@ var (
 	logLevel       string
 	endpoint       string
 	dialTimeout    time.Duration
+	security       client.SecurityConfig
 	requestTimeout time.Duration
 )
 
@@ -31,15 +32,17 @@ func NewCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&endpoint, "endpoint", "0.0.0.0:8080", "server endpoint")
 	cmd.PersistentFlags().DurationVar(&dialTimeout, "dial-timeout", 10*time.Second, "server dial timeout")
 	cmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 10*time.Second, "client request timeout")
+	client.RegisterSecurityFlags(cmd.PersistentFlags(), &security)
 
 	return cmd
 }
 
 func pingFunc(cmd *cobra.Command, args []string) error {
 	cli, err := client.New(client.Config{
-		LogLevel:    logLevel,
-		Endpoint:    endpoint,
-		DialTimeout: dialTimeout,
+		LogLevel:       logLevel,
+		Endpoint:       endpoint,
+		DialTimeout:    dialTimeout,
+		SecurityConfig: security,
 	})
 	if err != nil {
 		return err
@@ -5,13 +5,19 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/docker"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
 	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/aaronbuchwald/avalanche-network-runner/inprocess"
 	"github.com/aaronbuchwald/avalanche-network-runner/localbinary"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/log"
 	"github.com/spf13/cobra"
@@ -31,6 +37,34 @@ var (
 	orchestratorBaseDir   string
 	teardownOnExit        bool
 	avalancheGoBinaryPath string
+	nodeLogsConsole       bool
+	nodeLogMaxSize        int64
+	nodeLogMaxBackups     int
+	backendName           string
+	avalancheGoImage      string
+	nodePortRangeStart    int
+	nodePortRangeEnd      int
+	networkPortRangeSize  int
+	restoreNetworks       bool
+	snapshotDir           string
+	faultInjection        bool
+	tlsCertFile           string
+	tlsKeyFile            string
+	tlsClientCAFile       string
+	authToken             string
+	requireTenant         bool
+	tenantMaxNetworks     int
+	tenantMaxNodes        int
+	tenantMaxDiskBytes    int64
+	vmBinaryPaths         map[string]string
+	executables           map[string]string
+	executablesDir        string
+)
+
+const (
+	localBinaryBackend = "local"
+	dockerBackend      = "docker"
+	inProcessBackend   = "inprocess"
 )
 
 func NewCommand() *cobra.Command {
@@ -47,6 +81,28 @@ func NewCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&orchestratorBaseDir, "base-directory", constants.BaseDataDir, "Set the base directory for the orchestrator running behind the server.")
 	cmd.PersistentFlags().BoolVar(&teardownOnExit, "destroy-on-teardown", false, "Set boolean on whether or not all data associated with the orchestrator should be destroyed on shutdown.")
 	cmd.PersistentFlags().StringVar(&avalancheGoBinaryPath, "avalanchego-binary-path", constants.AvalancheGoBinary, "Sets the path to use for the AvalancheGo binary.")
+	cmd.PersistentFlags().BoolVar(&nodeLogsConsole, "node-logs-console", false, "Write the output of every node to the console in addition to its log files.")
+	cmd.PersistentFlags().Int64Var(&nodeLogMaxSize, "node-log-max-size", 10*1024*1024, "Size in bytes that a node log file can grow to before it is rotated.")
+	cmd.PersistentFlags().IntVar(&nodeLogMaxBackups, "node-log-max-backups", 5, "Number of rotated log files to retain for each node output stream.")
+	cmd.PersistentFlags().StringVar(&backendName, "backend", localBinaryBackend, fmt.Sprintf("Backend used to run nodes: %q runs local processes, %q runs docker containers and %q runs stub nodes inside the server.", localBinaryBackend, dockerBackend, inProcessBackend))
+	cmd.PersistentFlags().StringVar(&avalancheGoImage, "avalanchego-image", constants.AvalancheGoDockerImage, "Sets the docker image to use for AvalancheGo when using the docker backend.")
+	cmd.PersistentFlags().IntVar(&nodePortRangeStart, "node-port-range-start", utils.DefaultPortRangeStart, "First port of the range that node ports are allocated from.")
+	cmd.PersistentFlags().IntVar(&nodePortRangeEnd, "node-port-range-end", utils.DefaultPortRangeEnd, "End (exclusive) of the range that node ports are allocated from.")
+	cmd.PersistentFlags().IntVar(&networkPortRangeSize, "network-port-range-size", utils.DefaultNetworkPortRangeSize, "Number of ports reserved for each network from the node port range.")
+	cmd.PersistentFlags().BoolVar(&restoreNetworks, "restore-networks", true, "Restore the networks persisted under the base directory when the server starts, adopting node processes that are still running and restarting the others. Only supported by the local backend.")
+	cmd.PersistentFlags().StringVar(&snapshotDir, "snapshot-directory", "", "Directory that network snapshots are saved to and restored from. Defaults to <base-directory>/snapshots. Only supported by the local backend.")
+	cmd.PersistentFlags().BoolVar(&faultInjection, "fault-injection", false, "Connect the nodes of new networks through link proxies, so that networks can be partitioned and link faults injected. Nodes must set their NodeID. Only supported by the local backend.")
+	cmd.PersistentFlags().StringVar(&tlsCertFile, "tls-cert-file", "", "Path to the PEM certificate that enables TLS on both the gRPC server and the gateway.")
+	cmd.PersistentFlags().StringVar(&tlsKeyFile, "tls-key-file", "", "Path to the PEM key of --tls-cert-file.")
+	cmd.PersistentFlags().StringVar(&tlsClientCAFile, "tls-client-ca-file", "", "Path to a PEM file of CAs. If set, clients of both the gRPC server and the gateway must present a certificate signed by one of them (mTLS).")
+	cmd.PersistentFlags().StringVar(&authToken, "auth-token", "", fmt.Sprintf("Bearer token that every gRPC and gateway request must carry. Defaults to $%s. Disabled if empty.", auth.TokenEnvVar))
+	cmd.PersistentFlags().BoolVar(&requireTenant, "require-tenant", false, "Reject requests that do not specify a tenant. Requests without a tenant operate on every network, so the tenant limits apply to the networks of every tenant combined.")
+	cmd.PersistentFlags().IntVar(&tenantMaxNetworks, "tenant-max-networks", 0, "Maximum number of networks of each tenant. 0 is unlimited.")
+	cmd.PersistentFlags().IntVar(&tenantMaxNodes, "tenant-max-nodes", 0, "Maximum number of nodes across the networks of each tenant. 0 is unlimited.")
+	cmd.PersistentFlags().StringToStringVar(&executables, "executable", nil, fmt.Sprintf("Registers an additional AvalancheGo binary, or image when using the docker backend, under a name that nodes can run it by, as name=path ie. v1.7.9=/path/to/avalanchego. Can be repeated. The binary of --avalanchego-binary-path is registered as %q.", constants.NormalExecution))
+	cmd.PersistentFlags().StringVar(&executablesDir, "executables-dir", "", "Directory of AvalancheGo builds ie. <dir>/v1.7.10/avalanchego, each of which is registered under the version it reports. Only supported by the local backend.")
+	cmd.PersistentFlags().StringToStringVar(&vmBinaryPaths, "vm-binary-path", nil, "Registers the binary of a VM under a name that subnets can deploy it by, as name=path. Can be repeated. Only supported by the local backend.")
+	cmd.PersistentFlags().Int64Var(&tenantMaxDiskBytes, "tenant-max-disk-bytes", 0, "Disk usage in bytes across the networks of each tenant above which new networks and nodes are rejected. 0 is unlimited. Only supported by the local backend.")
 
 	return cmd
 }
@@ -58,18 +114,79 @@ func serverFunc(cmd *cobra.Command, args []string) (err error) {
 	}
 	log.SetGlobalLogLevel(level)
 
-	orchestrator := localbinary.NewNetworkOrchestrator(&localbinary.OrchestratorConfig{
-		BaseDir: orchestratorBaseDir,
-		Registry: map[string]string{
-			constants.NormalExecution: avalancheGoBinaryPath,
-		},
-		DestroyOnTeardown: teardownOnExit,
-	})
+	ports := utils.PortsConfig{
+		RangeStart:       nodePortRangeStart,
+		RangeEnd:         nodePortRangeEnd,
+		NetworkRangeSize: networkPortRangeSize,
+	}
+	var orchestrator backend.NetworkOrchestrator
+	switch backendName {
+	case localBinaryBackend:
+		registry, err := newRegistry(avalancheGoBinaryPath, executables, vmBinaryPaths)
+		if err != nil {
+			return err
+		}
+		orchestrator = localbinary.NewNetworkOrchestrator(&localbinary.OrchestratorConfig{
+			BaseDir:           orchestratorBaseDir,
+			Registry:          registry,
+			DestroyOnTeardown: teardownOnExit,
+			NodeLogs: localbinary.NodeLogsConfig{
+				MaxSize:    nodeLogMaxSize,
+				MaxBackups: nodeLogMaxBackups,
+				Console:    nodeLogsConsole,
+			},
+			Ports:          ports,
+			Restore:        restoreNetworks,
+			SnapshotDir:    snapshotDir,
+			FaultInjection: faultInjection,
+		})
+	case dockerBackend:
+		registry, err := newRegistry(avalancheGoImage, executables)
+		if err != nil {
+			return err
+		}
+		orchestrator = docker.NewNetworkOrchestrator(&docker.OrchestratorConfig{
+			Registry: registry,
+			Ports:    ports,
+		})
+	case inProcessBackend:
+		orchestrator = inprocess.NewNetworkOrchestrator(&inprocess.OrchestratorConfig{
+			Factories: map[string]inprocess.NodeFactory{
+				constants.NormalExecution: inprocess.NewStubNode,
+			},
+		})
+	default:
+		return fmt.Errorf("unknown backend %q", backendName)
+	}
+	if executablesDir != "" {
+		manager, ok := orchestrator.(backend.ExecutorManager)
+		if !ok {
+			return fmt.Errorf("backend %q does not support managing executors", backendName)
+		}
+		if _, err := manager.DiscoverExecutors(context.Background(), executablesDir); err != nil {
+			return fmt.Errorf("failed to register the builds in %s: %w", executablesDir, err)
+		}
+	}
 
+	if authToken == "" {
+		authToken = os.Getenv(auth.TokenEnvVar)
+	}
 	s, err := server.New(server.Config{
 		Port:        port,
 		GwPort:      gwPort,
 		DialTimeout: dialTimeout,
+
+		TLSCertFile:     tlsCertFile,
+		TLSKeyFile:      tlsKeyFile,
+		TLSClientCAFile: tlsClientCAFile,
+		AuthToken:       authToken,
+
+		RequireTenant: requireTenant,
+		TenantLimits: server.TenantLimits{
+			MaxNetworks:  tenantMaxNetworks,
+			MaxNodes:     tenantMaxNodes,
+			MaxDiskBytes: tenantMaxDiskBytes,
+		},
 	}, orchestrator)
 	if err != nil {
 		return err
@@ -99,3 +216,20 @@ func serverFunc(cmd *cobra.Command, args []string) (err error) {
 	}
 	return err
 }
+
+// newRegistry returns the executables of the orchestrator, which are [normalExecution] registered as
+// constants.NormalExecution along with each of [registrations]. Each name can only be registered once.
+func newRegistry(normalExecution string, registrations ...map[string]string) (map[string]string, error) {
+	registry := map[string]string{
+		constants.NormalExecution: normalExecution,
+	}
+	for _, registration := range registrations {
+		for name, path := range registration {
+			if _, exists := registry[name]; exists {
+				return nil, fmt.Errorf("cannot register %s under name %q, which is already registered", path, name)
+			}
+			registry[name] = path
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// dockerBinary is the docker CLI used to manage networks and containers. The docker CLI is used instead of
+	// the docker API client to avoid pinning the API version supported by the daemon.
+	dockerBinary = "docker"
+
+	// resourcePrefix is prepended to the name of every docker network and container created by the orchestrator
+	resourcePrefix = "avalanche-network-runner"
+	// networkLabel and nodeLabel mark the docker resources created for a network and node, so that they can be
+	// found and removed on teardown.
+	networkLabel = "avalanche-network-runner.network"
+	nodeLabel    = "avalanche-network-runner.node"
+)
+
+// runDocker runs the docker CLI with [args] and returns its trimmed stdout. If the command fails, the returned error
+// includes its stderr.
+func runDocker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, dockerBinary, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// networkResourceName returns the name of the docker network created for the network [name]
+func networkResourceName(name string) string {
+	return fmt.Sprintf("%s-%s", resourcePrefix, name)
+}
+
+// containerName returns the name of the container created for the node [nodeName] in the network [networkName]
+func containerName(networkName string, nodeName string) string {
+	return fmt.Sprintf("%s-%s-%s", resourcePrefix, networkName, nodeName)
+}
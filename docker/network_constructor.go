@@ -0,0 +1,156 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/ava-labs/avalanchego/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultHTTPPort    = 9650
+	defaultStakingPort = 9651
+
+	// entrypoint starts AvalancheGo with the IP of the container as its public IP, since the IP is only assigned by
+	// docker once the container starts. The image must provide /bin/sh and run AvalancheGo from its working directory,
+	// as the AvalancheGo images do.
+	entrypoint = `ip=$(hostname -i); exec ./avalanchego --public-ip="${ip%% *}" "$@"`
+
+	// containerConfigDir is the directory that the chain and subnet configs of a node are mounted at in its container
+	containerConfigDir = "/avalanche-network-runner/configs"
+)
+
+var _ backend.NetworkConstructor = &networkConstructor{}
+
+type networkConstructor struct {
+	registry      backend.ExecutorRegistry
+	name          string
+	dockerNetwork string
+	// ports is the range of host ports reserved for the network
+	ports *utils.PortAllocator
+	// configDir is the host directory that holds the chain and subnet configs mounted into the container of each node
+	configDir string
+}
+
+func newNetworkConstructor(name string, dockerNetwork string, registry backend.ExecutorRegistry, ports *utils.PortAllocator, configDir string) backend.NetworkConstructor {
+	return &networkConstructor{
+		registry:      registry,
+		name:          name,
+		dockerNetwork: dockerNetwork,
+		ports:         ports,
+		configDir:     configDir,
+	}
+}
+
+func (c *networkConstructor) AddNode(ctx context.Context, nodeDef backend.NodeConfig) (backend.Node, error) {
+	if len(nodeDef.Plugins) > 0 {
+		return nil, fmt.Errorf("cannot install the plugins of node %s, which the docker backend does not support", nodeDef.Name)
+	}
+	image, exists := c.registry.GetExecutor(nodeDef.Executable)
+	if !exists {
+		image = nodeDef.Executable
+	}
+
+	// Every node has its own IP in the docker network, so the default ports can be used unless they are set explicitly.
+	modifiedNodeConfig := backend.CopyConfig(nodeDef.Config)
+	if _, ok := modifiedNodeConfig[config.HTTPPortKey]; !ok {
+		modifiedNodeConfig[config.HTTPPortKey] = defaultHTTPPort
+	}
+	if _, ok := modifiedNodeConfig[config.StakingPortKey]; !ok {
+		modifiedNodeConfig[config.StakingPortKey] = defaultStakingPort
+	}
+	// The public IP is set by the entrypoint and the API must listen on every interface to be reachable
+	// through the published port.
+	delete(modifiedNodeConfig, config.PublicIPKey)
+	modifiedNodeConfig[config.HTTPHostKey] = "0.0.0.0"
+
+	// The chain and subnet configs are written on the host and mounted into the container, so that they can be
+	// inspected and edited without entering the container.
+	chainConfigDir, subnetConfigDir := path.Join(containerConfigDir, "chains"), path.Join(containerConfigDir, "subnets")
+	if err := backend.SetConfigDirs(nodeDef, modifiedNodeConfig, chainConfigDir, subnetConfigDir); err != nil {
+		return nil, err
+	}
+	nodeConfigDir := filepath.Join(c.configDir, nodeDef.Name)
+	if err := backend.WriteConfigFiles(nodeDef, filepath.Join(nodeConfigDir, "chains"), filepath.Join(nodeConfigDir, "subnets")); err != nil {
+		return nil, fmt.Errorf("failed to write config files of node %s: %w", nodeDef.Name, err)
+	}
+
+	// Publish the HTTP port on a fixed host port, so that the URI of the node does not change when it is restarted.
+	// The container is kept until the node is removed, so the host port remains reserved until then as well.
+	ports, err := c.ports.Allocate(1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate a host port for node %s: %w", nodeDef.Name, err)
+	}
+	httpPort := fmt.Sprintf("%v", modifiedNodeConfig[config.HTTPPortKey])
+	stakingPort := fmt.Sprintf("%v", modifiedNodeConfig[config.StakingPortKey])
+
+	nodeConfigBytes, err := json.Marshal(modifiedNodeConfig)
+	if err != nil {
+		c.ports.Release(ports...)
+		return nil, fmt.Errorf("failed to marshal node config: %w", err)
+	}
+
+	zap.L().Info("Starting node", zap.String("name", nodeDef.Name), zap.String("image", image), zap.String("config", string(nodeConfigBytes)))
+	container := containerName(c.name, nodeDef.Name)
+	containerID, err := runDocker(ctx,
+		"create",
+		"--name", container,
+		"--network", c.dockerNetwork,
+		"--label", fmt.Sprintf("%s=%s", networkLabel, c.name),
+		"--label", fmt.Sprintf("%s=%s", nodeLabel, nodeDef.Name),
+		"--publish", fmt.Sprintf("127.0.0.1:%d:%s", ports[0], httpPort),
+		"--mount", fmt.Sprintf("type=bind,source=%s,target=%s,readonly", nodeConfigDir, containerConfigDir),
+		"--entrypoint", "/bin/sh",
+		image,
+		"-c", entrypoint, "avalanchego",
+		fmt.Sprintf("--%s=%s", config.ConfigContentKey, base64.StdEncoding.EncodeToString(nodeConfigBytes)),
+		fmt.Sprintf("--%s=json", config.ConfigContentTypeKey),
+	)
+	if err != nil {
+		c.ports.Release(ports...)
+		return nil, fmt.Errorf("failed to create container for node %s: %w", nodeDef.Name, err)
+	}
+
+	node, err := newNode(ctx, nodeDef, containerID, c.dockerNetwork, c.ports, ports[0], stakingPort)
+	if err != nil {
+		if _, rmErr := runDocker(context.Background(), "rm", "--force", containerID); rmErr != nil {
+			zap.L().Warn("failed to remove container", zap.String("name", nodeDef.Name), zap.Error(rmErr))
+		} else {
+			c.ports.Release(ports...)
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// Teardown removes every container created for the network along with the docker network itself, and returns the
+// host ports reserved for the network.
+func (c *networkConstructor) Teardown(ctx context.Context) error {
+	defer c.ports.Close()
+	defer os.RemoveAll(c.configDir)
+
+	containers, err := runDocker(ctx, "ps", "--all", "--quiet", "--filter", fmt.Sprintf("label=%s=%s", networkLabel, c.name))
+	if err != nil {
+		return err
+	}
+	if containers != "" {
+		args := append([]string{"rm", "--force", "--volumes"}, strings.Fields(containers)...)
+		if _, err := runDocker(ctx, args...); err != nil {
+			return err
+		}
+	}
+	_, err = runDocker(ctx, "network", "rm", c.dockerNetwork)
+	return err
+}
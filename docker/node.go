@@ -0,0 +1,311 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	_ backend.Node           = &node{}
+	_ backend.NodeLogger     = &node{}
+	_ backend.StatusNotifier = &node{}
+)
+
+type node struct {
+	// lock protects the container state below, which is updated each time the container is started.
+	lock sync.RWMutex
+
+	config        backend.NodeConfig
+	containerID   string
+	dockerNetwork string
+	stakingPort   string
+
+	httpBaseURI string
+	// bootstrapIP is derived from the IP of the container, which is assigned by docker each time the container starts.
+	bootstrapIP string
+
+	// hostPort is the host port that the HTTP port of the container is published on, which is reserved from [ports]
+	// until the container is removed
+	ports    *utils.PortAllocator
+	hostPort int
+	removed  bool
+
+	backend.NodeStatusTracker
+}
+
+func newNode(ctx context.Context, nodeDef backend.NodeConfig, containerID string, dockerNetwork string, ports *utils.PortAllocator, hostPort int, stakingPort string) (*node, error) {
+	node := &node{
+		config:        nodeDef,
+		containerID:   containerID,
+		dockerNetwork: dockerNetwork,
+		stakingPort:   stakingPort,
+		httpBaseURI:   fmt.Sprintf("http://127.0.0.1:%d", hostPort),
+		ports:         ports,
+		hostPort:      hostPort,
+	}
+	node.NodeStatusTracker = backend.NewNodeStatusTracker(&node.lock, nil)
+	if err := node.start(ctx); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// start starts the container of the node and waits to optimistically ensure that it has started successfully.
+func (n *node) start(ctx context.Context) error {
+	if _, err := runDocker(ctx, "start", n.containerID); err != nil {
+		return fmt.Errorf("failed to start container for node %s: %w", n.config.Name, err)
+	}
+	ip, err := runDocker(ctx, "inspect", "--format", fmt.Sprintf(`{{(index .NetworkSettings.Networks %q).IPAddress}}`, n.dockerNetwork), n.containerID)
+	if err != nil {
+		n.kill()
+		return fmt.Errorf("failed to get IP of node %s: %w", n.config.Name, err)
+	}
+
+	n.lock.Lock()
+	n.bootstrapIP = fmt.Sprintf("%s:%s", ip, n.stakingPort)
+	nodeStopped := n.StartRun(backend.NodeStarting)
+	n.lock.Unlock()
+
+	go n.wait(nodeStopped)
+
+	// Wait 500ms to optimistically try to ensure the node has started successfully.
+	// If it fails within the first 500ms, return the error that occurs on startup.
+	select {
+	case <-nodeStopped:
+		n.lock.RLock()
+		defer n.lock.RUnlock()
+		if err := n.StopErr(); err != nil {
+			return err
+		}
+		return fmt.Errorf("node %s exited on startup", n.config.Name)
+	case <-ctx.Done():
+		// Kill the container rather than leaving it running, since the caller treats the node as not started once
+		// start fails. Wait for it to exit, so that the node is marked as stopped.
+		n.lock.Lock()
+		n.RequestStop()
+		n.lock.Unlock()
+		n.kill()
+		<-nodeStopped
+		return ctx.Err()
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.MarkRunning(nodeStopped)
+	return nil
+}
+
+// kill kills the container of the node if it is running
+func (n *node) kill() {
+	if _, err := runDocker(context.Background(), "kill", n.containerID); err != nil {
+		zap.L().Debug("failed to kill container", zap.String("name", n.config.Name), zap.Error(err))
+	}
+}
+
+// wait waits for the container to exit and marks the node as stopped or crashed depending on whether
+// the node was asked to stop.
+func (n *node) wait(nodeStopped chan struct{}) {
+	var err error
+	exitCode, waitErr := runDocker(context.Background(), "wait", n.containerID)
+	switch {
+	case waitErr != nil:
+		err = waitErr
+	case exitCode != "0":
+		err = fmt.Errorf("node %s exited with code %s", n.config.Name, exitCode)
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.Exited(n.config.Name, err, nodeStopped)
+}
+
+func (n *node) GetName() string { return n.config.Name }
+
+func (n *node) GetHTTPBaseURI() string { return n.httpBaseURI }
+
+func (n *node) GetBootstrapIP() string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.bootstrapIP
+}
+
+func (n *node) Executable() string { return n.config.Executable }
+
+func (n *node) Config() map[string]interface{} {
+	return backend.CopyConfig(n.config.Config)
+}
+
+func (n *node) Status() backend.NodeStatus {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.CurrentStatus()
+}
+
+// Stop stops the container of the node and removes it along with the reservation of its host port, so that the node
+// can be added again under the same name.
+func (n *node) Stop(stopTimeout time.Duration) error {
+	err := n.stop(stopTimeout)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.removed {
+		return err
+	}
+	if _, rmErr := runDocker(context.Background(), "rm", "--force", n.containerID); rmErr != nil {
+		if err == nil {
+			err = rmErr
+		}
+		return err
+	}
+	n.removed = true
+	n.ports.Release(n.hostPort)
+	return err
+}
+
+// stop stops the container of the node without removing it. Docker kills the container if it does not stop within
+// [stopTimeout], so the exit code of the container is not reported.
+func (n *node) stop(stopTimeout time.Duration) error {
+	n.lock.Lock()
+	paused := n.CurrentStatus() == backend.NodePaused
+	nodeStopped, running := n.RequestStop()
+	n.lock.Unlock()
+	if !running {
+		return nil
+	}
+
+	// A paused container will not handle SIGTERM until it is unpaused.
+	if paused {
+		if _, err := runDocker(context.Background(), "unpause", n.containerID); err != nil {
+			return err
+		}
+	}
+	timeoutSeconds := strconv.Itoa(int(math.Ceil(stopTimeout.Seconds())))
+	if _, err := runDocker(context.Background(), "stop", "--time", timeoutSeconds, n.containerID); err != nil {
+		return err
+	}
+	<-nodeStopped
+	return nil
+}
+
+func (n *node) Restart(ctx context.Context, stopTimeout time.Duration) error {
+	if err := n.stop(stopTimeout); err != nil {
+		zap.L().Debug("node exited with an error while stopping for restart", zap.String("name", n.config.Name), zap.Error(err))
+	}
+
+	// Wait for the container to exit before starting it again.
+	n.lock.RLock()
+	nodeStopped := n.Stopped()
+	n.lock.RUnlock()
+	select {
+	case <-nodeStopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return n.start(ctx)
+}
+
+func (n *node) Pause() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if status := n.CurrentStatus(); status != backend.NodeStarting && status != backend.NodeRunning {
+		return fmt.Errorf("cannot pause node %s with status %s", n.config.Name, status)
+	}
+	if _, err := runDocker(context.Background(), "pause", n.containerID); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodePaused, nil)
+	return nil
+}
+
+func (n *node) Resume() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if status := n.CurrentStatus(); status != backend.NodePaused {
+		return fmt.Errorf("cannot resume node %s with status %s", n.config.Name, status)
+	}
+	if _, err := runDocker(context.Background(), "unpause", n.containerID); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodeRunning, nil)
+	return nil
+}
+
+// GetLogs returns the last [lines] lines written to [stream] by the container. Docker applies --tail to the combined
+// output of both streams, so the whole output is fetched and trimmed once the stream has been selected.
+func (n *node) GetLogs(ctx context.Context, stream backend.LogStream, lines int) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, dockerBinary, "logs", n.containerID)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to get logs of node %s: %w: %s", n.config.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := stdout.String()
+	if stream == backend.Stderr {
+		output = stderr.String()
+	}
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return []string{}, nil
+	}
+	logLines := strings.Split(output, "\n")
+	if lines > 0 && len(logLines) > lines {
+		logLines = logLines[len(logLines)-lines:]
+	}
+	return logLines, nil
+}
+
+// TailLogs follows the output of the container until it stops.
+func (n *node) TailLogs(ctx context.Context, stream backend.LogStream, f func(line string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, dockerBinary, "logs", "--follow", "--tail", "0", n.containerID)
+	reader, writer := io.Pipe()
+	// Close the reader on return, so that the output of the command is discarded and the command can exit.
+	defer reader.Close()
+	cmd.Stdout, cmd.Stderr = writer, io.Discard
+	if stream == backend.Stderr {
+		cmd.Stdout, cmd.Stderr = io.Discard, writer
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to tail logs of node %s: %w", n.config.Name, err)
+	}
+	go func() {
+		_ = writer.CloseWithError(cmd.Wait())
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if err := f(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
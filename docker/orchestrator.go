@@ -0,0 +1,95 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"go.uber.org/zap"
+)
+
+var _ backend.OrchestratorBackend = &orchestrator{}
+
+type orchestrator struct {
+	registry backend.ExecutorRegistry
+
+	// ports reserves a range of host ports for each network. If the port range is invalid, [portsErr] is returned
+	// when creating a network instead.
+	ports            *utils.PortAllocator
+	portsErr         error
+	networkRangeSize int
+}
+
+type OrchestratorConfig struct {
+	// Registry maps executable names to docker images. An executable that is not in the registry is used as
+	// the docker image directly.
+	Registry map[string]string `json:"registry"`
+	// Ports configures the host ports that the HTTP port of each node is published on
+	Ports utils.PortsConfig `json:"ports"`
+}
+
+func NewNetworkOrchestratorFromBytes(configBytes []byte) (backend.NetworkOrchestrator, error) {
+	config := new(OrchestratorConfig)
+	if err := json.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+
+	return NewNetworkOrchestrator(config), nil
+}
+
+// NewNetworkOrchestrator creates a new orchestrator that generates networks using docker containers, where each network
+// is isolated in its own docker bridge network.
+func NewNetworkOrchestrator(config *OrchestratorConfig) backend.NetworkOrchestrator {
+	registry := config.Registry
+	if registry == nil {
+		registry = make(map[string]string)
+	}
+	ports, portsErr := config.Ports.NewPortAllocator()
+	return backend.NewOrchestrator(&orchestrator{
+		registry:         backend.NewExecutorRegistry(registry),
+		ports:            ports,
+		portsErr:         portsErr,
+		networkRangeSize: config.Ports.GetNetworkRangeSize(),
+	})
+}
+
+func (o *orchestrator) CreateNetworkConstructor(name string) (backend.NetworkConstructor, error) {
+	if o.portsErr != nil {
+		return nil, o.portsErr
+	}
+	ports, err := o.ports.AllocateRange(o.networkRangeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve ports for network %s: %w", name, err)
+	}
+
+	configDir, err := os.MkdirTemp("", networkResourceName(name))
+	if err != nil {
+		ports.Close()
+		return nil, fmt.Errorf("failed to create config directory for network %s: %w", name, err)
+	}
+
+	zap.L().Info("Creating network", zap.String("name", name))
+	dockerNetwork := networkResourceName(name)
+	if _, err := runDocker(context.Background(),
+		"network", "create",
+		"--driver", "bridge",
+		"--label", fmt.Sprintf("%s=%s", networkLabel, name),
+		dockerNetwork,
+	); err != nil {
+		ports.Close()
+		_ = os.RemoveAll(configDir)
+		return nil, fmt.Errorf("failed to create docker network for %s: %w", name, err)
+	}
+	return newNetworkConstructor(name, dockerNetwork, o.registry, ports, configDir), nil
+}
+
+// Teardown is a no-op since every docker resource belongs to a network, which is removed when the network is torn down.
+func (o *orchestrator) Teardown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/e2e"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestOrchestrator returns a docker orchestrator that runs the AvalancheGo image, or skips the test if the image is
+// not available.
+func newTestOrchestrator(ctx context.Context, t *testing.T) backend.NetworkOrchestrator {
+	// The image must already be available, so that the test can run against a local docker daemon without
+	// network access.
+	if _, err := runDocker(ctx, "image", "inspect", constants.AvalancheGoDockerImage); err != nil {
+		t.Skipf("skipping docker test since %s is not available: %s", constants.AvalancheGoDockerImage, err)
+	}
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoDockerImage,
+		},
+	})
+	t.Cleanup(func() {
+		assert.NoError(t, orchestrator.Teardown(context.Background()), "failed to teardown orchestator")
+	})
+	return orchestrator
+}
+
+func TestDockerNetworkOrchestrator(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	e2e.TestNetworkOrchestrator(ctx, t, newTestOrchestrator(ctx, t))
+}
+
+func TestDockerNodeRemoval(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	e2e.TestNodeRemoval(ctx, t, newTestOrchestrator(ctx, t))
+}
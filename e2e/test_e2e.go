@@ -5,16 +5,21 @@ package e2e
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/networks"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/config"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
+var errStopTail = errors.New("stop tailing")
+
 // TestNetworkOrchestrator tests that [orchestator] can be used to construct the default local network and wait for all of the clients
 // to get healthy before tearing the network down.
 func TestNetworkOrchestrator(ctx context.Context, t *testing.T, orchestrator backend.NetworkOrchestrator) {
@@ -44,5 +49,189 @@ func TestNetworkOrchestrator(ctx context.Context, t *testing.T, orchestrator bac
 		// check against initial chain config
 		node.Config()
 		node.GetName()
+
+		if logger, ok := node.(backend.NodeLogger); ok {
+			lines, err := logger.GetLogs(ctx, backend.Stdout, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.NotEmpty(lines, "expected node %s to have written to stdout", node.GetName())
+			assert.LessOrEqual(len(lines), 10)
+		}
+	}
+}
+
+// TestNodeLifecycle tests that a node in a network constructed by [orchestrator] can be paused, resumed, and restarted,
+// and that it reports the expected status after each operation.
+func TestNodeLifecycle(ctx context.Context, t *testing.T, orchestrator backend.NetworkOrchestrator) {
+	assert := assert.New(t)
+
+	network, err := networks.NewDefaultLocalNetwork(ctx, orchestrator, constants.NormalExecution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx), "failed to teardown network")
+	}()
+
+	if err := AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := nodes[len(nodes)-1]
+	assert.Equal(backend.NodeRunning, node.Status())
+
+	zap.L().Info("Pausing node", zap.String("name", node.GetName()))
+	if err := node.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(backend.NodePaused, node.Status())
+	assert.Error(node.Pause(), "expected pausing a paused node to fail")
+
+	zap.L().Info("Resuming node", zap.String("name", node.GetName()))
+	if err := node.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(backend.NodeRunning, node.Status())
+	assert.Error(node.Resume(), "expected resuming a running node to fail")
+
+	// Tail the node while it restarts. Whether the tail starts before or after the old process exits, it
+	// receives the output of the node as it shuts down or starts back up.
+	tailErr := make(chan error, 1)
+	if logger, ok := node.(backend.NodeLogger); ok {
+		go func() {
+			tailErr <- logger.TailLogs(ctx, backend.Stdout, func(string) error {
+				return errStopTail
+			})
+		}()
+	} else {
+		tailErr <- errStopTail
+	}
+
+	zap.L().Info("Restarting node", zap.String("name", node.GetName()))
+	uri := node.GetHTTPBaseURI()
+	if err := node.Restart(ctx, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(<-tailErr, errStopTail, "expected to tail the output of the node while it restarted")
+	assert.Equal(backend.NodeRunning, node.Status())
+	assert.Equal(uri, node.GetHTTPBaseURI())
+
+	if err := AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNodeRemoval tests that a node removed from a network constructed by [orchestrator] gives up the resources it was
+// allocated, so that it can be added again under the same name.
+func TestNodeRemoval(ctx context.Context, t *testing.T, orchestrator backend.NetworkOrchestrator) {
+	assert := assert.New(t)
+
+	network, err := networks.NewDefaultLocalNetwork(ctx, orchestrator, constants.NormalExecution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx), "failed to teardown network")
+	}()
+
+	if err := AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Remove a node that bootstraps from another, since the other nodes can only reach the boot node at its original
+	// bootstrap IP.
+	var node backend.Node
+	for _, candidate := range nodes {
+		if bootstrapIPs, ok := candidate.Config()[config.BootstrapIPsKey]; ok && bootstrapIPs != "" {
+			node = candidate
+			break
+		}
+	}
+	if node == nil {
+		t.Fatal("expected a node that bootstraps from another node")
+	}
+	nodeDef := backend.NodeConfig{
+		Name:       node.GetName(),
+		Executable: constants.NormalExecution,
+		Config:     node.Config(),
+	}
+
+	zap.L().Info("Removing node", zap.String("name", nodeDef.Name))
+	if err := network.RemoveNode(nodeDef.Name, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	_, err = network.GetNode(nodeDef.Name)
+	assert.Error(err, "expected the removed node to be gone")
+
+	zap.L().Info("Adding node again", zap.String("name", nodeDef.Name))
+	readded, err := network.AddNode(ctx, nodeDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(backend.NodeRunning, readded.Status())
+
+	if err := AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNodeReadiness tests that AddNode waits for a node added to a network constructed by [orchestrator] to reach its
+// readiness level, and that a node that never becomes ready is stopped instead of being added to the network.
+func TestNodeReadiness(ctx context.Context, t *testing.T, orchestrator backend.NetworkOrchestrator) {
+	assert := assert.New(t)
+
+	network, err := networks.NewDefaultLocalNetwork(ctx, orchestrator, constants.NormalExecution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx), "failed to teardown network")
+	}()
+	bootNode, err := network.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newNodeConfig := func(name string, bootstrapIP string, level backend.ReadinessLevel) backend.NodeConfig {
+		nodeConfig := networks.CreateBasicLocalNodeConfig()
+		nodeConfig[config.BootstrapIPsKey] = bootstrapIP
+		nodeConfig[config.BootstrapIDsKey] = constants.Staker1NodeID
+		return backend.NodeConfig{
+			Name:       name,
+			Executable: constants.NormalExecution,
+			Config:     nodeConfig,
+			Readiness:  &backend.ReadinessConfig{Level: level},
+		}
+	}
+
+	zap.L().Info("Adding node and waiting for it to bootstrap")
+	node, err := network.AddNode(ctx, newNodeConfig("ready", bootNode.GetBootstrapIP(), backend.ReadinessBootstrapped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrapped, err := info.NewClient(node.GetHTTPBaseURI()).IsBootstrapped(ctx, "P")
+	if err != nil {
+		t.Fatal(err)
 	}
+	assert.True(bootstrapped, "expected node to be bootstrapped once AddNode returned")
+
+	zap.L().Info("Adding node that cannot become healthy")
+	unreadyCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err = network.AddNode(unreadyCtx, newNodeConfig("unready", "127.0.0.1:1", backend.ReadinessHealthy))
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	_, err = network.GetNode("unready")
+	assert.Error(err, "expected a node that never became ready not to be added to the network")
+
+	_, err = network.AddNode(ctx, backend.NodeConfig{Name: "invalid", Readiness: &backend.ReadinessConfig{Level: "unknown"}})
+	assert.Error(err)
 }
@@ -0,0 +1,44 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TenantMetadataKey is the metadata key of the tenant that a request is made on behalf of. The gateway forwards the
+// header "Grpc-Metadata-Tenant" under the same key.
+const TenantMetadataKey = "tenant"
+
+// tenantRegex matches valid tenant IDs, which never contain the separator between a tenant and the names it owns
+var tenantRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateTenant returns an error if [tenant] is not a valid tenant ID
+func ValidateTenant(tenant string) error {
+	if !tenantRegex.MatchString(tenant) {
+		return fmt.Errorf("invalid tenant %q, expected at most 63 lowercase alphanumeric characters or '-'", tenant)
+	}
+	return nil
+}
+
+// TenantFromContext returns the tenant in the incoming metadata of [ctx] or an empty string if there is none
+func TenantFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	values := md.Get(TenantMetadataKey)
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return values[0], ValidateTenant(values[0])
+	default:
+		return "", fmt.Errorf("expected a single tenant, found %d", len(values))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TokenEnvVar is the environment variable that the bearer token of the server and the CLI defaults to, so that the
+	// token does not need to be passed on the command line.
+	TokenEnvVar = "AVALANCHE_NETWORK_RUNNER_AUTH_TOKEN"
+
+	// authorizationKey is the metadata key of the bearer token. The gateway forwards the Authorization header of HTTP
+	// requests under the same key.
+	authorizationKey = "authorization"
+	bearerPrefix     = "bearer "
+)
+
+var (
+	_ credentials.PerRPCCredentials = tokenCredentials{}
+
+	errUnauthenticated = status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+)
+
+// UnaryServerInterceptor rejects unary requests that are not authenticated with the bearer token [token]
+func UnaryServerInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !authenticated(ctx, token) {
+			return nil, errUnauthenticated
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streaming requests that are not authenticated with the bearer token [token]
+func StreamServerInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !authenticated(ss.Context(), token) {
+			return errUnauthenticated
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authenticated returns true if the metadata of [ctx] carries the bearer token [token]
+func authenticated(ctx context.Context, token string) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, value := range md.Get(authorizationKey) {
+		if len(value) < len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(value[len(bearerPrefix):]), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenCredentials attaches a bearer token to every request
+type tokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+// NewTokenCredentials returns credentials that authenticate every request with the bearer token [token]. If
+// [requireTLS] is true, the token is never sent over a connection without TLS.
+func NewTokenCredentials(token string, requireTLS bool) credentials.PerRPCCredentials {
+	return tokenCredentials{token: token, requireTLS: requireTLS}
+}
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool { return c.requireTLS }
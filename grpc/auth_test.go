@@ -0,0 +1,251 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/client"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testAuthToken = "secret"
+
+// testPKI holds the paths of a CA and of a server and client certificate signed by it
+type testPKI struct {
+	caFile                    string
+	serverCertFile, serverKey string
+	clientCertFile, clientKey string
+}
+
+// newTestPKI writes a CA, a server certificate for localhost and a client certificate to a temporary directory
+func newTestPKI(t *testing.T) testPKI {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki := testPKI{caFile: filepath.Join(dir, "ca.pem")}
+	writePEM(t, pki.caFile, "CERTIFICATE", caDER)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	pki.serverCertFile, pki.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	pki.clientCertFile, pki.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dialSecure returns a client of [endpoint] configured with [security], or the error dialing it
+func dialSecure(t *testing.T, endpoint string, security client.SecurityConfig) (client.Client, error) {
+	cli, err := client.New(client.Config{
+		LogLevel:       zapcore.InfoLevel.String(),
+		Endpoint:       endpoint,
+		DialTimeout:    2 * time.Second,
+		SecurityConfig: security,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, cli.Close(), "closing grpc client")
+	})
+	return cli, nil
+}
+
+func TestAuthGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	// Make sure that the clients only send the tokens configured below.
+	t.Setenv(auth.TokenEnvVar, "")
+
+	pki := newTestPKI(t)
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{}))
+	t.Cleanup(func() {
+		assert.NoError(orchestrator.Teardown(context.Background()))
+	})
+	s, err := server.New(server.Config{
+		Port:            ":8088",
+		GwPort:          ":8089",
+		DialTimeout:     10 * time.Second,
+		TLSCertFile:     pki.serverCertFile,
+		TLSKeyFile:      pki.serverKey,
+		TLSClientCAFile: pki.caFile,
+		AuthToken:       testAuthToken,
+	}, orchestrator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	mTLS := client.SecurityConfig{
+		TLSCAFile:   pki.caFile,
+		TLSCertFile: pki.clientCertFile,
+		TLSKeyFile:  pki.clientKey,
+		AuthToken:   testAuthToken,
+	}
+
+	// The server rejects plaintext connections and TLS connections without a client certificate.
+	_, err = dialSecure(t, "localhost:8088", client.SecurityConfig{AuthToken: testAuthToken})
+	assert.Error(err, "expected plaintext dial to fail")
+	_, err = dialSecure(t, "localhost:8088", client.SecurityConfig{TLSCAFile: pki.caFile, AuthToken: testAuthToken})
+	assert.Error(err, "expected dial without client certificate to fail")
+
+	// Requests without the right token are rejected, including streams.
+	for _, token := range []string{"", "wrong"} {
+		security := mTLS
+		security.AuthToken = token
+		cli, err := dialSecure(t, "localhost:8088", security)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cli.Ping(ctx)
+		assert.Equal(codes.Unauthenticated, status.Code(err), "token %q", token)
+		err = cli.WatchEvents(ctx, func(backend.Event) error { return nil })
+		assert.Equal(codes.Unauthenticated, status.Code(err), "token %q", token)
+	}
+
+	cli, err := dialSecure(t, "localhost:8088", mTLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.Ping(ctx)
+	assert.NoError(err)
+	network, err := cli.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(network.Teardown(ctx))
+
+	// The gateway enforces the same TLS config and token.
+	clientCert, err := tls.LoadX509KeyPair(pki.clientCertFile, pki.clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := auth.ClientTLSConfig(pki.caFile, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping := func(tlsConfig *tls.Config, token string) (int, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://localhost:8089/v1/ping", strings.NewReader("{}"))
+		if err != nil {
+			return 0, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		defer httpClient.CloseIdleConnections()
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer res.Body.Close()
+		_, err = io.Copy(io.Discard, res.Body)
+		return res.StatusCode, err
+	}
+	withCert := tlsConfig.Clone()
+	withCert.Certificates = []tls.Certificate{clientCert}
+	// The gateway starts serving once it has dialed the gRPC server.
+	var code int
+	for {
+		code, err = ping(withCert, "")
+		if !errors.Is(err, syscall.ECONNREFUSED) || ctx.Err() != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, code)
+	code, err = ping(withCert, "wrong")
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, code)
+	code, err = ping(withCert, testAuthToken)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, code)
+	_, err = ping(tlsConfig, testAuthToken)
+	assert.Error(err, "expected gateway request without client certificate to fail")
+}
@@ -5,27 +5,83 @@ package client
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 type Config struct {
 	LogLevel    string
 	Endpoint    string
 	DialTimeout time.Duration
+	// Tenant is the namespace that every request operates in. Requests without a tenant operate on every network of
+	// the server.
+	Tenant string
+	SecurityConfig
+}
+
+// SecurityConfig configures how a client authenticates the server and itself.
+type SecurityConfig struct {
+	// TLS dials the server over TLS. It is implied by any of the TLS files.
+	TLS bool
+	// TLSCAFile verifies the server against the CAs in the file instead of the system roots.
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile are presented to servers that require mTLS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSServerName overrides the name verified against the certificate of the server.
+	TLSServerName string
+	// AuthToken is sent as a bearer token with every request. Defaults to the value of auth.TokenEnvVar.
+	AuthToken string
+}
+
+// dialOptions returns the options to dial the server with according to [cfg]
+func (cfg SecurityConfig) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if cfg.AuthToken == "" {
+		cfg.AuthToken = os.Getenv(auth.TokenEnvVar)
+	}
+	useTLS := cfg.TLS || cfg.TLSCAFile != "" || cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" || cfg.TLSServerName != ""
+	if useTLS {
+		tlsConfig, err := auth.ClientTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSServerName)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if cfg.AuthToken != "" {
+		if !useTLS {
+			zap.L().Warn("sending auth token without TLS")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(auth.NewTokenCredentials(cfg.AuthToken, false)))
+	}
+	return opts, nil
 }
 
 type Client interface {
 	backend.NetworkOrchestrator
+	backend.EventWatcher
+	// ExecutorManager manages the executables registered on the server, which are shared by every client
+	backend.ExecutorManager
 	Ping(ctx context.Context) (*rpcpb.PingResponse, error)
+	// OrchestratorClient returns the underlying gRPC client, which can be used to issue requests
+	// against networks that were not created by this client.
+	OrchestratorClient() rpcpb.OrchestratorServiceClient
 	Close() error
 }
 
@@ -38,6 +94,7 @@ type client struct {
 
 	orchestratorc rpcpb.OrchestratorServiceClient
 	backend.NetworkOrchestrator
+	backend.ExecutorManager
 
 	closed    chan struct{}
 	closeOnce sync.Once
@@ -52,12 +109,28 @@ func New(cfg Config) (Client, error) {
 
 	zap.L().Info("Dialing grpc server", zap.String("endpoint", cfg.Endpoint))
 
+	opts, err := cfg.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Tenant != "" {
+		if err := auth.ValidateTenant(cfg.Tenant); err != nil {
+			return nil, err
+		}
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				return invoker(metadata.AppendToOutgoingContext(ctx, auth.TenantMetadataKey, cfg.Tenant), method, req, reply, cc, opts...)
+			}),
+			grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(metadata.AppendToOutgoingContext(ctx, auth.TenantMetadataKey, cfg.Tenant), desc, cc, method, opts...)
+			}),
+		)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
 	conn, err := grpc.DialContext(
 		ctx,
 		cfg.Endpoint,
-		grpc.WithBlock(),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		append(opts, grpc.WithBlock())...,
 	)
 	cancel()
 	if err != nil {
@@ -74,6 +147,7 @@ func New(cfg Config) (Client, error) {
 		pingc:               rpcpb.NewPingServiceClient(conn),
 		orchestratorc:       orchestratorc,
 		NetworkOrchestrator: orchestrator,
+		ExecutorManager:     orchestratorBackend,
 		closed:              make(chan struct{}),
 	}, nil
 }
@@ -86,6 +160,44 @@ func (c *client) Ping(ctx context.Context) (*rpcpb.PingResponse, error) {
 	return c.pingc.Ping(ctx, &rpcpb.PingRequest{})
 }
 
+// WatchEvents streams the events published by the server, which include the changes made by every other client.
+func (c *client) WatchEvents(ctx context.Context, f func(backend.Event) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watchClient, err := c.orchestratorc.WatchEvents(ctx, &rpcpb.WatchEventsRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := watchClient.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(newEvent(res.Event)); err != nil {
+			return err
+		}
+	}
+}
+
+// newEvent returns the backend.Event described by [event]
+func newEvent(event *rpcpb.Event) backend.Event {
+	return backend.Event{
+		Type:    backend.EventType(event.Type),
+		Network: event.Network,
+		Node:    event.Node,
+		Time:    time.Unix(0, event.Timestamp),
+		Message: event.Message,
+	}
+}
+
+func (c *client) OrchestratorClient() rpcpb.OrchestratorServiceClient {
+	return c.orchestratorc
+}
+
 func (c *client) Close() error {
 	c.closeOnce.Do(func() {
 		close(c.closed)
@@ -0,0 +1,20 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package client
+
+import (
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
+	"github.com/spf13/pflag"
+)
+
+// RegisterSecurityFlags registers the flags that populate [cfg] on [flags], so that every command dialing the server
+// accepts the same TLS and auth token flags.
+func RegisterSecurityFlags(flags *pflag.FlagSet, cfg *SecurityConfig) {
+	flags.BoolVar(&cfg.TLS, "tls", false, "Dial the server over TLS, verifying it against the system roots unless --tls-ca-file is set. Implied by the other TLS flags.")
+	flags.StringVar(&cfg.TLSCAFile, "tls-ca-file", "", "Path to a PEM file of the CAs to verify the server certificate against.")
+	flags.StringVar(&cfg.TLSCertFile, "tls-cert-file", "", "Path to the PEM client certificate to present to servers that require mTLS.")
+	flags.StringVar(&cfg.TLSKeyFile, "tls-key-file", "", "Path to the PEM key of --tls-cert-file.")
+	flags.StringVar(&cfg.TLSServerName, "tls-server-name", "", "Name to verify the server certificate against. Defaults to the host of --endpoint.")
+	flags.StringVar(&cfg.AuthToken, "auth-token", "", "Bearer token to authenticate with the server. Defaults to $"+auth.TokenEnvVar+".")
+}
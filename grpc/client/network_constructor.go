@@ -7,12 +7,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
 )
 
-var _ backend.NetworkConstructor = &networkConstructor{}
+var (
+	_ backend.NetworkConstructor = &networkConstructor{}
+	_ backend.ReadinessProber    = &networkConstructor{}
+	_ backend.Snapshotter        = &networkConstructor{}
+	_ backend.FaultInjector      = &networkConstructor{}
+	_ backend.NodeUpdater        = &networkConstructor{}
+)
 
 type networkConstructor struct {
 	network string
@@ -42,6 +49,72 @@ func (n *networkConstructor) AddNode(ctx context.Context, config backend.NodeCon
 	return newNode(n.network, res.Node, n.client)
 }
 
+// AwaitReady returns immediately, since the server waits for the node to become ready before responding to AddNode.
+// The node may not be reachable from the client, so it is not probed again.
+func (n *networkConstructor) AwaitReady(context.Context, backend.Node, backend.ReadinessConfig) error {
+	return nil
+}
+
+func (n *networkConstructor) Snapshot(ctx context.Context, name string) error {
+	_, err := n.client.SnapshotNetwork(ctx, &rpcpb.SnapshotNetworkRequest{
+		Network:  n.network,
+		Snapshot: name,
+	})
+	return err
+}
+
+func (n *networkConstructor) Partition(ctx context.Context, partitions [][]string) error {
+	req := &rpcpb.PartitionNetworkRequest{
+		Network:    n.network,
+		Partitions: make([]*rpcpb.Partition, 0, len(partitions)),
+	}
+	for _, partition := range partitions {
+		req.Partitions = append(req.Partitions, &rpcpb.Partition{Nodes: partition})
+	}
+	_, err := n.client.PartitionNetwork(ctx, req)
+	return err
+}
+
+func (n *networkConstructor) Heal(ctx context.Context) error {
+	_, err := n.client.HealNetwork(ctx, &rpcpb.HealNetworkRequest{
+		Network: n.network,
+	})
+	return err
+}
+
+func (n *networkConstructor) SetLinkFaults(ctx context.Context, from string, to string, faults backend.LinkFaults) error {
+	_, err := n.client.SetLinkFaults(ctx, &rpcpb.SetLinkFaultsRequest{
+		Network: n.network,
+		From:    from,
+		To:      to,
+		Faults: &rpcpb.LinkFaults{
+			Latency:   int64(faults.Latency),
+			Jitter:    int64(faults.Jitter),
+			Bandwidth: faults.Bandwidth,
+			DropRate:  faults.DropRate,
+		},
+	})
+	return err
+}
+
+func (n *networkConstructor) UpdateNode(ctx context.Context, name string, update backend.NodeUpdate, stopTimeout time.Duration) (backend.Node, error) {
+	updateBytes, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal node update: %w", err)
+	}
+	res, err := n.client.UpdateNode(ctx, &rpcpb.UpdateNodeRequest{
+		Network: n.network,
+		Name:    name,
+		Update:  updateBytes,
+		Timeout: int64(stopTimeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newNode(n.network, res.Node, n.client)
+}
+
 func (n *networkConstructor) Teardown(ctx context.Context) error {
 	_, err := n.client.Teardown(ctx, &rpcpb.TeardownRequest{
 		Network: n.network,
@@ -6,19 +6,33 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"go.uber.org/zap"
 )
 
-var _ backend.Node = &node{}
+// statusTimeout bounds the request made by Status, which does not take a context
+const statusTimeout = 10 * time.Second
+
+var (
+	_ backend.Node               = &node{}
+	_ backend.NodeLogger         = &node{}
+	_ backend.ExecutableReporter = &node{}
+)
 
 type node struct {
-	network  string
+	network string
+	config  map[string]interface{}
+	client  rpcpb.OrchestratorServiceClient
+
+	// lock protects [nodeInfo], which is updated with the latest state of the node reported by the server.
+	lock     sync.RWMutex
 	nodeInfo *rpcpb.NodeInfo
-	config   map[string]interface{}
-	client   rpcpb.OrchestratorServiceClient
 }
 
 func newNode(network string, nodeInfo *rpcpb.NodeInfo, client rpcpb.OrchestratorServiceClient) (backend.Node, error) {
@@ -35,19 +49,135 @@ func newNode(network string, nodeInfo *rpcpb.NodeInfo, client rpcpb.Orchestrator
 	}, nil
 }
 
-func (n *node) GetName() string { return n.nodeInfo.Name }
+func (n *node) GetName() string { return n.getNodeInfo().Name }
 
 func (n *node) Config() map[string]interface{} { return n.config }
 
-func (n *node) GetHTTPBaseURI() string { return n.nodeInfo.Uri }
+func (n *node) Executable() string { return n.getNodeInfo().Executable }
+
+func (n *node) GetHTTPBaseURI() string { return n.getNodeInfo().Uri }
+
+func (n *node) GetBootstrapIP() string { return n.getNodeInfo().Bootstrapip }
+
+// Status fetches the current status of the node from the server. If the server cannot be reached within
+// statusTimeout, it returns the last status reported by the server. If the server reported a status that is not
+// recognized, it returns NodeUnknown.
+func (n *node) Status() backend.NodeStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+	defer cancel()
+	res, err := n.client.GetNode(ctx, &rpcpb.GetNodeRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+	})
+	if err != nil {
+		zap.L().Warn("failed to fetch node status", zap.String("name", n.GetName()), zap.Error(err))
+	} else {
+		n.setNodeInfo(res.Node)
+	}
 
-func (n *node) GetBootstrapIP() string { return n.nodeInfo.Bootstrapip }
+	status, err := backend.ParseNodeStatus(n.getNodeInfo().Status)
+	if err != nil {
+		zap.L().Warn("server reported invalid node status", zap.String("name", n.GetName()), zap.Error(err))
+		return backend.NodeUnknown
+	}
+	return status
+}
 
 func (n *node) Stop(timeout time.Duration) error {
 	_, err := n.client.NodeStop(context.Background(), &rpcpb.NodeStopRequest{
 		Network: n.network,
-		Name:    n.nodeInfo.Name,
+		Name:    n.GetName(),
 		Timeout: int64(timeout),
 	})
 	return err
 }
+
+func (n *node) Restart(ctx context.Context, stopTimeout time.Duration) error {
+	res, err := n.client.NodeRestart(ctx, &rpcpb.NodeRestartRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+		Timeout: int64(stopTimeout),
+	})
+	if err != nil {
+		return err
+	}
+	n.setNodeInfo(res.Node)
+	return nil
+}
+
+func (n *node) Pause() error {
+	res, err := n.client.NodePause(context.Background(), &rpcpb.NodePauseRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+	})
+	if err != nil {
+		return err
+	}
+	n.setNodeInfo(res.Node)
+	return nil
+}
+
+func (n *node) Resume() error {
+	res, err := n.client.NodeResume(context.Background(), &rpcpb.NodeResumeRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+	})
+	if err != nil {
+		return err
+	}
+	n.setNodeInfo(res.Node)
+	return nil
+}
+
+func (n *node) GetLogs(ctx context.Context, stream backend.LogStream, lines int) ([]string, error) {
+	res, err := n.client.GetNodeLogs(ctx, &rpcpb.GetNodeLogsRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+		Stream:  string(stream),
+		Lines:   int64(lines),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Lines, nil
+}
+
+func (n *node) TailLogs(ctx context.Context, stream backend.LogStream, f func(line string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tailClient, err := n.client.TailNodeLogs(ctx, &rpcpb.TailNodeLogsRequest{
+		Network: n.network,
+		Name:    n.GetName(),
+		Stream:  string(stream),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := tailClient.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(res.Line); err != nil {
+			return err
+		}
+	}
+}
+
+func (n *node) getNodeInfo() *rpcpb.NodeInfo {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.nodeInfo
+}
+
+func (n *node) setNodeInfo(nodeInfo *rpcpb.NodeInfo) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.nodeInfo = nodeInfo
+}
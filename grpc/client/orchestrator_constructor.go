@@ -11,13 +11,18 @@ import (
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
 )
 
-var _ backend.OrchestratorBackend = &orchestrator{}
+var (
+	_ backend.OrchestratorBackend = &orchestrator{}
+	_ backend.NetworkAttacher     = &orchestrator{}
+	_ backend.SnapshotRestorer    = &orchestrator{}
+	_ backend.ExecutorManager     = &orchestrator{}
+)
 
 type orchestrator struct {
 	client rpcpb.OrchestratorServiceClient
 }
 
-func newOrchestrator(client rpcpb.OrchestratorServiceClient) backend.OrchestratorBackend {
+func newOrchestrator(client rpcpb.OrchestratorServiceClient) *orchestrator {
 	return &orchestrator{
 		client: client,
 	}
@@ -31,6 +36,119 @@ func (o *orchestrator) CreateNetworkConstructor(name string) (backend.NetworkCon
 	return newNetwork(name, o.client), nil
 }
 
+func (o *orchestrator) ListNetworks(ctx context.Context) ([]string, error) {
+	res, err := o.client.ListNetworks(ctx, &rpcpb.ListNetworksRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(res.Networks))
+	for _, networkInfo := range res.Networks {
+		names = append(names, networkInfo.Name)
+	}
+	return names, nil
+}
+
+func (o *orchestrator) AttachNetworkConstructor(ctx context.Context, name string) (backend.NetworkConstructor, []backend.Node, error) {
+	res, err := o.client.GetNetwork(ctx, &rpcpb.GetNetworkRequest{Network: name})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes, err := newNodes(res.Network, o.client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newNetwork(name, o.client), nodes, nil
+}
+
+func (o *orchestrator) ListSnapshots(ctx context.Context) ([]string, error) {
+	res, err := o.client.ListSnapshots(ctx, &rpcpb.ListSnapshotsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Snapshots, nil
+}
+
+func (o *orchestrator) RestoreSnapshotConstructor(ctx context.Context, snapshot string, name string) (string, backend.NetworkConstructor, []backend.Node, error) {
+	res, err := o.client.RestoreSnapshot(ctx, &rpcpb.RestoreSnapshotRequest{
+		Snapshot: snapshot,
+		Network:  name,
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	nodes, err := newNodes(res.Network, o.client)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return res.Network.Name, newNetwork(res.Network.Name, o.client), nodes, nil
+}
+
+// newNodes returns the nodes of the network described by [networkInfo]
+func newNodes(networkInfo *rpcpb.NetworkInfo, client rpcpb.OrchestratorServiceClient) ([]backend.Node, error) {
+	nodes := make([]backend.Node, 0, len(networkInfo.Nodes))
+	for _, nodeInfo := range networkInfo.Nodes {
+		node, err := newNode(networkInfo.Name, nodeInfo, client)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
 func (o *orchestrator) Teardown(ctx context.Context) error {
 	return errors.New("cannot tear down server network constructor")
 }
+
+func (o *orchestrator) RegisterExecutor(ctx context.Context, name string, path string, sha256 string) (backend.Executor, error) {
+	res, err := o.client.RegisterExecutor(ctx, &rpcpb.RegisterExecutorRequest{
+		Name:   name,
+		Path:   path,
+		Sha256: sha256,
+	})
+	if err != nil {
+		return backend.Executor{}, err
+	}
+	return newExecutor(res.Executor), nil
+}
+
+func (o *orchestrator) DiscoverExecutors(ctx context.Context, dir string) ([]backend.Executor, error) {
+	res, err := o.client.DiscoverExecutors(ctx, &rpcpb.DiscoverExecutorsRequest{Directory: dir})
+	if err != nil {
+		return nil, err
+	}
+	return newExecutors(res.Executors), nil
+}
+
+func (o *orchestrator) ListExecutors(ctx context.Context) ([]backend.Executor, error) {
+	res, err := o.client.ListExecutors(ctx, &rpcpb.ListExecutorsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return newExecutors(res.Executors), nil
+}
+
+func (o *orchestrator) UnregisterExecutor(ctx context.Context, name string) error {
+	_, err := o.client.UnregisterExecutor(ctx, &rpcpb.UnregisterExecutorRequest{Name: name})
+	return err
+}
+
+func newExecutors(executorInfos []*rpcpb.ExecutorInfo) []backend.Executor {
+	executors := make([]backend.Executor, 0, len(executorInfos))
+	for _, executorInfo := range executorInfos {
+		executors = append(executors, newExecutor(executorInfo))
+	}
+	return executors
+}
+
+func newExecutor(executorInfo *rpcpb.ExecutorInfo) backend.Executor {
+	return backend.Executor{
+		Name:    executorInfo.GetName(),
+		Path:    executorInfo.GetPath(),
+		Version: executorInfo.GetVersion(),
+		SHA256:  executorInfo.GetSha256(),
+	}
+}
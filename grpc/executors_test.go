@@ -0,0 +1,79 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExecutorsGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	startServer(ctx, t, ":8098", ":8099")
+	client := newClient(t, "localhost:8098")
+
+	dir := t.TempDir()
+	for version, path := range map[string]string{
+		"1.7.9":  filepath.Join(dir, "v1.7.9", "avalanchego"),
+		"1.7.10": filepath.Join(dir, "v1.7.10", "build", "avalanchego"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		script := "#!/bin/sh\necho \"avalanche/" + version + " [database=v1.4.5]\"\n"
+		if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	executor, err := client.RegisterExecutor(ctx, "", filepath.Join(dir, "v1.7.9", "avalanchego"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("v1.7.9", executor.Name)
+	assert.Equal("v1.7.9", executor.Version)
+	assert.Len(executor.SHA256, 64)
+	_, err = client.RegisterExecutor(ctx, "other", filepath.Join(dir, "v1.7.9", "avalanchego"), "invalid")
+	assert.Error(err, "expected executable with a different checksum to be rejected")
+
+	executors, err := client.DiscoverExecutors(ctx, dir)
+	assert.NoError(err)
+	if assert.Len(executors, 1, "expected the registered version to be skipped") {
+		assert.Equal("v1.7.10", executors[0].Name)
+	}
+
+	assert.NoError(client.UnregisterExecutor(ctx, "v1.7.9"))
+	executors, err = client.ListExecutors(ctx)
+	assert.NoError(err)
+	names := make([]string, 0, len(executors))
+	for _, executor := range executors {
+		names = append(names, executor.Name)
+	}
+	assert.Equal([]string{constants.NormalExecution, "v1.7.10"}, names)
+
+	// The executors are shared by every tenant, so tenants may list them but not change them.
+	alice := newTenantClient(t, "localhost:8098", "alice")
+	res, err := alice.ListExecutors(ctx, &rpcpb.ListExecutorsRequest{})
+	assert.NoError(err)
+	assert.Len(res.Executors, 2)
+	_, err = alice.RegisterExecutor(ctx, &rpcpb.RegisterExecutorRequest{Name: "alice", Path: filepath.Join(dir, "v1.7.9", "avalanchego")})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "expected a tenant registering an executor to be denied")
+	_, err = alice.DiscoverExecutors(ctx, &rpcpb.DiscoverExecutorsRequest{Directory: dir})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "expected a tenant discovering executors to be denied")
+	_, err = alice.UnregisterExecutor(ctx, &rpcpb.UnregisterExecutorRequest{Name: "v1.7.10"})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "expected a tenant unregistering an executor to be denied")
+	assert.Error(client.UnregisterExecutor(ctx, "v1.7.9"), "expected unregistering a missing executor to fail")
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjectionGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	s, err := server.New(server.Config{
+		Port:        ":8092",
+		GwPort:      ":8093",
+		DialTimeout: 10 * time.Second,
+	}, backend.NewOrchestrator(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	client := newClient(t, "localhost:8092")
+	network, err := client.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"node0", "node1", "node2"} {
+		if _, err := network.AddNode(ctx, backend.NodeConfig{Name: name, Executable: "fake", Config: map[string]interface{}{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	constructor, ok := fake.Network("network")
+	if !assert.True(ok, "expected network to be created on the server") {
+		return
+	}
+
+	assert.NoError(network.Partition(ctx, [][]string{{"node0", "node1"}, {"node2"}}))
+	assert.Equal([][]string{{"node0", "node1"}, {"node2"}}, constructor.Partitions())
+	assert.Error(network.Partition(ctx, [][]string{{"node3"}}), "expected partition of missing node to fail on the server")
+
+	faults := backend.LinkFaults{Latency: time.Second, Jitter: time.Millisecond, Bandwidth: 1024, DropRate: 0.5}
+	assert.NoError(network.SetLinkFaults(ctx, "node0", "node2", faults))
+	assert.Equal(faults, constructor.LinkFaults("node0", "node2"))
+	assert.Error(network.SetLinkFaults(ctx, "node0", "node2", backend.LinkFaults{Latency: -time.Second}), "expected invalid faults to fail")
+
+	assert.NoError(network.Heal(ctx))
+	assert.Nil(constructor.Partitions())
+	assert.Equal(backend.LinkFaults{}, constructor.LinkFaults("node0", "node2"))
+
+	assert.NoError(network.Teardown(ctx))
+}
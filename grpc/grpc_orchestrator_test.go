@@ -8,19 +8,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/e2e"
 	"github.com/aaronbuchwald/avalanche-network-runner/grpc/client"
 	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
 	"github.com/aaronbuchwald/avalanche-network-runner/localbinary"
+	"github.com/aaronbuchwald/avalanche-network-runner/networks"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/ava-labs/avalanchego/config"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
 )
 
-func TestOrchestratorGRPC(t *testing.T) {
-	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
-	defer cancel()
-
+// startServer starts a server backed by a local binary orchestrator on [port] and [gwPort]. The server runs until [ctx] is done.
+func startServer(ctx context.Context, t *testing.T, port string, gwPort string) {
 	// Note: t.TempDir() returns a directory that can be cleaned up within the test, whereas
 	// os.TempDir() maintains an open file descriptor, so it cannot be cleaned up by the
 	// network orchestrator.
@@ -33,14 +34,13 @@ func TestOrchestratorGRPC(t *testing.T) {
 	})
 	// We simply tear down the underlying network constructor instead of tearing down the created client, since the client
 	// does not support the teardown operation.
-	defer func() {
-		assert.NoError(t, orchestrator.Teardown(ctx))
-	}()
+	t.Cleanup(func() {
+		assert.NoError(t, orchestrator.Teardown(context.Background()))
+	})
 
-	// Spin up the client and server, so that we can test the client network orchestrator implementation.
 	server, err := server.New(server.Config{
-		Port:        ":8080",
-		GwPort:      ":8081",
+		Port:        port,
+		GwPort:      gwPort,
 		DialTimeout: 10 * time.Second,
 	}, orchestrator)
 	if err != nil {
@@ -49,18 +49,196 @@ func TestOrchestratorGRPC(t *testing.T) {
 	go func() {
 		assert.NoError(t, server.Run(ctx), "server run error")
 	}()
+}
 
+func newClient(t *testing.T, endpoint string) client.Client {
 	client, err := client.New(client.Config{
 		LogLevel:    zapcore.InfoLevel.String(),
-		Endpoint:    "localhost:8080",
+		Endpoint:    endpoint,
 		DialTimeout: 10 * time.Second,
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer func() {
+	t.Cleanup(func() {
 		assert.NoError(t, client.Close(), "closing grpc client")
-	}()
+	})
+	return client
+}
+
+func TestOrchestratorGRPC(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	// Spin up the client and server, so that we can test the client network orchestrator implementation.
+	startServer(ctx, t, ":8080", ":8081")
+	client := newClient(t, "localhost:8080")
 
 	e2e.TestNetworkOrchestrator(ctx, t, client)
 }
+
+// TestOrchestratorGRPCAttach tests that a client can find and operate on a network that was created by a different client.
+func TestOrchestratorGRPCAttach(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	startServer(ctx, t, ":8082", ":8083")
+	creator := newClient(t, "localhost:8082")
+	attacher := newClient(t, "localhost:8082")
+	events := watchEvents(ctx, t, attacher, creator)
+
+	networkConfig := networks.CreateLocalNetworkConfig(constants.NormalExecution)
+	networkConfig.Nodes = networkConfig.Nodes[:1]
+	createdNetwork, err := networks.NewNetwork(ctx, creator, "attach", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachedNetworks, err := attacher.GetNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(attachedNetworks, 1)
+
+	attachedNetwork, err := attacher.GetNetwork(createdNetwork.GetName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := attachedNetwork.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(nodes, 1)
+	createdNode, err := createdNetwork.GetNode(nodes[0].GetName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(createdNode.GetHTTPBaseURI(), nodes[0].GetHTTPBaseURI())
+
+	// Add a node from the creator and ensure that the attached client picks it up after re-attaching.
+	nodeConfig := networks.CreateLocalNetworkConfig(constants.NormalExecution).Nodes[1]
+	nodeConfig.Config[config.BootstrapIPsKey] = createdNode.GetBootstrapIP()
+	if _, err := createdNetwork.AddNode(ctx, nodeConfig); err != nil {
+		t.Fatal(err)
+	}
+	attachedNetwork, err = attacher.AttachNetwork(ctx, createdNetwork.GetName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err = attachedNetwork.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(nodes, 2)
+
+	// Tear down the network from the attached client and ensure that the creator no longer reports it.
+	assert.NoError(attachedNetwork.Teardown(ctx))
+	createdNetworks, err := creator.GetNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(createdNetworks, 0)
+
+	_, err = attacher.GetNetwork(createdNetwork.GetName())
+	assert.Error(err)
+
+	// The attacher observes every change made by the creator.
+	observed := make(map[backend.EventType][]string)
+	for len(observed[backend.EventNetworkTornDown]) == 0 {
+		select {
+		case event := <-events:
+			assert.Equal("attach", event.Network)
+			observed[event.Type] = append(observed[event.Type], event.Node)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the network to be torn down")
+		}
+	}
+	assert.Len(observed[backend.EventNetworkCreated], 1)
+	assert.ElementsMatch([]string{"node0", "node1"}, observed[backend.EventNodeAdded])
+	assert.ElementsMatch([]string{"node0", "node1"}, observed[backend.EventNodeStarted])
+	assert.ElementsMatch([]string{"node0", "node1"}, observed[backend.EventNodeStopped])
+}
+
+// watchEvents watches the events of the server through [watcher] and returns a channel that receives every event
+// apart from health events. Returns once the watch is active, which is checked by creating networks through [creator]
+// until one of them is observed.
+func watchEvents(ctx context.Context, t *testing.T, watcher client.Client, creator client.Client) <-chan backend.Event {
+	events := make(chan backend.Event, 1024)
+	go func() {
+		_ = watcher.WatchEvents(ctx, func(event backend.Event) error {
+			if event.Type != backend.EventNodeHealthy && event.Type != backend.EventNodeUnhealthy {
+				events <- event
+			}
+			return nil
+		})
+	}()
+
+	for {
+		network, err := creator.CreateNetwork("subscribed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := network.Teardown(ctx); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case event := <-events:
+			// The watch may have started between creating and tearing down the network.
+			for event.Type != backend.EventNetworkTornDown {
+				event = <-events
+			}
+			return events
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func TestNodeLifecycleGRPC(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	startServer(ctx, t, ":8084", ":8085")
+	client := newClient(t, "localhost:8084")
+
+	e2e.TestNodeLifecycle(ctx, t, client)
+}
+
+func TestSnapshotGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	startServer(ctx, t, ":8086", ":8087")
+	client := newClient(t, "localhost:8086")
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := networks.NewNetwork(ctx, client, "original", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := network.Snapshot(ctx, "snapshot"); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := client.ListSnapshots(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"snapshot"}, snapshots)
+
+	restored, err := client.RestoreSnapshot(ctx, "snapshot", "restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("restored", restored.GetName())
+	nodes, err := restored.GetNodes()
+	assert.NoError(err)
+	assert.Len(nodes, 1)
+	for _, node := range nodes {
+		assert.Equal(backend.NodeRunning, node.Status())
+	}
+
+	assert.NoError(restored.Teardown(ctx))
+	assert.NoError(network.Teardown(ctx))
+}
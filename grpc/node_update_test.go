@@ -0,0 +1,80 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateNodeGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	s, err := server.New(server.Config{
+		Port:        ":8094",
+		GwPort:      ":8095",
+		DialTimeout: 10 * time.Second,
+	}, backend.NewOrchestrator(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	client := newClient(t, "localhost:8094")
+	network, err := client.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AddNode(ctx, backend.NodeConfig{Name: "node0", Executable: "fake", Config: map[string]interface{}{"a": "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := network.UpdateNode(ctx, "node0", backend.NodeUpdate{
+		Executable: "fake-v2",
+		Config:     map[string]interface{}{"a": nil, "c": "d"},
+		Plugins:    map[string]string{"vm": "fake-vm"},
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(map[string]interface{}{"c": "d"}, node.Config())
+	constructor, ok := fake.Network("network")
+	if !assert.True(ok, "expected network to be created on the server") {
+		return
+	}
+	nodes := constructor.RunningNodes()
+	if assert.Len(nodes, 1) {
+		assert.Equal("fake-v2", nodes[0].NodeConfig().Executable)
+		assert.Equal(map[string]string{"vm": "fake-vm"}, nodes[0].NodeConfig().Plugins)
+	}
+	nodeAfterUpdate, err := network.GetNode("node0")
+	assert.NoError(err)
+	assert.Equal(node, nodeAfterUpdate, "expected the network to track the updated node")
+
+	_, err = network.UpdateNode(ctx, "node1", backend.NodeUpdate{}, time.Second)
+	assert.Error(err, "expected update of missing node to fail on the server")
+
+	_, err = client.OrchestratorClient().DeploySubnet(ctx, &rpcpb.DeploySubnetRequest{Network: "network"})
+	assert.Error(err, "expected subnet without blockchains to be rejected")
+
+	assert.NoError(network.Teardown(ctx))
+}
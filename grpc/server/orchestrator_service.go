@@ -6,95 +6,132 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/networks"
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type OrchestratorServiceHandler struct {
 	rpcpb.UnimplementedOrchestratorServiceServer
 
 	orchestrator backend.NetworkOrchestrator
+	// requireTenant rejects requests that do not specify a tenant
+	requireTenant bool
+	quotas        *quotas
 }
 
 func NewOrchestatorServiceHandler(orchestrator backend.NetworkOrchestrator) *OrchestratorServiceHandler {
 	return &OrchestratorServiceHandler{
 		orchestrator: orchestrator,
+		quotas:       newQuotas(TenantLimits{}),
 	}
 }
 
 func (o *OrchestratorServiceHandler) CreateNetwork(ctx context.Context, req *rpcpb.CreateNetworkRequest) (*rpcpb.CreateNetworkResponse, error) {
+	t, err := getTenant(ctx, o.requireTenant)
+	if err != nil {
+		return nil, err
+	}
+	release, err := o.quotas.reserve(ctx, o.orchestrator, t, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Create the network, but do not save any information in the service handler.
 	// The network is still accessible via the orchestrator by its unique name, which can be used
 	// as the key to access it.
-	_, err := o.orchestrator.CreateNetwork(req.Network)
-	if err != nil {
+	if _, err := o.orchestrator.CreateNetwork(t.scope(req.Network)); err != nil {
 		return nil, err
 	}
 
 	return &rpcpb.CreateNetworkResponse{}, nil
 }
 
-func (o *OrchestratorServiceHandler) GetNodes(ctx context.Context, req *rpcpb.GetNodesRequest) (*rpcpb.GetNodesResponse, error) {
-	network, err := o.orchestrator.GetNetwork(req.Network)
+func (o *OrchestratorServiceHandler) ListNetworks(ctx context.Context, req *rpcpb.ListNetworksRequest) (*rpcpb.ListNetworksResponse, error) {
+	t, err := getTenant(ctx, o.requireTenant)
 	if err != nil {
 		return nil, err
 	}
-
-	nodes, err := network.GetNodes()
+	networks, err := o.orchestrator.GetNetworks()
 	if err != nil {
 		return nil, err
 	}
 
-	nodeInfos := make([]*rpcpb.NodeInfo, 0, len(nodes))
-	for _, node := range nodes {
-		configBytes, err := json.Marshal(node.Config())
+	networkInfos := make([]*rpcpb.NetworkInfo, 0, len(networks))
+	for _, network := range networks {
+		if !t.owns(network.GetName()) {
+			continue
+		}
+		networkInfo, err := newNetworkInfo(t, network)
 		if err != nil {
 			return nil, err
 		}
-		nodeInfos = append(nodeInfos, &rpcpb.NodeInfo{
-			Name:        node.GetName(),
-			Config:      configBytes,
-			Uri:         node.GetHTTPBaseURI(),
-			Bootstrapip: node.GetBootstrapIP(),
-		})
+		networkInfos = append(networkInfos, networkInfo)
 	}
 
-	return &rpcpb.GetNodesResponse{
-		Nodes: nodeInfos,
+	return &rpcpb.ListNetworksResponse{
+		Networks: networkInfos,
 	}, nil
 }
 
-func (o *OrchestratorServiceHandler) GetNode(ctx context.Context, req *rpcpb.GetNodeRequest) (*rpcpb.GetNodeResponse, error) {
-	network, err := o.orchestrator.GetNetwork(req.Network)
+func (o *OrchestratorServiceHandler) GetNetwork(ctx context.Context, req *rpcpb.GetNetworkRequest) (*rpcpb.GetNetworkResponse, error) {
+	t, network, err := o.getNetwork(ctx, req.Network)
 	if err != nil {
 		return nil, err
 	}
 
-	node, err := network.GetNode(req.Name)
+	networkInfo, err := newNetworkInfo(t, network)
 	if err != nil {
 		return nil, err
 	}
 
-	configBytes, err := json.Marshal(node.Config())
+	return &rpcpb.GetNetworkResponse{
+		Network: networkInfo,
+	}, nil
+}
+
+func (o *OrchestratorServiceHandler) GetNodes(ctx context.Context, req *rpcpb.GetNodesRequest) (*rpcpb.GetNodesResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeInfos, err := newNodeInfos(network)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcpb.GetNodesResponse{
+		Nodes: nodeInfos,
+	}, nil
+}
+
+func (o *OrchestratorServiceHandler) GetNode(ctx context.Context, req *rpcpb.GetNodeRequest) (*rpcpb.GetNodeResponse, error) {
+	node, err := o.getNode(ctx, req.Network, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeInfo, err := newNodeInfo(node)
 	if err != nil {
 		return nil, err
 	}
 
 	return &rpcpb.GetNodeResponse{
-		Node: &rpcpb.NodeInfo{
-			Name:        node.GetName(),
-			Config:      configBytes,
-			Uri:         node.GetHTTPBaseURI(),
-			Bootstrapip: node.GetBootstrapIP(),
-		},
+		Node: nodeInfo,
 	}, nil
 }
 
 func (o *OrchestratorServiceHandler) AddNode(ctx context.Context, req *rpcpb.AddNodeRequest) (*rpcpb.AddNodeResponse, error) {
-	network, err := o.orchestrator.GetNetwork(req.Network)
+	t, network, err := o.getNetwork(ctx, req.Network)
 	if err != nil {
 		return nil, err
 	}
@@ -102,27 +139,28 @@ func (o *OrchestratorServiceHandler) AddNode(ctx context.Context, req *rpcpb.Add
 	if err := json.Unmarshal(req.Config, &nodeConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal node config: %w", err)
 	}
+	release, err := o.quotas.reserve(ctx, o.orchestrator, t, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	node, err := network.AddNode(ctx, nodeConfig)
 	if err != nil {
 		return nil, err
 	}
-	configBytes, err := json.Marshal(node.Config())
+	nodeInfo, err := newNodeInfo(node)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal newly created node config: %w", err)
 	}
 
 	return &rpcpb.AddNodeResponse{
-		Node: &rpcpb.NodeInfo{
-			Name:        node.GetName(),
-			Config:      configBytes,
-			Uri:         node.GetHTTPBaseURI(),
-			Bootstrapip: node.GetBootstrapIP(),
-		},
+		Node: nodeInfo,
 	}, nil
 }
 
 func (o *OrchestratorServiceHandler) Teardown(ctx context.Context, req *rpcpb.TeardownRequest) (*rpcpb.TeardownResponse, error) {
-	network, err := o.orchestrator.GetNetwork(req.Network)
+	_, network, err := o.getNetwork(ctx, req.Network)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +169,7 @@ func (o *OrchestratorServiceHandler) Teardown(ctx context.Context, req *rpcpb.Te
 }
 
 func (o *OrchestratorServiceHandler) NodeStop(ctx context.Context, req *rpcpb.NodeStopRequest) (*rpcpb.NodeStopResponse, error) {
-	network, err := o.orchestrator.GetNetwork(req.Network)
+	_, network, err := o.getNetwork(ctx, req.Network)
 	if err != nil {
 		return nil, err
 	}
@@ -142,3 +180,470 @@ func (o *OrchestratorServiceHandler) NodeStop(ctx context.Context, req *rpcpb.No
 
 	return &rpcpb.NodeStopResponse{}, nil
 }
+
+func (o *OrchestratorServiceHandler) NodeRestart(ctx context.Context, req *rpcpb.NodeRestartRequest) (*rpcpb.NodeRestartResponse, error) {
+	node, err := o.getNode(ctx, req.Network, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := node.Restart(ctx, time.Duration(req.Timeout)); err != nil {
+		return nil, err
+	}
+
+	nodeInfo, err := newNodeInfo(node)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.NodeRestartResponse{Node: nodeInfo}, nil
+}
+
+func (o *OrchestratorServiceHandler) NodePause(ctx context.Context, req *rpcpb.NodePauseRequest) (*rpcpb.NodePauseResponse, error) {
+	node, err := o.getNode(ctx, req.Network, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := node.Pause(); err != nil {
+		return nil, err
+	}
+
+	nodeInfo, err := newNodeInfo(node)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.NodePauseResponse{Node: nodeInfo}, nil
+}
+
+func (o *OrchestratorServiceHandler) NodeResume(ctx context.Context, req *rpcpb.NodeResumeRequest) (*rpcpb.NodeResumeResponse, error) {
+	node, err := o.getNode(ctx, req.Network, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := node.Resume(); err != nil {
+		return nil, err
+	}
+
+	nodeInfo, err := newNodeInfo(node)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.NodeResumeResponse{Node: nodeInfo}, nil
+}
+
+func (o *OrchestratorServiceHandler) GetNodeLogs(ctx context.Context, req *rpcpb.GetNodeLogsRequest) (*rpcpb.GetNodeLogsResponse, error) {
+	logger, stream, err := o.getNodeLogger(ctx, req.Network, req.Name, req.Stream)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := logger.GetLogs(ctx, stream, int(req.Lines))
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.GetNodeLogsResponse{Lines: lines}, nil
+}
+
+func (o *OrchestratorServiceHandler) TailNodeLogs(req *rpcpb.TailNodeLogsRequest, srv rpcpb.OrchestratorService_TailNodeLogsServer) error {
+	logger, stream, err := o.getNodeLogger(srv.Context(), req.Network, req.Name, req.Stream)
+	if err != nil {
+		return err
+	}
+
+	return logger.TailLogs(srv.Context(), stream, func(line string) error {
+		return srv.Send(&rpcpb.TailNodeLogsResponse{Line: line})
+	})
+}
+
+func (o *OrchestratorServiceHandler) WatchEvents(req *rpcpb.WatchEventsRequest, srv rpcpb.OrchestratorService_WatchEventsServer) error {
+	t, err := getTenant(srv.Context(), o.requireTenant)
+	if err != nil {
+		return err
+	}
+	watcher, ok := o.orchestrator.(backend.EventWatcher)
+	if !ok {
+		return errors.New("orchestrator does not publish events")
+	}
+
+	networkName := t.scope(req.Network)
+	return watcher.WatchEvents(srv.Context(), func(event backend.Event) error {
+		if !t.owns(event.Network) || (networkName != "" && event.Network != networkName) {
+			return nil
+		}
+		return srv.Send(&rpcpb.WatchEventsResponse{Event: &rpcpb.Event{
+			Type:      string(event.Type),
+			Network:   t.unscope(event.Network),
+			Node:      event.Node,
+			Timestamp: event.Time.UnixNano(),
+			Message:   event.Message,
+		}})
+	})
+}
+
+func (o *OrchestratorServiceHandler) SnapshotNetwork(ctx context.Context, req *rpcpb.SnapshotNetworkRequest) (*rpcpb.SnapshotNetworkResponse, error) {
+	t, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := network.Snapshot(ctx, t.scope(req.Snapshot)); err != nil {
+		return nil, err
+	}
+	return &rpcpb.SnapshotNetworkResponse{}, nil
+}
+
+func (o *OrchestratorServiceHandler) RestoreSnapshot(ctx context.Context, req *rpcpb.RestoreSnapshotRequest) (*rpcpb.RestoreSnapshotResponse, error) {
+	t, err := getTenant(ctx, o.requireTenant)
+	if err != nil {
+		return nil, err
+	}
+	release, err := o.quotas.reserve(ctx, o.orchestrator, t, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The snapshots of a tenant are taken of its own networks, so the saved network name is already scoped to it.
+	network, err := o.orchestrator.RestoreSnapshot(ctx, t.scope(req.Snapshot), t.scope(req.Network))
+	if err != nil {
+		return nil, err
+	}
+	if !t.owns(network.GetName()) {
+		// The snapshot was taken by an unscoped request of a network outside of the namespace of the tenant.
+		err = status.Errorf(codes.PermissionDenied, "snapshot %s restores network %s outside of tenant %s, specify a network name", req.Snapshot, network.GetName(), t)
+	} else {
+		err = o.quotas.checkNodes(ctx, o.orchestrator, t)
+	}
+	if err != nil {
+		if teardownErr := network.Teardown(ctx); teardownErr != nil {
+			zap.L().Error("failed to tear down restored network", zap.String("network", network.GetName()), zap.Error(teardownErr))
+		}
+		return nil, err
+	}
+
+	networkInfo, err := newNetworkInfo(t, network)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.RestoreSnapshotResponse{Network: networkInfo}, nil
+}
+
+func (o *OrchestratorServiceHandler) ListSnapshots(ctx context.Context, req *rpcpb.ListSnapshotsRequest) (*rpcpb.ListSnapshotsResponse, error) {
+	t, err := getTenant(ctx, o.requireTenant)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := o.orchestrator.ListSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantSnapshots := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if t.owns(snapshot) {
+			tenantSnapshots = append(tenantSnapshots, t.unscope(snapshot))
+		}
+	}
+	return &rpcpb.ListSnapshotsResponse{Snapshots: tenantSnapshots}, nil
+}
+
+func (o *OrchestratorServiceHandler) PartitionNetwork(ctx context.Context, req *rpcpb.PartitionNetworkRequest) (*rpcpb.PartitionNetworkResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([][]string, 0, len(req.Partitions))
+	for _, partition := range req.Partitions {
+		partitions = append(partitions, partition.GetNodes())
+	}
+	if err := network.Partition(ctx, partitions); err != nil {
+		return nil, err
+	}
+	return &rpcpb.PartitionNetworkResponse{}, nil
+}
+
+func (o *OrchestratorServiceHandler) HealNetwork(ctx context.Context, req *rpcpb.HealNetworkRequest) (*rpcpb.HealNetworkResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := network.Heal(ctx); err != nil {
+		return nil, err
+	}
+	return &rpcpb.HealNetworkResponse{}, nil
+}
+
+func (o *OrchestratorServiceHandler) SetLinkFaults(ctx context.Context, req *rpcpb.SetLinkFaultsRequest) (*rpcpb.SetLinkFaultsResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	faults := backend.LinkFaults{
+		Latency:   time.Duration(req.Faults.GetLatency()),
+		Jitter:    time.Duration(req.Faults.GetJitter()),
+		Bandwidth: req.Faults.GetBandwidth(),
+		DropRate:  req.Faults.GetDropRate(),
+	}
+	if err := network.SetLinkFaults(ctx, req.From, req.To, faults); err != nil {
+		return nil, err
+	}
+	return &rpcpb.SetLinkFaultsResponse{}, nil
+}
+
+func (o *OrchestratorServiceHandler) UpdateNode(ctx context.Context, req *rpcpb.UpdateNodeRequest) (*rpcpb.UpdateNodeResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+	update := backend.NodeUpdate{}
+	if err := json.Unmarshal(req.Update, &update); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node update: %w", err)
+	}
+
+	node, err := network.UpdateNode(ctx, req.Name, update, time.Duration(req.Timeout))
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo, err := newNodeInfo(node)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.UpdateNodeResponse{Node: nodeInfo}, nil
+}
+
+func (o *OrchestratorServiceHandler) DeploySubnet(ctx context.Context, req *rpcpb.DeploySubnetRequest) (*rpcpb.DeploySubnetResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetConfig := networks.SubnetConfig{
+		Validators:  req.Validators,
+		Blockchains: make([]networks.BlockchainConfig, 0, len(req.Blockchains)),
+	}
+	for _, blockchain := range req.Blockchains {
+		subnetConfig.Blockchains = append(subnetConfig.Blockchains, networks.BlockchainConfig{
+			Name:    blockchain.Name,
+			VM:      blockchain.Vm,
+			VMID:    blockchain.VmId,
+			Genesis: blockchain.Genesis,
+		})
+	}
+	subnet, err := networks.DeploySubnet(ctx, network, subnetConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &rpcpb.DeploySubnetResponse{
+		SubnetId:    subnet.SubnetID,
+		Blockchains: make([]*rpcpb.BlockchainInfo, 0, len(subnet.Blockchains)),
+	}
+	for _, blockchain := range subnet.Blockchains {
+		res.Blockchains = append(res.Blockchains, &rpcpb.BlockchainInfo{
+			Name:         blockchain.Name,
+			VmId:         blockchain.VMID,
+			BlockchainId: blockchain.BlockchainID,
+			RpcUrls:      blockchain.RPCURLs,
+		})
+	}
+	return res, nil
+}
+
+func (o *OrchestratorServiceHandler) RollingUpgrade(ctx context.Context, req *rpcpb.RollingUpgradeRequest) (*rpcpb.RollingUpgradeResponse, error) {
+	_, network, err := o.getNetwork(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	healthGate := backend.ReadinessConfig{
+		Level:  backend.ReadinessLevel(req.HealthGate),
+		Chains: req.HealthGateChains,
+	}
+	upgraded, err := networks.RollingUpgrade(ctx, network, req.FromExecutable, req.ToExecutable, int(req.BatchSize), healthGate)
+	if err != nil {
+		return nil, err
+	}
+	nodeInfos, err := newNodeInfos(network)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.RollingUpgradeResponse{Upgraded: upgraded, Nodes: nodeInfos}, nil
+}
+
+func (o *OrchestratorServiceHandler) RegisterExecutor(ctx context.Context, req *rpcpb.RegisterExecutorRequest) (*rpcpb.RegisterExecutorResponse, error) {
+	manager, err := o.getExecutorManager(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	executor, err := manager.RegisterExecutor(ctx, req.Name, req.Path, req.Sha256)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.RegisterExecutorResponse{Executor: newExecutorInfo(executor)}, nil
+}
+
+func (o *OrchestratorServiceHandler) DiscoverExecutors(ctx context.Context, req *rpcpb.DiscoverExecutorsRequest) (*rpcpb.DiscoverExecutorsResponse, error) {
+	manager, err := o.getExecutorManager(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	executors, err := manager.DiscoverExecutors(ctx, req.Directory)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.DiscoverExecutorsResponse{Executors: newExecutorInfos(executors)}, nil
+}
+
+func (o *OrchestratorServiceHandler) ListExecutors(ctx context.Context, req *rpcpb.ListExecutorsRequest) (*rpcpb.ListExecutorsResponse, error) {
+	manager, err := o.getExecutorManager(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	executors, err := manager.ListExecutors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcpb.ListExecutorsResponse{Executors: newExecutorInfos(executors)}, nil
+}
+
+func (o *OrchestratorServiceHandler) UnregisterExecutor(ctx context.Context, req *rpcpb.UnregisterExecutorRequest) (*rpcpb.UnregisterExecutorResponse, error) {
+	manager, err := o.getExecutorManager(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.UnregisterExecutor(ctx, req.Name); err != nil {
+		return nil, err
+	}
+	return &rpcpb.UnregisterExecutorResponse{}, nil
+}
+
+// getExecutorManager returns the orchestrator as an ExecutorManager. The executors are shared by every tenant, so
+// requests that [modify] them are only accepted without a tenant.
+func (o *OrchestratorServiceHandler) getExecutorManager(ctx context.Context, modify bool) (backend.ExecutorManager, error) {
+	tenant, err := getTenant(ctx, o.requireTenant)
+	if err != nil {
+		return nil, err
+	}
+	if modify && tenant != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s cannot modify the executors shared by every tenant", tenant)
+	}
+	manager, ok := o.orchestrator.(backend.ExecutorManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "orchestrator does not support managing executors")
+	}
+	return manager, nil
+}
+
+// getNodeLogger returns the node [name] from the network [networkName] as a NodeLogger along with the parsed [stream].
+func (o *OrchestratorServiceHandler) getNodeLogger(ctx context.Context, networkName string, name string, stream string) (backend.NodeLogger, backend.LogStream, error) {
+	logStream, err := backend.ParseLogStream(stream)
+	if err != nil {
+		return nil, "", err
+	}
+	node, err := o.getNode(ctx, networkName, name)
+	if err != nil {
+		return nil, "", err
+	}
+	logger, ok := node.(backend.NodeLogger)
+	if !ok {
+		return nil, "", fmt.Errorf("node %s does not capture its logs", name)
+	}
+	return logger, logStream, nil
+}
+
+// getNode returns the node [name] from the network [networkName] of the tenant of [ctx]
+func (o *OrchestratorServiceHandler) getNode(ctx context.Context, networkName string, name string) (backend.Node, error) {
+	_, network, err := o.getNetwork(ctx, networkName)
+	if err != nil {
+		return nil, err
+	}
+	return network.GetNode(name)
+}
+
+// getNetwork returns the tenant of [ctx] along with its network [name]
+func (o *OrchestratorServiceHandler) getNetwork(ctx context.Context, name string) (tenant, backend.Network, error) {
+	t, err := getTenant(ctx, o.requireTenant)
+	if err != nil {
+		return "", nil, err
+	}
+	network, err := o.orchestrator.GetNetwork(t.scope(name))
+	if err != nil {
+		return "", nil, err
+	}
+	return t, network, nil
+}
+
+// newNetworkInfo returns the NetworkInfo describing [network] and all of its nodes, named within the tenant [t].
+func newNetworkInfo(t tenant, network backend.Network) (*rpcpb.NetworkInfo, error) {
+	nodeInfos, err := newNodeInfos(network)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcpb.NetworkInfo{
+		Name:  t.unscope(network.GetName()),
+		Nodes: nodeInfos,
+	}, nil
+}
+
+func newNodeInfos(network backend.Network) ([]*rpcpb.NodeInfo, error) {
+	nodes, err := network.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeInfos := make([]*rpcpb.NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		nodeInfo, err := newNodeInfo(node)
+		if err != nil {
+			return nil, err
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	return nodeInfos, nil
+}
+
+func newNodeInfo(node backend.Node) (*rpcpb.NodeInfo, error) {
+	configBytes, err := json.Marshal(node.Config())
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcpb.NodeInfo{
+		Name:        node.GetName(),
+		Config:      configBytes,
+		Uri:         node.GetHTTPBaseURI(),
+		Bootstrapip: node.GetBootstrapIP(),
+		Status:      node.Status().String(),
+		Executable:  nodeExecutable(node),
+	}, nil
+}
+
+// nodeExecutable returns the name of the executable of [node], or an empty string if the node does not report it
+func nodeExecutable(node backend.Node) string {
+	if reporter, ok := node.(backend.ExecutableReporter); ok {
+		return reporter.Executable()
+	}
+	return ""
+}
+
+func newExecutorInfos(executors []backend.Executor) []*rpcpb.ExecutorInfo {
+	executorInfos := make([]*rpcpb.ExecutorInfo, 0, len(executors))
+	for _, executor := range executors {
+		executorInfos = append(executorInfos, newExecutorInfo(executor))
+	}
+	return executorInfos
+}
+
+func newExecutorInfo(executor backend.Executor) *rpcpb.ExecutorInfo {
+	return &rpcpb.ExecutorInfo{
+		Name:    executor.Name,
+		Path:    executor.Path,
+		Version: executor.Version,
+		Sha256:  executor.SHA256,
+	}
+}
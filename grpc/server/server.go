@@ -5,6 +5,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -12,19 +13,38 @@ import (
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
 	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
 )
 
+// gwBufferSize is the size of the in-memory connection between the gateway and the gRPC server
+const gwBufferSize = 1024 * 1024
+
 var ErrInvalidPort = errors.New("invalid port")
 
 type Config struct {
 	Port        string
 	GwPort      string
 	DialTimeout time.Duration
+
+	// TLSCertFile and TLSKeyFile enable TLS on both the gRPC server and the gateway if set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile requires clients of both servers to present a certificate signed by one of its CAs if set.
+	TLSClientCAFile string
+	// AuthToken requires every request to carry the header "Authorization: Bearer <AuthToken>" if set.
+	AuthToken string
+
+	// RequireTenant rejects requests that do not specify a tenant, which otherwise operate on every network.
+	RequireTenant bool
+	// TenantLimits caps the resources used by each tenant.
+	TenantLimits TenantLimits
 }
 
 type Server interface {
@@ -42,8 +62,10 @@ type server struct {
 	gRPCServer       *grpc.Server
 	gRPCRegisterOnce sync.Once
 
-	gwMux    *runtime.ServeMux
-	gwServer *http.Server
+	gwLn         *bufconn.Listener
+	gwGRPCServer *grpc.Server
+	gwMux        *runtime.ServeMux
+	gwServer     *http.Server
 
 	PingServiceHandler
 	OrchestratorServiceHandler
@@ -54,10 +76,37 @@ func New(cfg Config, orchestrator backend.NetworkOrchestrator) (Server, error) {
 		return nil, ErrInvalidPort
 	}
 
+	var (
+		opts      []grpc.ServerOption
+		tlsConfig *tls.Config
+	)
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" || cfg.TLSClientCAFile != "" {
+		var err error
+		tlsConfig, err = auth.ServerTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AuthToken != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(cfg.AuthToken)),
+			grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(cfg.AuthToken)),
+		)
+	}
+	// The gateway reaches its own gRPC server over an in-memory listener. That server shares the interceptors of the
+	// public one but not its credentials, since TLS is enforced by the gateway's own listener instead.
+	gwGRPCServer := grpc.NewServer(opts...)
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
 	ln, err := net.Listen("tcp", cfg.Port)
 	if err != nil {
 		return nil, err
 	}
+	handler := NewOrchestatorServiceHandler(orchestrator)
+	handler.requireTenant = cfg.RequireTenant
+	handler.quotas = newQuotas(cfg.TenantLimits)
 	gwMux := runtime.NewServeMux()
 	return &server{
 		cfg: cfg,
@@ -65,14 +114,17 @@ func New(cfg Config, orchestrator backend.NetworkOrchestrator) (Server, error) {
 		closed: make(chan struct{}),
 
 		ln:         ln,
-		gRPCServer: grpc.NewServer(),
+		gRPCServer: grpc.NewServer(opts...),
 
-		gwMux: gwMux,
+		gwLn:         bufconn.Listen(gwBufferSize),
+		gwGRPCServer: gwGRPCServer,
+		gwMux:        gwMux,
 		gwServer: &http.Server{
-			Addr:    cfg.GwPort,
-			Handler: gwMux,
+			Addr:      cfg.GwPort,
+			Handler:   gwMux,
+			TLSConfig: tlsConfig,
 		},
-		OrchestratorServiceHandler: *NewOrchestatorServiceHandler(orchestrator),
+		OrchestratorServiceHandler: *handler,
 	}, nil
 }
 
@@ -88,12 +140,24 @@ func (s *server) Run(rootCtx context.Context) (err error) {
 
 	gwErrc := make(chan error)
 	go func() {
-		zap.L().Info("dialing gRPC server", zap.String("port", s.cfg.Port))
+		gwGRPCErrc := make(chan error, 1)
+		go func() {
+			gwGRPCErrc <- s.gwGRPCServer.Serve(s.gwLn)
+		}()
+		defer func() {
+			s.gwGRPCServer.Stop()
+			<-gwGRPCErrc
+		}()
+
+		zap.L().Info("dialing gateway gRPC server")
 		ctx, cancel := context.WithTimeout(rootCtx, s.cfg.DialTimeout)
 		gwConn, err := grpc.DialContext(
 			ctx,
-			"0.0.0.0"+s.cfg.Port,
+			"bufnet",
 			grpc.WithBlock(),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return s.gwLn.DialContext(ctx)
+			}),
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
 		)
 		cancel()
@@ -108,7 +172,12 @@ func (s *server) Run(rootCtx context.Context) (err error) {
 			return
 		}
 
-		zap.L().Info("serving gRPC gateway", zap.String("port", s.cfg.GwPort))
+		zap.L().Info("serving gRPC gateway", zap.String("port", s.cfg.GwPort), zap.Bool("tls", s.gwServer.TLSConfig != nil))
+		if s.gwServer.TLSConfig != nil {
+			// The certificate is already loaded into the TLS config.
+			gwErrc <- s.gwServer.ListenAndServeTLS("", "")
+			return
+		}
 		gwErrc <- s.gwServer.ListenAndServe()
 	}()
 
@@ -143,8 +212,10 @@ func (s *server) Run(rootCtx context.Context) (err error) {
 
 func (s *server) registerServiceServers() {
 	s.gRPCRegisterOnce.Do(func() {
-		rpcpb.RegisterPingServiceServer(s.gRPCServer, s)
-		rpcpb.RegisterOrchestratorServiceServer(s.gRPCServer, s)
+		for _, gRPCServer := range []*grpc.Server{s.gRPCServer, s.gwGRPCServer} {
+			rpcpb.RegisterPingServiceServer(gRPCServer, s)
+			rpcpb.RegisterOrchestratorServiceServer(gRPCServer, s)
+		}
 	})
 }
 
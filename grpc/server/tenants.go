@@ -0,0 +1,221 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tenantSeparator separates the tenant from the name of the networks and snapshots it owns in the orchestrator. Tenant
+// IDs cannot contain it, so the owner of a name is unambiguous.
+const tenantSeparator = "."
+
+// TenantLimits caps the resources used by each tenant. Zero values are unlimited.
+type TenantLimits struct {
+	// MaxNetworks is the maximum number of networks of a tenant
+	MaxNetworks int
+	// MaxNodes is the maximum number of nodes across the networks of a tenant
+	MaxNodes int
+	// MaxDiskBytes is the disk usage across the networks of a tenant above which new networks and nodes are rejected.
+	// Requires a backend that reports the disk usage of its networks.
+	MaxDiskBytes int64
+}
+
+// tenant is the namespace that a request operates in. The networks and snapshots of a tenant are stored in the
+// orchestrator under names prefixed with the tenant, so that different tenants can use the same names.
+// The empty tenant is unscoped: it uses and sees the names of the orchestrator as they are, including the networks of
+// every other tenant, so its limits apply to the resources used by every network of the orchestrator.
+type tenant string
+
+// getTenant returns the tenant of the request [ctx]. If [required] is true, requests without a tenant are rejected.
+func getTenant(ctx context.Context, required bool) (tenant, error) {
+	id, err := auth.TenantFromContext(ctx)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if id == "" && required {
+		return "", status.Errorf(codes.InvalidArgument, "requests must specify a tenant in the %q metadata", auth.TenantMetadataKey)
+	}
+	return tenant(id), nil
+}
+
+// scope returns the name that [name] is stored under in the orchestrator
+func (t tenant) scope(name string) string {
+	if t == "" || name == "" {
+		return name
+	}
+	return string(t) + tenantSeparator + name
+}
+
+// owns returns true if [scopedName] belongs to the tenant
+func (t tenant) owns(scopedName string) bool {
+	return t == "" || strings.HasPrefix(scopedName, string(t)+tenantSeparator)
+}
+
+// unscope returns the name of [scopedName] within the tenant
+func (t tenant) unscope(scopedName string) string {
+	if t == "" {
+		return scopedName
+	}
+	return strings.TrimPrefix(scopedName, string(t)+tenantSeparator)
+}
+
+// tenantUsage is the amount of resources used by a tenant
+type tenantUsage struct {
+	networks  int
+	nodes     int
+	diskBytes int64
+}
+
+// quotas enforces TenantLimits. The networks and nodes that are being created are reserved while they are created, so
+// that concurrent requests cannot exceed the limits.
+type quotas struct {
+	limits TenantLimits
+
+	lock    sync.Mutex
+	pending map[tenant]tenantUsage
+	// released is incremented each time a reservation is released, so that a usage computed concurrently, which may not
+	// include the resources created for the reservation, is recomputed
+	released uint64
+}
+
+func newQuotas(limits TenantLimits) *quotas {
+	return &quotas{
+		limits:  limits,
+		pending: make(map[tenant]tenantUsage),
+	}
+}
+
+// reserve reserves [networks] networks and [nodes] nodes for [t] and returns a function that releases the
+// reservation, which must be called once the resources have been created or have failed to be created. Returns a
+// ResourceExhausted error if the reservation would exceed the limits of the tenant.
+func (q *quotas) reserve(ctx context.Context, orchestrator backend.NetworkOrchestrator, t tenant, networks int, nodes int) (func(), error) {
+	if q.limits == (TenantLimits{}) {
+		return func() {}, nil
+	}
+
+	usage, err := q.lockUsage(ctx, orchestrator, t)
+	if err != nil {
+		return nil, err
+	}
+	defer q.lock.Unlock()
+
+	if q.limits.MaxNetworks > 0 && usage.networks+networks > q.limits.MaxNetworks {
+		return nil, status.Errorf(codes.ResourceExhausted, "tenant %s has reached its limit of %d networks", t, q.limits.MaxNetworks)
+	}
+	if q.limits.MaxNodes > 0 && usage.nodes+nodes > q.limits.MaxNodes {
+		return nil, status.Errorf(codes.ResourceExhausted, "tenant %s has reached its limit of %d nodes", t, q.limits.MaxNodes)
+	}
+	if q.limits.MaxDiskBytes > 0 && usage.diskBytes >= q.limits.MaxDiskBytes {
+		return nil, status.Errorf(codes.ResourceExhausted, "tenant %s uses %d bytes of disk, which exceeds its limit of %d bytes", t, usage.diskBytes, q.limits.MaxDiskBytes)
+	}
+
+	pending := q.pending[t]
+	q.pending[t] = tenantUsage{networks: pending.networks + networks, nodes: pending.nodes + nodes}
+	return func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+
+		pending := q.pending[t]
+		pending.networks -= networks
+		pending.nodes -= nodes
+		if pending == (tenantUsage{}) {
+			delete(q.pending, t)
+		} else {
+			q.pending[t] = pending
+		}
+		q.released++
+	}, nil
+}
+
+// checkNodes returns a ResourceExhausted error if [t] uses more nodes than its limit. This is used after restoring a
+// snapshot, since the number of nodes it contains is not known in advance.
+func (q *quotas) checkNodes(ctx context.Context, orchestrator backend.NetworkOrchestrator, t tenant) error {
+	if q.limits.MaxNodes == 0 {
+		return nil
+	}
+
+	usage, err := q.lockUsage(ctx, orchestrator, t)
+	if err != nil {
+		return err
+	}
+	defer q.lock.Unlock()
+
+	if usage.nodes > q.limits.MaxNodes {
+		return status.Errorf(codes.ResourceExhausted, "tenant %s has reached its limit of %d nodes", t, q.limits.MaxNodes)
+	}
+	return nil
+}
+
+// lockUsage returns the resources used by [t] including its pending reservations, with the lock held if no error is
+// returned. The usage is computed without holding the lock, since it queries every network of [orchestrator], and is
+// recomputed if a reservation is released meanwhile.
+func (q *quotas) lockUsage(ctx context.Context, orchestrator backend.NetworkOrchestrator, t tenant) (tenantUsage, error) {
+	for {
+		q.lock.Lock()
+		released := q.released
+		q.lock.Unlock()
+
+		usage, err := q.usage(ctx, orchestrator, t)
+		if err != nil {
+			return tenantUsage{}, err
+		}
+
+		q.lock.Lock()
+		if q.released == released {
+			// The empty tenant uses the resources of every tenant.
+			for owner, pending := range q.pending {
+				if t == "" || owner == t {
+					usage.networks += pending.networks
+					usage.nodes += pending.nodes
+				}
+			}
+			return usage, nil
+		}
+		q.lock.Unlock()
+		if err := ctx.Err(); err != nil {
+			return tenantUsage{}, status.FromContextError(err).Err()
+		}
+	}
+}
+
+// usage returns the resources used by the networks of [t]. The disk usage is only computed if it is limited.
+func (q *quotas) usage(ctx context.Context, orchestrator backend.NetworkOrchestrator, t tenant) (tenantUsage, error) {
+	networks, err := orchestrator.GetNetworks()
+	if err != nil {
+		return tenantUsage{}, err
+	}
+	usage := tenantUsage{}
+	for _, network := range networks {
+		if !t.owns(network.GetName()) {
+			continue
+		}
+		usage.networks++
+		nodes, err := network.GetNodes()
+		if err != nil {
+			return tenantUsage{}, err
+		}
+		usage.nodes += len(nodes)
+		if q.limits.MaxDiskBytes == 0 {
+			continue
+		}
+		reporter, ok := network.(backend.DiskUsageReporter)
+		if !ok {
+			return tenantUsage{}, status.Errorf(codes.FailedPrecondition, "cannot enforce disk limit: network %s does not report its disk usage", network.GetName())
+		}
+		diskBytes, err := reporter.DiskUsage(ctx)
+		if err != nil {
+			return tenantUsage{}, err
+		}
+		usage.diskBytes += diskBytes
+	}
+	return usage, nil
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/client"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTenantClient returns the gRPC client of [endpoint] that operates in the namespace of [tenant]
+func newTenantClient(t *testing.T, endpoint string, tenant string) rpcpb.OrchestratorServiceClient {
+	cli, err := client.New(client.Config{
+		LogLevel:    zapcore.InfoLevel.String(),
+		Endpoint:    endpoint,
+		DialTimeout: 10 * time.Second,
+		Tenant:      tenant,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, cli.Close(), "closing grpc client")
+	})
+	return cli.OrchestratorClient()
+}
+
+// listNetworks returns the sorted names of the networks visible to [orchestratorc]
+func listNetworks(ctx context.Context, t *testing.T, orchestratorc rpcpb.OrchestratorServiceClient) []string {
+	res, err := orchestratorc.ListNetworks(ctx, &rpcpb.ListNetworksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(res.Networks))
+	for _, network := range res.Networks {
+		names = append(names, network.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func addNode(ctx context.Context, orchestratorc rpcpb.OrchestratorServiceClient, network string, name string) error {
+	config, err := json.Marshal(backend.NodeConfig{
+		Name:       name,
+		Executable: "fake",
+		Config:     map[string]interface{}{},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = orchestratorc.AddNode(ctx, &rpcpb.AddNodeRequest{Network: network, Config: config})
+	return err
+}
+
+func TestTenantsGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	orchestrator := backend.NewOrchestrator(fake)
+	s, err := server.New(server.Config{
+		Port:          ":8090",
+		GwPort:        ":8091",
+		DialTimeout:   10 * time.Second,
+		RequireTenant: true,
+		TenantLimits: server.TenantLimits{
+			MaxNetworks:  2,
+			MaxNodes:     3,
+			MaxDiskBytes: 1024,
+		},
+	}, orchestrator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	anonymous := newTenantClient(t, "localhost:8090", "")
+	alice := newTenantClient(t, "localhost:8090", "alice")
+	bob := newTenantClient(t, "localhost:8090", "bob")
+
+	_, err = anonymous.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "network"})
+	assert.Equal(codes.InvalidArgument, status.Code(err), "expected request without tenant to be rejected")
+
+	// Tenants can use the same names without seeing each other's networks.
+	for _, tenant := range []rpcpb.OrchestratorServiceClient{alice, bob} {
+		_, err := tenant.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "network"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal([]string{"network"}, listNetworks(ctx, t, alice))
+	assert.Equal([]string{"network"}, listNetworks(ctx, t, bob))
+	_, err = bob.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "bob-only"})
+	assert.NoError(err)
+	_, err = alice.GetNetwork(ctx, &rpcpb.GetNetworkRequest{Network: "bob-only"})
+	assert.Error(err, "expected network of another tenant to be hidden")
+	_, err = alice.Teardown(ctx, &rpcpb.TeardownRequest{Network: "bob-only"})
+	assert.Error(err, "expected teardown of another tenant's network to fail")
+	assert.Equal([]string{"bob-only", "network"}, listNetworks(ctx, t, bob))
+
+	// Limits apply to each tenant separately.
+	_, err = bob.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "third"})
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "expected network limit to be enforced")
+	for _, name := range []string{"node0", "node1", "node2"} {
+		assert.NoError(addNode(ctx, alice, "network", name))
+	}
+	assert.Equal(codes.ResourceExhausted, status.Code(addNode(ctx, alice, "network", "node3")), "expected node limit to be enforced")
+	assert.NoError(addNode(ctx, bob, "network", "node0"))
+
+	_, err = alice.NodeStop(ctx, &rpcpb.NodeStopRequest{Network: "network", Name: "node2", Timeout: int64(time.Second)})
+	assert.NoError(err)
+	aliceNetwork, ok := fake.Network("alice.network")
+	if !assert.True(ok, "expected network to be stored under its tenant") {
+		return
+	}
+	aliceNetwork.SetDiskUsage(1024)
+	assert.Equal(codes.ResourceExhausted, status.Code(addNode(ctx, alice, "network", "node2")), "expected disk limit to be enforced")
+	aliceNetwork.SetDiskUsage(0)
+	assert.NoError(addNode(ctx, alice, "network", "node2"))
+
+	// Snapshots are scoped to their tenant as well.
+	_, err = alice.SnapshotNetwork(ctx, &rpcpb.SnapshotNetworkRequest{Network: "network", Snapshot: "snapshot"})
+	assert.NoError(err)
+	snapshots, err := bob.ListSnapshots(ctx, &rpcpb.ListSnapshotsRequest{})
+	assert.NoError(err)
+	assert.Empty(snapshots.Snapshots)
+	snapshots, err = alice.ListSnapshots(ctx, &rpcpb.ListSnapshotsRequest{})
+	assert.NoError(err)
+	assert.Equal([]string{"snapshot"}, snapshots.Snapshots)
+	_, err = alice.RestoreSnapshot(ctx, &rpcpb.RestoreSnapshotRequest{Snapshot: "snapshot", Network: "copy"})
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "expected restored nodes to count towards the node limit")
+	assert.Equal([]string{"network"}, listNetworks(ctx, t, alice))
+
+	_, err = alice.Teardown(ctx, &rpcpb.TeardownRequest{Network: "network"})
+	assert.NoError(err)
+	res, err := alice.RestoreSnapshot(ctx, &rpcpb.RestoreSnapshotRequest{Snapshot: "snapshot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("network", res.Network.Name)
+	assert.Len(res.Network.Nodes, 3)
+
+	for tenant, networks := range map[rpcpb.OrchestratorServiceClient][]string{alice: {"network"}, bob: {"bob-only", "network"}} {
+		for _, network := range networks {
+			_, err := tenant.Teardown(ctx, &rpcpb.TeardownRequest{Network: network})
+			assert.NoError(err)
+		}
+	}
+	assert.NoError(orchestrator.Teardown(ctx))
+}
+
+// TestUnscopedTenantLimitsGRPC tests that the limits apply to requests without a tenant, which operate on every network.
+func TestUnscopedTenantLimitsGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	orchestrator := backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{}))
+	s, err := server.New(server.Config{
+		Port:        ":8100",
+		GwPort:      ":8101",
+		DialTimeout: 10 * time.Second,
+		TenantLimits: server.TenantLimits{
+			MaxNetworks: 2,
+		},
+	}, orchestrator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	anonymous := newTenantClient(t, "localhost:8100", "")
+	alice := newTenantClient(t, "localhost:8100", "alice")
+
+	_, err = alice.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "network"})
+	assert.NoError(err)
+	_, err = anonymous.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "network"})
+	assert.NoError(err)
+	_, err = anonymous.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "third"})
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "expected the networks of every tenant to count towards the unscoped limit")
+	_, err = alice.CreateNetwork(ctx, &rpcpb.CreateNetworkRequest{Network: "second"})
+	assert.NoError(err, "expected the unscoped networks not to count towards the limit of a tenant")
+
+	for _, network := range []string{"alice.network", "alice.second", "network"} {
+		_, err := anonymous.Teardown(ctx, &rpcpb.TeardownRequest{Network: network})
+		assert.NoError(err)
+	}
+	assert.NoError(orchestrator.Teardown(ctx))
+}
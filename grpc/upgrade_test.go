@@ -0,0 +1,91 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/aaronbuchwald/avalanche-network-runner/grpc/server"
+	"github.com/aaronbuchwald/avalanche-network-runner/rpcpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingUpgradeGRPC(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s, err := server.New(server.Config{
+		Port:        ":8096",
+		GwPort:      ":8097",
+		DialTimeout: 10 * time.Second,
+	}, backend.NewOrchestrator(fakebackend.New(fakebackend.Hooks{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		assert.NoError(s.Run(ctx), "server run error")
+	}()
+	defer func() {
+		cancel()
+		<-serverDone
+	}()
+
+	client := newClient(t, "localhost:8096")
+	network, err := client.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, executable := range map[string]string{"node0": "v1", "node1": "v2", "node2": "v1"} {
+		if _, err := network.AddNode(ctx, backend.NodeConfig{Name: name, Executable: executable}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := client.OrchestratorClient().RollingUpgrade(ctx, &rpcpb.RollingUpgradeRequest{
+		Network:        "network",
+		FromExecutable: "v1",
+		ToExecutable:   "v2",
+		BatchSize:      1,
+		HealthGate:     string(backend.ReadinessStarted),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal([]string{"node0", "node2"}, res.Upgraded)
+	if assert.Len(res.Nodes, 3) {
+		for _, nodeInfo := range res.Nodes {
+			assert.Equal("v2", nodeInfo.Executable, "unexpected executable of node %s", nodeInfo.Name)
+		}
+	}
+
+	// The executable reported by the server is exposed by the nodes of the client.
+	attached, err := newClient(t, "localhost:8096").AttachNetwork(ctx, "network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := attached.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter, ok := node.(backend.ExecutableReporter)
+	if assert.True(ok, "expected client node to report its executable") {
+		assert.Equal("v2", reporter.Executable())
+	}
+
+	_, err = client.OrchestratorClient().RollingUpgrade(ctx, &rpcpb.RollingUpgradeRequest{
+		Network:        "network",
+		FromExecutable: "v1",
+		ToExecutable:   "v2",
+	})
+	assert.Error(err, "expected an upgrade without a batch size to fail")
+
+	assert.NoError(network.Teardown(ctx))
+}
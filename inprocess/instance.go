@@ -0,0 +1,31 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package inprocess
+
+import (
+	"context"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+)
+
+// Instance is a node-like instance that runs inside the process of the orchestrator
+type Instance interface {
+	// Run runs the instance until [ctx] is cancelled and calls [started] once the instance is serving requests.
+	// Run may be called again after it returns to restart the instance, which must keep the same addresses.
+	// Run returns a nil error if the instance stopped because [ctx] was cancelled.
+	Run(ctx context.Context, started func()) error
+	GetHTTPBaseURI() string
+	GetBootstrapIP() string
+}
+
+// Pauser is an optional interface that an Instance can implement to support pausing and resuming the node
+type Pauser interface {
+	// Pause suspends the instance without stopping it, so that it appears frozen to its peers
+	Pause() error
+	// Resume continues a paused instance
+	Resume() error
+}
+
+// NodeFactory creates the Instance for the node described by [config]
+type NodeFactory func(config backend.NodeConfig) (Instance, error)
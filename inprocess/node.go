@@ -0,0 +1,183 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package inprocess
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"go.uber.org/zap"
+)
+
+var (
+	_ backend.Node           = &node{}
+	_ backend.StatusNotifier = &node{}
+)
+
+type node struct {
+	// lock protects the run related fields below, which are replaced each time the instance is started.
+	lock sync.RWMutex
+
+	config   backend.NodeConfig
+	instance Instance
+
+	cancel context.CancelFunc
+
+	backend.NodeStatusTracker
+}
+
+func newNode(ctx context.Context, nodeDef backend.NodeConfig, instance Instance) (*node, error) {
+	node := &node{
+		config:   nodeDef,
+		instance: instance,
+	}
+	node.NodeStatusTracker = backend.NewNodeStatusTracker(&node.lock, nil)
+	if err := node.start(ctx); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// start runs the instance of the node and waits for it to report that it has started.
+func (n *node) start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var startOnce sync.Once
+
+	n.lock.Lock()
+	n.cancel = cancel
+	nodeStopped := n.StartRun(backend.NodeStarting)
+	n.lock.Unlock()
+
+	go n.run(runCtx, func() { startOnce.Do(func() { close(started) }) }, nodeStopped)
+
+	select {
+	case <-started:
+	case <-nodeStopped:
+		n.lock.RLock()
+		defer n.lock.RUnlock()
+		if err := n.StopErr(); err != nil {
+			return err
+		}
+		return fmt.Errorf("node %s exited on startup", n.config.Name)
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.MarkRunning(nodeStopped)
+	return nil
+}
+
+// run runs the instance until it exits and marks the node as stopped or crashed depending on whether
+// the node was asked to stop.
+func (n *node) run(ctx context.Context, started func(), nodeStopped chan struct{}) {
+	err := n.instance.Run(ctx, started)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.Exited(n.config.Name, err, nodeStopped)
+}
+
+func (n *node) GetName() string { return n.config.Name }
+
+func (n *node) GetHTTPBaseURI() string { return n.instance.GetHTTPBaseURI() }
+
+func (n *node) GetBootstrapIP() string { return n.instance.GetBootstrapIP() }
+
+func (n *node) Executable() string { return n.config.Executable }
+
+func (n *node) Config() map[string]interface{} {
+	return backend.CopyConfig(n.config.Config)
+}
+
+func (n *node) Status() backend.NodeStatus {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.CurrentStatus()
+}
+
+func (n *node) Stop(stopTimeout time.Duration) error {
+	n.lock.Lock()
+	paused := n.CurrentStatus() == backend.NodePaused
+	cancel := n.cancel
+	nodeStopped, running := n.RequestStop()
+	n.lock.Unlock()
+	if !running {
+		return nil
+	}
+
+	// A paused instance may not handle the cancellation until it is resumed.
+	if paused {
+		if err := n.instance.(Pauser).Resume(); err != nil {
+			return err
+		}
+	}
+	cancel()
+
+	select {
+	case <-nodeStopped:
+		n.lock.RLock()
+		defer n.lock.RUnlock()
+		return n.StopErr()
+	case <-time.After(stopTimeout):
+		return fmt.Errorf("node %s did not stop within %s", n.config.Name, stopTimeout)
+	}
+}
+
+func (n *node) Restart(ctx context.Context, stopTimeout time.Duration) error {
+	if err := n.Stop(stopTimeout); err != nil {
+		zap.L().Debug("node exited with an error while stopping for restart", zap.String("name", n.config.Name), zap.Error(err))
+	}
+
+	// Wait for the instance to exit before running it again, so that it releases its addresses.
+	n.lock.RLock()
+	nodeStopped := n.Stopped()
+	n.lock.RUnlock()
+	select {
+	case <-nodeStopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return n.start(ctx)
+}
+
+func (n *node) Pause() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	pauser, ok := n.instance.(Pauser)
+	if !ok {
+		return fmt.Errorf("node %s does not support pausing", n.config.Name)
+	}
+	if status := n.CurrentStatus(); status != backend.NodeStarting && status != backend.NodeRunning {
+		return fmt.Errorf("cannot pause node %s with status %s", n.config.Name, status)
+	}
+	if err := pauser.Pause(); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodePaused, nil)
+	return nil
+}
+
+func (n *node) Resume() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if status := n.CurrentStatus(); status != backend.NodePaused {
+		return fmt.Errorf("cannot resume node %s with status %s", n.config.Name, status)
+	}
+	if err := n.instance.(Pauser).Resume(); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodeRunning, nil)
+	return nil
+}
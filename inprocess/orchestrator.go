@@ -0,0 +1,70 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package inprocess
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"go.uber.org/zap"
+)
+
+var (
+	_ backend.OrchestratorBackend = &orchestrator{}
+	_ backend.NetworkConstructor  = &networkConstructor{}
+)
+
+type orchestrator struct {
+	factories map[string]NodeFactory
+}
+
+type OrchestratorConfig struct {
+	// Factories maps executable names to the NodeFactory used to create the nodes that run them
+	Factories map[string]NodeFactory
+}
+
+// NewNetworkOrchestrator creates a new orchestrator that generates networks of nodes running inside of the current
+// process, which are created by the NodeFactory registered for their executable.
+func NewNetworkOrchestrator(config *OrchestratorConfig) backend.NetworkOrchestrator {
+	factories := make(map[string]NodeFactory, len(config.Factories))
+	for name, factory := range config.Factories {
+		factories[name] = factory
+	}
+	return backend.NewOrchestrator(&orchestrator{
+		factories: factories,
+	})
+}
+
+func (o *orchestrator) CreateNetworkConstructor(name string) (backend.NetworkConstructor, error) {
+	zap.L().Info("Creating network", zap.String("name", name))
+	return &networkConstructor{factories: o.factories}, nil
+}
+
+func (o *orchestrator) Teardown(ctx context.Context) error {
+	return nil
+}
+
+type networkConstructor struct {
+	factories map[string]NodeFactory
+}
+
+func (c *networkConstructor) AddNode(ctx context.Context, nodeDef backend.NodeConfig) (backend.Node, error) {
+	factory, exists := c.factories[nodeDef.Executable]
+	if !exists {
+		return nil, fmt.Errorf("no node factory found for node %s to execute %s", nodeDef.Name, nodeDef.Executable)
+	}
+
+	zap.L().Info("Starting node", zap.String("name", nodeDef.Name), zap.String("executable", nodeDef.Executable))
+	instance, err := factory(nodeDef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node %s: %w", nodeDef.Name, err)
+	}
+	return newNode(ctx, nodeDef, instance)
+}
+
+// Teardown is a no-op since the nodes are stopped by the network before the constructor is torn down.
+func (c *networkConstructor) Teardown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/e2e"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInProcessNetworkOrchestrator(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Factories: map[string]NodeFactory{
+			constants.NormalExecution: NewStubNode,
+		},
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNetworkOrchestrator(ctx, t, orchestrator)
+}
+
+func TestInProcessNodeLifecycle(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Factories: map[string]NodeFactory{
+			constants.NormalExecution: NewStubNode,
+		},
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeLifecycle(ctx, t, orchestrator)
+}
+
+func TestInProcessNodeRemoval(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Factories: map[string]NodeFactory{
+			constants.NormalExecution: NewStubNode,
+		},
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeRemoval(ctx, t, orchestrator)
+}
+
+func TestInProcessNodeReadiness(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Factories: map[string]NodeFactory{
+			constants.NormalExecution: NewStubNode,
+		},
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeReadiness(ctx, t, orchestrator)
+}
+
+// crashingInstance is a stub node that crashes shortly after it first starts
+type crashingInstance struct {
+	Instance
+	runs int32
+}
+
+func (c *crashingInstance) Run(ctx context.Context, started func()) error {
+	if atomic.AddInt32(&c.runs, 1) > 1 {
+		return c.Instance.Run(ctx, started)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := c.Instance.Run(runCtx, started); err != nil || ctx.Err() != nil {
+		return err
+	}
+	return errors.New("injected crash")
+}
+
+func TestInProcessRestartPolicy(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Minute))
+	defer cancel()
+
+	instances := make(chan *crashingInstance, 1)
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		Factories: map[string]NodeFactory{
+			constants.NormalExecution: func(config backend.NodeConfig) (Instance, error) {
+				instance, err := NewStubNode(config)
+				if err != nil {
+					return nil, err
+				}
+				crashing := &crashingInstance{Instance: instance}
+				instances <- crashing
+				return crashing, nil
+			},
+		},
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	network, err := orchestrator.CreateNetwork("restart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := network.AddNode(ctx, backend.NodeConfig{
+		Name:          "node0",
+		Executable:    constants.NormalExecution,
+		RestartPolicy: &backend.RestartPolicy{Mode: backend.RestartOnFailure, MaxRetries: 1, Backoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance := <-instances
+
+	// The node crashes once and is restarted on the same addresses.
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt32(&instance.runs) < 2 || node.Status() != backend.NodeRunning {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the node to restart, status: %s", node.Status())
+		}
+	}
+	assert.NoError(e2e.AwaitHealthy(ctx, network, 100*time.Millisecond))
+	assert.NoError(network.Teardown(ctx))
+}
@@ -0,0 +1,263 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package inprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	bootstrapCheck       = "bootstrapped"
+	bootstrapDialTimeout = time.Second
+)
+
+var (
+	_ Instance        = &stubNode{}
+	_ Pauser          = &stubNode{}
+	_ health.Reporter = &stubNode{}
+
+	errAlreadyPaused = errors.New("stub node is already paused")
+	errNotPaused     = errors.New("stub node is not paused")
+)
+
+// stubNode is a lightweight stand-in for AvalancheGo, which serves the health API and accepts connections on its
+// staking address. It reports healthy once every node in its [bootstrap-ips] accepts connections.
+// It additionally serves info.getNodeID and info.isBootstrapped, where every chain is bootstrapped once the node is
+// healthy, so that nodes can be probed for readiness.
+type stubNode struct {
+	lock sync.Mutex
+
+	nodeID       string
+	httpAddr     string
+	stakingAddr  string
+	bootstrapIPs []string
+
+	// httpListener and stakingListener hold the listeners bound when the node was created until the node first runs,
+	// so that its addresses are reserved from the start.
+	httpListener    net.Listener
+	stakingListener net.Listener
+
+	// resumed is non-nil while the node is paused and is closed when it is resumed
+	resumed chan struct{}
+}
+
+// NewStubNode is a NodeFactory that creates stub nodes, which serve the AvalancheGo health API without running any
+// chains, so that orchestration logic can be tested quickly and without an AvalancheGo binary.
+// Stub nodes ignore the ports in their config and listen on free ports on 127.0.0.1 instead, so that any number of
+// networks can run concurrently.
+func NewStubNode(nodeDef backend.NodeConfig) (Instance, error) {
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	stakingListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = httpListener.Close()
+		return nil, err
+	}
+
+	var bootstrapIPs []string
+	if val, ok := nodeDef.Config[config.BootstrapIPsKey]; ok {
+		for _, ip := range strings.Split(fmt.Sprintf("%v", val), ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				bootstrapIPs = append(bootstrapIPs, ip)
+			}
+		}
+	}
+
+	return &stubNode{
+		nodeID:          nodeDef.NodeID,
+		httpAddr:        httpListener.Addr().String(),
+		stakingAddr:     stakingListener.Addr().String(),
+		bootstrapIPs:    bootstrapIPs,
+		httpListener:    httpListener,
+		stakingListener: stakingListener,
+	}, nil
+}
+
+func (s *stubNode) Run(ctx context.Context, started func()) error {
+	httpListener, stakingListener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	defer stakingListener.Close()
+
+	healthHandler, err := health.NewGetAndPostHandler(logging.NoLog{}, s)
+	if err != nil {
+		_ = httpListener.Close()
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/ext/health", s.pausable(healthHandler))
+	mux.Handle("/ext/info", s.pausable(http.HandlerFunc(s.serveInfo)))
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		for {
+			conn, err := stakingListener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(httpListener)
+	}()
+	started()
+
+	select {
+	case <-ctx.Done():
+		_ = server.Close()
+		<-serveErr
+		return nil
+	case err := <-serveErr:
+		return err
+	}
+}
+
+// listen returns the listeners reserved when the node was created or binds the addresses of the node again
+// if it is being restarted.
+func (s *stubNode) listen() (net.Listener, net.Listener, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.httpListener != nil {
+		httpListener, stakingListener := s.httpListener, s.stakingListener
+		s.httpListener, s.stakingListener = nil, nil
+		return httpListener, stakingListener, nil
+	}
+
+	httpListener, err := net.Listen("tcp", s.httpAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	stakingListener, err := net.Listen("tcp", s.stakingAddr)
+	if err != nil {
+		_ = httpListener.Close()
+		return nil, nil, err
+	}
+	return httpListener, stakingListener, nil
+}
+
+// pausable blocks requests to [handler] while the node is paused.
+func (s *stubNode) pausable(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		resumed := s.resumed
+		s.lock.Unlock()
+
+		if resumed != nil {
+			select {
+			case <-resumed:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (s *stubNode) GetHTTPBaseURI() string { return fmt.Sprintf("http://%s", s.httpAddr) }
+
+func (s *stubNode) GetBootstrapIP() string { return s.stakingAddr }
+
+func (s *stubNode) Pause() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.resumed != nil {
+		return errAlreadyPaused
+	}
+	s.resumed = make(chan struct{})
+	return nil
+}
+
+func (s *stubNode) Resume() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.resumed == nil {
+		return errNotPaused
+	}
+	close(s.resumed)
+	s.resumed = nil
+	return nil
+}
+
+func (s *stubNode) Readiness() (map[string]health.Result, bool) { return s.Health() }
+
+func (s *stubNode) Liveness() (map[string]health.Result, bool) {
+	return map[string]health.Result{}, true
+}
+
+// Health reports whether every bootstrap node of the stub node accepts connections.
+func (s *stubNode) Health() (map[string]health.Result, bool) {
+	start := time.Now()
+	result := health.Result{Timestamp: start}
+	for _, ip := range s.bootstrapIPs {
+		conn, err := net.DialTimeout("tcp", ip, bootstrapDialTimeout)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to connect to bootstrap node %s: %s", ip, err)
+			result.Error = &errMsg
+			break
+		}
+		_ = conn.Close()
+	}
+	result.Duration = time.Since(start)
+	return map[string]health.Result{bootstrapCheck: result}, result.Error == nil
+}
+
+type infoRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+type infoResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *infoError      `json:"error,omitempty"`
+}
+
+type infoError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// serveInfo serves the JSON-RPC methods of the info API that are used to probe the readiness of a node.
+func (s *stubNode) serveInfo(w http.ResponseWriter, r *http.Request) {
+	req := infoRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := infoResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "info.getNodeID":
+		res.Result = map[string]string{"nodeID": s.nodeID}
+	case "info.isBootstrapped":
+		_, healthy := s.Health()
+		res.Result = map[string]bool{"isBootstrapped": healthy}
+	default:
+		res.Error = &infoError{Code: -32601, Message: fmt.Sprintf("method %q is not supported by stub nodes", req.Method)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"go.uber.org/zap"
+)
+
+const (
+	// avalancheGoBinaryName is the name of the binaries that DiscoverExecutors registers
+	avalancheGoBinaryName = "avalanchego"
+	// maxDiscoveryDepth is the number of directories below the scanned directory that DiscoverExecutors searches for
+	// builds ie. <dir>/v1.7.10/build/avalanchego
+	maxDiscoveryDepth = 3
+	versionTimeout    = 10 * time.Second
+)
+
+var _ backend.ExecutorManager = &orchestrator{}
+
+// versionRegex matches the version printed by AvalancheGo with --version ie. "avalanche/1.7.10 [database=v1.4.5]"
+var versionRegex = regexp.MustCompile(`^\S+/v?(\d+\.\d+\.\d+\S*)`)
+
+// DetectVersion returns the version tag of the AvalancheGo binary at [path] ie. v1.7.10, as reported by the binary
+// when it is run with --version.
+func DetectVersion(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %w", path, err)
+	}
+	match := versionRegex.FindStringSubmatch(strings.TrimSpace(string(output)))
+	if match == nil {
+		return "", fmt.Errorf("unexpected output of %s --version: %q", path, strings.TrimSpace(string(output)))
+	}
+	return "v" + match[1], nil
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the file at [path]
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyChecksum returns an error unless the checksum of the file at [path] is [checksum]. Any file passes if
+// [checksum] is empty.
+func verifyChecksum(path string, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	actual, err := Checksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to compute checksum of %s: %w", path, err)
+	}
+	if !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("checksum of %s is %s, expected %s", path, actual, checksum)
+	}
+	return nil
+}
+
+// inspectExecutable returns the executor for the binary at [path] along with its checksum and version. The version is
+// left empty if the binary does not report one, since VMs are registered as executors as well. If [checksum] is set,
+// the binary is rejected before it is run unless its checksum matches.
+func inspectExecutable(ctx context.Context, path string, checksum string) (backend.Executor, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return backend.Executor{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return backend.Executor{}, err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return backend.Executor{}, fmt.Errorf("%s is not an executable file", path)
+	}
+	actual, err := Checksum(path)
+	if err != nil {
+		return backend.Executor{}, fmt.Errorf("failed to compute checksum of %s: %w", path, err)
+	}
+	if checksum != "" && !strings.EqualFold(checksum, actual) {
+		return backend.Executor{}, fmt.Errorf("checksum of %s is %s, expected %s", path, actual, checksum)
+	}
+	version, err := DetectVersion(ctx, path)
+	if err != nil {
+		zap.L().Debug("failed to detect version of executable", zap.String("path", path), zap.Error(err))
+	}
+	return backend.Executor{
+		Path:    path,
+		Version: version,
+		SHA256:  actual,
+	}, nil
+}
+
+func (o *orchestrator) RegisterExecutor(ctx context.Context, name string, path string, checksum string) (backend.Executor, error) {
+	executor, err := inspectExecutable(ctx, path, checksum)
+	if err != nil {
+		return backend.Executor{}, err
+	}
+	executor.Name = name
+	if executor.Name == "" {
+		executor.Name = executor.Version
+	}
+	if executor.Name == "" {
+		return backend.Executor{}, fmt.Errorf("cannot detect the version of %s to register it under, specify a name", executor.Path)
+	}
+	if err := o.registry.AddExecutor(executor); err != nil {
+		return backend.Executor{}, err
+	}
+	zap.L().Info("Registered executor",
+		zap.String("name", executor.Name),
+		zap.String("path", executor.Path),
+		zap.String("version", executor.Version),
+		zap.String("sha256", executor.SHA256),
+	)
+	return executor, nil
+}
+
+// DiscoverExecutors registers each AvalancheGo binary found within [maxDiscoveryDepth] directories of [dir] under its
+// version tag. Builds of a version that is already registered are skipped.
+func (o *orchestrator) DiscoverExecutors(ctx context.Context, dir string) ([]backend.Executor, error) {
+	paths, err := findBuilds(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var executors []backend.Executor
+	for _, path := range paths {
+		executor, err := inspectExecutable(ctx, path, "")
+		if err != nil {
+			return executors, err
+		}
+		if executor.Version == "" {
+			zap.L().Warn("Skipping build without a version", zap.String("path", executor.Path))
+			continue
+		}
+		if _, exists := o.registry.GetExecutor(executor.Version); exists {
+			zap.L().Info("Skipping build of registered version", zap.String("path", executor.Path), zap.String("version", executor.Version))
+			continue
+		}
+		executor.Name = executor.Version
+		if err := o.registry.AddExecutor(executor); err != nil {
+			return executors, err
+		}
+		zap.L().Info("Registered executor",
+			zap.String("name", executor.Name),
+			zap.String("path", executor.Path),
+			zap.String("sha256", executor.SHA256),
+		)
+		executors = append(executors, executor)
+	}
+	return executors, nil
+}
+
+// findBuilds returns the paths of the AvalancheGo binaries within [maxDiscoveryDepth] directories of [dir] sorted by
+// path. Plugin directories are not searched.
+func findBuilds(dir string) ([]string, error) {
+	dir = filepath.Clean(dir)
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		depth := strings.Count(strings.TrimPrefix(path, dir), string(filepath.Separator))
+		if entry.IsDir() {
+			if path != dir && (depth > maxDiscoveryDepth || entry.Name() == pluginsDirName) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() == avalancheGoBinaryName && entry.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s for builds: %w", dir, err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (o *orchestrator) ListExecutors(ctx context.Context) ([]backend.Executor, error) {
+	return o.registry.ListExecutors(), nil
+}
+
+func (o *orchestrator) UnregisterExecutor(ctx context.Context, name string) error {
+	if err := o.registry.UnregisterExecutor(name); err != nil {
+		return err
+	}
+	zap.L().Info("Unregistered executor", zap.String("name", name))
+	return nil
+}
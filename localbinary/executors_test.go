@@ -0,0 +1,139 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeBuild writes a script to [path] that reports [version] like AvalancheGo when it is run with --version, and
+// returns the SHA-256 checksum of the script.
+func writeBuild(t *testing.T, path string, version string) string {
+	content := "#!/bin/sh\necho \"avalanche/" + version + " [database=v1.4.5]\"\n"
+	writeFile(t, path, content)
+	checksum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(checksum[:])
+}
+
+func TestDetectVersion(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "avalanchego")
+	checksum := writeBuild(t, path, "1.7.10")
+	version, err := DetectVersion(ctx, path)
+	assert.NoError(err)
+	assert.Equal("v1.7.10", version)
+	computed, err := Checksum(path)
+	assert.NoError(err)
+	assert.Equal(checksum, computed)
+
+	invalid := filepath.Join(dir, "invalid")
+	writeFile(t, invalid, "#!/bin/sh\necho unknown\n")
+	_, err = DetectVersion(ctx, invalid)
+	assert.Error(err, "expected output without a version to fail")
+	_, err = DetectVersion(ctx, filepath.Join(dir, "missing"))
+	assert.Error(err, "expected missing binary to fail")
+}
+
+func TestRegisterExecutor(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	manager := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:  filepath.Join(dir, "base"),
+		Registry: map[string]string{"avalanchego": "/bin/avalanchego"},
+	}).(backend.ExecutorManager)
+
+	path := filepath.Join(dir, "v1.7.10", "avalanchego")
+	checksum := writeBuild(t, path, "1.7.10")
+	_, err := manager.RegisterExecutor(ctx, "", path, "00"+checksum[2:])
+	assert.Error(err, "expected executable with a different checksum to be rejected")
+	executor, err := manager.RegisterExecutor(ctx, "", path, checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(backend.Executor{Name: "v1.7.10", Path: path, Version: "v1.7.10", SHA256: checksum}, executor)
+	_, err = manager.RegisterExecutor(ctx, "", path, "")
+	assert.Error(err, "expected version that is already registered to be rejected")
+	executor, err = manager.RegisterExecutor(ctx, "patched", path, "")
+	assert.NoError(err)
+	assert.Equal("patched", executor.Name)
+
+	// The checksum is compared before the executable is run to detect its version.
+	marker := filepath.Join(dir, "ran")
+	untrusted := filepath.Join(dir, "untrusted")
+	writeFile(t, untrusted, "#!/bin/sh\ntouch "+marker+"\n")
+	_, err = manager.RegisterExecutor(ctx, "untrusted", untrusted, checksum)
+	assert.Error(err, "expected executable with a different checksum to be rejected")
+	_, err = os.Stat(marker)
+	assert.True(os.IsNotExist(err), "expected executable with a different checksum not to be run")
+
+	vm := filepath.Join(dir, "vm")
+	writeFile(t, vm, "#!/bin/sh\nexit 1\n")
+	_, err = manager.RegisterExecutor(ctx, "", vm, "")
+	assert.Error(err, "expected executable without a version or name to be rejected")
+	executor, err = manager.RegisterExecutor(ctx, "vm", vm, "")
+	assert.NoError(err)
+	assert.Empty(executor.Version)
+
+	executors, err := manager.ListExecutors(ctx)
+	assert.NoError(err)
+	names := make([]string, 0, len(executors))
+	for _, executor := range executors {
+		names = append(names, executor.Name)
+	}
+	assert.Equal([]string{"avalanchego", "patched", "v1.7.10", "vm"}, names)
+
+	assert.NoError(manager.UnregisterExecutor(ctx, "patched"))
+	assert.Error(manager.UnregisterExecutor(ctx, "patched"))
+}
+
+func TestDiscoverExecutors(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	manager := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:  filepath.Join(dir, "base"),
+		Registry: map[string]string{},
+	}).(backend.ExecutorManager)
+	builds := filepath.Join(dir, "builds")
+	writeBuild(t, filepath.Join(builds, "v1.7.10", "avalanchego"), "1.7.10")
+	writeBuild(t, filepath.Join(builds, "avalanchego-v1.7.9", "build", "avalanchego"), "1.7.9")
+	writeBuild(t, filepath.Join(builds, "copy", "avalanchego"), "1.7.10")
+	writeBuild(t, filepath.Join(builds, "v1.7.8", "build", "plugins", "avalanchego"), "1.7.8")
+	writeBuild(t, filepath.Join(builds, "a", "b", "c", "d", "avalanchego"), "1.7.7")
+	writeFile(t, filepath.Join(builds, "unknown", "avalanchego"), "#!/bin/sh\nexit 1\n")
+
+	executors, err := manager.DiscoverExecutors(ctx, builds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := make(map[string]string, len(executors))
+	for _, executor := range executors {
+		assert.Equal(executor.Version, executor.Name)
+		versions[executor.Name] = executor.Path
+	}
+	assert.Equal(map[string]string{
+		"v1.7.9":  filepath.Join(builds, "avalanchego-v1.7.9", "build", "avalanchego"),
+		"v1.7.10": filepath.Join(builds, "copy", "avalanchego"),
+	}, versions, "expected the first build of each version outside of plugin directories to be registered")
+
+	executors, err = manager.DiscoverExecutors(ctx, builds)
+	assert.NoError(err)
+	assert.Empty(executors, "expected registered versions to be skipped")
+	_, err = manager.DiscoverExecutors(ctx, filepath.Join(dir, "missing"))
+	assert.Error(err)
+}
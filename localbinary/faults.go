@@ -0,0 +1,561 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/ava-labs/avalanchego/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// faultInjectionPublicIP is the IP advertised by the nodes of networks with fault injection enabled. It is an
+	// unroutable unique local address, which nodes do not connect to since private IPs are disallowed, so that nodes
+	// only connect to each other through the link proxies they bootstrap from.
+	faultInjectionPublicIP = "fd9a:3e2c:71b0::1"
+	// faultInjectionPingTimeout is the default ping timeout of the nodes of networks with fault injection enabled. A
+	// node re-dials a disconnected peer at its advertised IP, which is unreachable, so connections stalled by a
+	// partition must not time out.
+	faultInjectionPingTimeout = "24h"
+
+	// proxyBufferSize is the maximum size of each chunk of traffic forwarded by a link proxy
+	proxyBufferSize = 32 * 1024
+	// proxyQueueSize is the number of chunks that each direction of a proxied connection buffers while they are delayed
+	proxyQueueSize   = 256
+	proxyDialTimeout = 5 * time.Second
+)
+
+var (
+	_ backend.FaultInjector = &networkConstructor{}
+
+	errFaultInjectionDisabled = errors.New("fault injection is disabled, enable it in the orchestrator config to use it")
+)
+
+// link is the direction of traffic from the node [from] to the node [to]
+type link struct {
+	from string
+	to   string
+}
+
+// faultInjector forwards the connections between the nodes of a network through link proxies, which apply the
+// partitions and link faults injected into the network.
+type faultInjector struct {
+	lock sync.Mutex
+	// cond is broadcast whenever the partitions or link faults change or a proxied connection is closed
+	cond *sync.Cond
+	// partitions maps each node listed in a partition to the index of its partition, or is nil if the network is not
+	// partitioned. Nodes that are not listed are part of an additional partition.
+	partitions map[string]int
+	faults     map[link]backend.LinkFaults
+	proxies    map[link]*linkProxy
+}
+
+func newFaultInjector() *faultInjector {
+	f := &faultInjector{
+		faults:  make(map[link]backend.LinkFaults),
+		proxies: make(map[link]*linkProxy),
+	}
+	f.cond = sync.NewCond(&f.lock)
+	return f
+}
+
+// blocked returns true if the nodes of [l] are in different partitions. Assumes the lock is held.
+func (f *faultInjector) blocked(l link) bool {
+	if f.partitions == nil {
+		return false
+	}
+	partition := func(name string) int {
+		if i, ok := f.partitions[name]; ok {
+			return i
+		}
+		return -1
+	}
+	return partition(l.from) != partition(l.to)
+}
+
+// await waits until [l] is not blocked by a partition and returns its faults. Returns false if [done] is closed first.
+func (f *faultInjector) await(l link, done <-chan struct{}) (backend.LinkFaults, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for {
+		select {
+		case <-done:
+			return backend.LinkFaults{}, false
+		default:
+		}
+		if !f.blocked(l) {
+			return f.faults[l], true
+		}
+		f.cond.Wait()
+	}
+}
+
+// wake wakes up every proxied connection that is waiting on the state of its link
+func (f *faultInjector) wake() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.cond.Broadcast()
+}
+
+func (f *faultInjector) partition(partitions [][]string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.partitions = make(map[string]int)
+	for i, partition := range partitions {
+		for _, name := range partition {
+			f.partitions[name] = i
+		}
+	}
+	f.cond.Broadcast()
+}
+
+func (f *faultInjector) heal() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.partitions = nil
+	f.faults = make(map[link]backend.LinkFaults)
+	f.cond.Broadcast()
+}
+
+func (f *faultInjector) setFaults(l link, faults backend.LinkFaults) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if faults == (backend.LinkFaults{}) {
+		delete(f.faults, l)
+	} else {
+		f.faults[l] = faults
+	}
+	f.cond.Broadcast()
+}
+
+// open returns the port of the proxy of [l], which is started on a port allocated from [ports] if it is not running.
+// The proxy forwards connections to the address returned by [target].
+func (f *faultInjector) open(l link, ports *utils.PortAllocator, target func() (string, error)) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if proxy, ok := f.proxies[l]; ok {
+		return proxy.port, nil
+	}
+	allocated, err := ports.Allocate(1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate port for link from %s to %s: %w", l.from, l.to, err)
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", allocated[0]))
+	if err != nil {
+		ports.Release(allocated...)
+		return 0, fmt.Errorf("failed to start proxy for link from %s to %s: %w", l.from, l.to, err)
+	}
+	proxy := &linkProxy{
+		link:     l,
+		port:     allocated[0],
+		listener: listener,
+		target:   target,
+		faults:   f,
+		conns:    make(map[*proxiedConn]struct{}),
+		release:  func() { ports.Release(allocated...) },
+	}
+	f.proxies[l] = proxy
+	go proxy.serve()
+	return proxy.port, nil
+}
+
+// closeNode closes the proxies of every link from or to the node [name]
+func (f *faultInjector) closeNode(name string) {
+	f.lock.Lock()
+	proxies := make([]*linkProxy, 0)
+	for l, proxy := range f.proxies {
+		if l.from == name || l.to == name {
+			proxies = append(proxies, proxy)
+			delete(f.proxies, l)
+		}
+	}
+	f.lock.Unlock()
+
+	for _, proxy := range proxies {
+		proxy.close()
+	}
+}
+
+// close closes every link proxy
+func (f *faultInjector) close() {
+	f.lock.Lock()
+	proxies := f.proxies
+	f.proxies = make(map[link]*linkProxy)
+	f.lock.Unlock()
+
+	for _, proxy := range proxies {
+		proxy.close()
+	}
+}
+
+// linkProxy forwards the connections that the node [link.from] makes to the node [link.to], applying the faults of
+// the link in each direction.
+type linkProxy struct {
+	link     link
+	port     int
+	listener net.Listener
+	// target returns the address of the node [link.to]
+	target func() (string, error)
+	faults *faultInjector
+	// release releases the port of the proxy
+	release func()
+
+	lock   sync.Mutex
+	conns  map[*proxiedConn]struct{}
+	closed bool
+}
+
+func (p *linkProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.forward(conn)
+	}
+}
+
+// forward forwards [conn] to the target of the proxy until either side closes its connection
+func (p *linkProxy) forward(conn net.Conn) {
+	address, err := p.target()
+	if err != nil {
+		zap.L().Debug("failed to resolve link target", zap.String("from", p.link.from), zap.String("to", p.link.to), zap.Error(err))
+		_ = conn.Close()
+		return
+	}
+	dst, err := net.DialTimeout("tcp", address, proxyDialTimeout)
+	if err != nil {
+		zap.L().Debug("failed to dial link target", zap.String("from", p.link.from), zap.String("to", p.link.to), zap.Error(err))
+		_ = conn.Close()
+		return
+	}
+	proxied := &proxiedConn{src: conn, dst: dst, done: make(chan struct{}), faults: p.faults}
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		proxied.close()
+		return
+	}
+	p.conns[proxied] = struct{}{}
+	p.lock.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		proxied.pipe(p.link, conn, dst)
+	}()
+	go func() {
+		defer wg.Done()
+		proxied.pipe(link{from: p.link.to, to: p.link.from}, dst, conn)
+	}()
+	wg.Wait()
+	proxied.close()
+
+	p.lock.Lock()
+	delete(p.conns, proxied)
+	p.lock.Unlock()
+}
+
+// close stops accepting connections, closes the connections that are being forwarded, and releases the port of the
+// proxy
+func (p *linkProxy) close() {
+	p.lock.Lock()
+	p.closed = true
+	conns := p.conns
+	p.conns = nil
+	p.lock.Unlock()
+
+	_ = p.listener.Close()
+	for conn := range conns {
+		conn.close()
+	}
+	p.release()
+}
+
+// proxiedConn is a connection forwarded by a link proxy from [src] to [dst]
+type proxiedConn struct {
+	src    net.Conn
+	dst    net.Conn
+	faults *faultInjector
+
+	closeOnce sync.Once
+	// done is closed once the connection is closed
+	done chan struct{}
+}
+
+// chunk is a chunk of traffic that is delivered at [deliverAt]
+type chunk struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+// pipe copies the traffic of [l] from [src] to [dst]. Traffic is not read while [l] is blocked by a partition, so that
+// the sender is stalled rather than disconnected, and is written once its delay has passed and the link is not
+// blocked, at no more than the bandwidth of the link.
+func (c *proxiedConn) pipe(l link, src net.Conn, dst net.Conn) {
+	chunks := make(chan chunk, proxyQueueSize)
+	go func() {
+		defer close(chunks)
+
+		var lastDelivery time.Time
+		for {
+			faults, ok := c.faults.await(l, c.done)
+			if !ok {
+				return
+			}
+			size := proxyBufferSize
+			// Read small chunks from bandwidth limited links, so that traffic is paced smoothly.
+			if faults.Bandwidth > 0 && faults.Bandwidth/10 < int64(size) {
+				size = int(faults.Bandwidth/10) + 1
+			}
+			buf := make([]byte, size)
+			n, err := src.Read(buf)
+			if n > 0 {
+				// The faults may have changed while waiting for traffic.
+				if faults, ok = c.faults.await(l, c.done); !ok {
+					return
+				}
+				// Links carry TCP connections, which cannot lose data without corrupting the stream, so a dropped
+				// chunk resets the connection instead, as if it had been lost for good.
+				if faults.DropRate > 0 && rand.Float64() < faults.DropRate {
+					c.reset()
+					return
+				}
+				deliverAt := time.Now().Add(chunkDelay(faults))
+				// Traffic is never reordered.
+				if deliverAt.Before(lastDelivery) {
+					deliverAt = lastDelivery
+				}
+				lastDelivery = deliverAt
+				select {
+				case chunks <- chunk{data: buf[:n], deliverAt: deliverAt}:
+				case <-c.done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var nextSend time.Time
+	for chunk := range chunks {
+		if !c.sleepUntil(chunk.deliverAt) {
+			return
+		}
+		faults, ok := c.faults.await(l, c.done)
+		if !ok {
+			return
+		}
+		if faults.Bandwidth > 0 {
+			if now := time.Now(); nextSend.Before(now) {
+				nextSend = now
+			}
+			nextSend = nextSend.Add(time.Duration(int64(len(chunk.data)) * int64(time.Second) / faults.Bandwidth))
+			if !c.sleepUntil(nextSend) {
+				return
+			}
+		}
+		if _, err := dst.Write(chunk.data); err != nil {
+			c.close()
+			return
+		}
+	}
+	// [src] closed its side of the connection, so close the same side towards [dst] once its traffic is delivered.
+	if tcpConn, ok := dst.(*net.TCPConn); ok {
+		_ = tcpConn.CloseWrite()
+	}
+}
+
+// sleepUntil waits until [t]. Returns false if the connection is closed first.
+func (c *proxiedConn) sleepUntil(t time.Time) bool {
+	delay := time.Until(t)
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+// reset closes both sides of the connection with a TCP reset rather than a graceful close
+func (c *proxiedConn) reset() {
+	for _, conn := range []net.Conn{c.src, c.dst} {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			_ = tcpConn.SetLinger(0)
+		}
+	}
+	c.close()
+}
+
+func (c *proxiedConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.src.Close()
+		_ = c.dst.Close()
+		c.faults.wake()
+	})
+}
+
+// chunkDelay returns the delay of a chunk of traffic on a link with [faults]
+func chunkDelay(faults backend.LinkFaults) time.Duration {
+	delay := faults.Latency
+	if faults.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(faults.Jitter) + 1))
+	}
+	return delay
+}
+
+// faultInjectionConfig updates [nodeConfig] of the node [nodeDef] so that it only connects to its peers through link
+// proxies
+func faultInjectionConfig(nodeDef backend.NodeConfig, nodeConfig map[string]interface{}) error {
+	if nodeDef.NodeID == "" {
+		return fmt.Errorf("node %s must set its NodeID to join a network with fault injection enabled", nodeDef.Name)
+	}
+	nodeConfig[config.PublicIPKey] = faultInjectionPublicIP
+	nodeConfig[config.NetworkAllowPrivateIPsKey] = false
+	if _, ok := nodeConfig[config.NetworkPingTimeoutKey]; !ok {
+		nodeConfig[config.NetworkPingTimeoutKey] = faultInjectionPingTimeout
+	}
+	return nil
+}
+
+// linkArgs returns [args] with the bootstrap IPs and IDs in the config of the node [name] replaced with the link
+// proxies to every other node in the network. Bootstrap IPs of nodes outside of the network are left as they are. This
+// is called each time the node is started, so that it connects to the nodes that joined the network since it was
+// last started.
+func (c *networkConstructor) linkArgs(name string, args []string) ([]string, error) {
+	nodeConfig, err := nodeConfigFromArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	peers := make([]*node, 0, len(c.nodes))
+	for peerName, peer := range c.nodes {
+		if peer != nil && peerName != name && peer.config.NodeID != "" {
+			peers = append(peers, peer)
+		}
+	}
+	c.lock.Unlock()
+	sort.Slice(peers, func(i, j int) bool { return peers[i].config.Name < peers[j].config.Name })
+
+	peerPorts := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		if _, port, err := net.SplitHostPort(peer.GetBootstrapIP()); err == nil {
+			peerPorts[port] = true
+		}
+	}
+	ips, ids := make([]string, 0, len(peers)), make([]string, 0, len(peers))
+	configIPs, _ := nodeConfig[config.BootstrapIPsKey].(string)
+	configIDs, _ := nodeConfig[config.BootstrapIDsKey].(string)
+	if configIPs != "" {
+		splitIDs := strings.Split(configIDs, ",")
+		for i, ip := range strings.Split(configIPs, ",") {
+			if _, port, err := net.SplitHostPort(strings.TrimSpace(ip)); err == nil && peerPorts[port] {
+				continue
+			}
+			ips = append(ips, ip)
+			if i < len(splitIDs) {
+				ids = append(ids, splitIDs[i])
+			}
+		}
+	}
+	for _, peer := range peers {
+		port, err := c.faults.open(link{from: name, to: peer.config.Name}, c.ports, peerAddress(peer))
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, fmt.Sprintf("127.0.0.1:%d", port))
+		ids = append(ids, peer.config.NodeID)
+	}
+	nodeConfig[config.BootstrapIPsKey] = strings.Join(ips, ",")
+	nodeConfig[config.BootstrapIDsKey] = strings.Join(ids, ",")
+	return nodeArgs(nodeConfig)
+}
+
+// peerAddress returns a function that returns the address that [peer] accepts connections from its peers on
+func peerAddress(peer *node) func() (string, error) {
+	return func() (string, error) {
+		_, port, err := net.SplitHostPort(peer.GetBootstrapIP())
+		if err != nil {
+			return "", err
+		}
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+}
+
+// checkNodes returns an error if any of [names] is not a node of the network
+func (c *networkConstructor) checkNodes(names ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, name := range names {
+		if _, exists := c.nodes[name]; !exists {
+			return fmt.Errorf("node %s does not exist in network %s", name, c.name)
+		}
+	}
+	return nil
+}
+
+// Partition splits the nodes of the network into [partitions] by stalling the links between nodes in different
+// partitions
+func (c *networkConstructor) Partition(ctx context.Context, partitions [][]string) error {
+	if c.faults == nil {
+		return errFaultInjectionDisabled
+	}
+	for _, partition := range partitions {
+		if err := c.checkNodes(partition...); err != nil {
+			return fmt.Errorf("cannot partition network %s: %w", c.name, err)
+		}
+	}
+	c.faults.partition(partitions)
+	return nil
+}
+
+// Heal removes all partitions and link faults from the network
+func (c *networkConstructor) Heal(ctx context.Context) error {
+	if c.faults == nil {
+		return errFaultInjectionDisabled
+	}
+	c.faults.heal()
+	return nil
+}
+
+// SetLinkFaults applies [faults] to the traffic sent from [from] to [to]
+func (c *networkConstructor) SetLinkFaults(ctx context.Context, from string, to string, faults backend.LinkFaults) error {
+	if c.faults == nil {
+		return errFaultInjectionDisabled
+	}
+	if err := c.checkNodes(from, to); err != nil {
+		return fmt.Errorf("cannot set faults of link from %s to %s: %w", from, to, err)
+	}
+	c.faults.setFaults(link{from: from, to: to}, faults)
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoServer returns the address of a server that echoes everything sent to it until the test finishes
+func echoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// roundTrip sends [size] bytes over [conn] and returns the time it takes for them to be echoed back
+func roundTrip(t *testing.T, conn net.Conn, size int) time.Duration {
+	start := time.Now()
+	if _, err := conn.Write(make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	return time.Since(start)
+}
+
+func TestLinkProxy(t *testing.T) {
+	assert := assert.New(t)
+
+	ports, err := utils.PortsConfig{}.NewPortAllocator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkPorts, err := ports.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer networkPorts.Close()
+
+	target := echoServer(t)
+	faults := newFaultInjector()
+	defer faults.close()
+	l := link{from: "node0", to: "node1"}
+	port, err := faults.open(l, networkPorts, func() (string, error) { return target, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	samePort, err := faults.open(l, networkPorts, func() (string, error) { return target, nil })
+	assert.NoError(err)
+	assert.Equal(port, samePort, "expected the proxy of a link to be reused")
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	assert.Less(int64(roundTrip(t, conn, 10)), int64(100*time.Millisecond))
+
+	// Latency applies to each direction of the connection separately.
+	faults.setFaults(l, backend.LinkFaults{Latency: 100 * time.Millisecond})
+	faults.setFaults(link{from: l.to, to: l.from}, backend.LinkFaults{Latency: 50 * time.Millisecond})
+	assert.GreaterOrEqual(int64(roundTrip(t, conn, 10)), int64(150*time.Millisecond))
+
+	faults.setFaults(l, backend.LinkFaults{Bandwidth: 10 * 1024})
+	faults.setFaults(link{from: l.to, to: l.from}, backend.LinkFaults{})
+	assert.GreaterOrEqual(int64(roundTrip(t, conn, 5*1024)), int64(400*time.Millisecond), "expected bandwidth to be capped")
+
+	// Traffic is held while the nodes are partitioned and delivered once they are healed.
+	faults.partition([][]string{{"node0"}})
+	if _, err := conn.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	echoed := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(conn, make([]byte, 10))
+		echoed <- err
+	}()
+	select {
+	case <-echoed:
+		t.Fatal("expected traffic to be blocked by the partition")
+	case <-time.After(300 * time.Millisecond):
+	}
+	faults.heal()
+	select {
+	case err := <-echoed:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected traffic to be delivered once the partition healed")
+	}
+	assert.Less(int64(roundTrip(t, conn, 10)), int64(100*time.Millisecond), "expected heal to remove the link faults")
+
+	// Closing the proxies of a node closes its connections.
+	faults.closeNode("node1")
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(err, "expected connection to be closed")
+	assert.NoError(networkPorts.Reserve(port), "expected the port of the proxy to be released")
+	networkPorts.Release(port)
+
+	// A dropped chunk of traffic resets its connection.
+	port, err = faults.open(l, networkPorts, func() (string, error) { return target, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err = net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	faults.setFaults(l, backend.LinkFaults{DropRate: 1})
+	if _, err := conn.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = io.ReadFull(conn, make([]byte, 10))
+	assert.Error(err, "expected the connection to be reset")
+	assert.False(errors.Is(err, os.ErrDeadlineExceeded), "expected the connection to be reset rather than stalled")
+}
+
+func TestFaultInjectionUnknownNodes(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	ports, err := utils.PortsConfig{}.NewPortAllocator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkPorts, err := ports.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer networkPorts.Close()
+
+	constructor := newNetworkConstructor("network", t.TempDir(), t.TempDir(), backend.NewExecutorRegistry(nil), NodeLogsConfig{}, networkPorts, true, func() {})
+	defer constructor.faults.close()
+	if err := constructor.claim("node0"); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(constructor.Partition(ctx, [][]string{{"node0"}}))
+	assert.Error(constructor.Partition(ctx, [][]string{{"node0"}, {"node1"}}), "expected a partition of an unknown node to fail")
+	assert.Error(constructor.SetLinkFaults(ctx, "node0", "node1", backend.LinkFaults{Latency: time.Second}), "expected faults of a link to an unknown node to fail")
+}
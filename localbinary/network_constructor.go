@@ -7,80 +7,568 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io/fs"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils"
 	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/ids"
 	"go.uber.org/zap"
 )
 
-var _ backend.NetworkConstructor = &networkConstructor{}
+const (
+	// chainConfigDir and subnetConfigDir are the directories that the chain and subnet configs of a node are written
+	// to within the directory of the node
+	chainConfigDir  = "configs/chains"
+	subnetConfigDir = "configs/subnets"
+)
+
+var (
+	_ backend.NetworkConstructor = &networkConstructor{}
+	_ backend.DiskUsageReporter  = &networkConstructor{}
+	_ backend.NodeUpdater        = &networkConstructor{}
+	_ backend.ExecutableChecker  = &networkConstructor{}
+)
 
 type networkConstructor struct {
+	name           string
 	registry       backend.ExecutorRegistry
 	networkBaseDir string
+	// snapshotDir is the directory that snapshots of the network are saved to
+	snapshotDir string
+	logsConfig  NodeLogsConfig
+	// ports is the range of ports reserved for the network
+	ports *utils.PortAllocator
+	// removed is called once the network has been torn down
+	removed func()
+	// faults injects faults into the connections between the nodes of the network if fault injection is enabled, or is
+	// nil otherwise
+	faults *faultInjector
+
+	// lock protects the fields below
+	lock sync.Mutex
+	// nodes are the nodes of the network that have not been stopped. A node that is still being added is nil.
+	nodes map[string]*node
+	// states are the persisted states of [nodes]
+	states   map[string]nodeState
+	tornDown bool
 }
 
-func newNetworkConstructor(networkBaseDir string, registry backend.ExecutorRegistry) backend.NetworkConstructor {
-	return &networkConstructor{
+func newNetworkConstructor(name string, networkBaseDir string, snapshotDir string, registry backend.ExecutorRegistry, logsConfig NodeLogsConfig, ports *utils.PortAllocator, faultInjection bool, removed func()) *networkConstructor {
+	constructor := &networkConstructor{
+		name:           name,
 		registry:       registry,
 		networkBaseDir: networkBaseDir,
+		snapshotDir:    snapshotDir,
+		logsConfig:     logsConfig,
+		ports:          ports,
+		removed:        removed,
+		nodes:          make(map[string]*node),
+		states:         make(map[string]nodeState),
+	}
+	if faultInjection {
+		constructor.faults = newFaultInjector()
 	}
+	return constructor
 }
 
 func (c *networkConstructor) AddNode(ctx context.Context, nodeDef backend.NodeConfig) (backend.Node, error) {
-	executable, exists := c.registry.GetExecutor(nodeDef.Executable)
-	if !exists {
-		return nil, fmt.Errorf("no executable found for node %s to execute command %s", nodeDef.Name, nodeDef.Executable)
+	if err := c.claim(nodeDef.Name); err != nil {
+		return nil, err
+	}
+	node, err := c.addNode(ctx, nodeDef)
+	if err != nil {
+		c.remove(nodeDef.Name)
+		return nil, err
 	}
+	c.track(node)
+	return node, nil
+}
 
-	// Modify the node config to advertise "127.0.0.1" as the public IP for the local network
-	modifiedNodeConfig := backend.CopyConfig(nodeDef.Config)
+func (c *networkConstructor) addNode(ctx context.Context, nodeDef backend.NodeConfig) (*node, error) {
+	modifiedNodeConfig, err := c.localNodeConfig(nodeDef)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := c.reservePorts(nodeDef.Name, modifiedNodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	node, err := c.createNode(nodeDef, modifiedNodeConfig, ports)
+	if err == nil {
+		err = node.start(ctx)
+	}
+	if err != nil {
+		c.ports.Release(ports.reserved...)
+		return nil, err
+	}
+	return node, nil
+}
 
-	modifiedNodeConfig[config.PublicIPKey] = "127.0.0.1"
+// UpdateNode restarts the node [name] with [update] applied to its config. The node keeps the ports it was allocated,
+// so that its peers can still reach it at its bootstrap IP.
+func (c *networkConstructor) UpdateNode(ctx context.Context, name string, update backend.NodeUpdate, stopTimeout time.Duration) (backend.Node, error) {
+	c.lock.Lock()
+	node := c.nodes[name]
+	c.lock.Unlock()
+	if node == nil {
+		return nil, fmt.Errorf("cannot update non-existent node: %s", name)
+	}
 
-	// Find 2 free ports in case they are needed ie. the port keys are not explicitly set.
-	ports, err := utils.GetFreePorts(2)
+	nodeDef := update.Apply(node.definition())
+	nodeConfig, err := c.localNodeConfig(nodeDef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find 2 free ports: %w", err)
+		return nil, err
 	}
-	if _, ok := modifiedNodeConfig[config.HTTPHostKey]; !ok {
-		modifiedNodeConfig[config.HTTPHostKey] = ports[0]
+	nodeConfig[config.HTTPPortKey] = node.ports.httpPort
+	nodeConfig[config.StakingPortKey] = node.ports.stakingPort
+	executable, plugins, err := c.executables(nodeDef)
+	if err != nil {
+		return nil, err
 	}
-	if _, ok := modifiedNodeConfig[config.StakingPortKey]; !ok {
-		modifiedNodeConfig[config.HTTPHostKey] = ports[1]
+
+	args, err := nodeArgs(nodeConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	nodeConfigBytes, err := json.Marshal(modifiedNodeConfig)
+	// The plugins of the node cannot be replaced while it is running them.
+	if err := node.halt(ctx, stopTimeout); err != nil {
+		return nil, err
+	}
+	nodeDir := filepath.Join(c.networkBaseDir, name)
+	if err := installPlugins(nodeDir, executable.Path, nodeConfig, plugins); err != nil {
+		return nil, c.restartNode(ctx, node, fmt.Errorf("failed to install plugins of node %s: %w", name, err))
+	}
+	if err := writeConfigFiles(nodeDir, nodeDef, nodeConfig); err != nil {
+		return nil, c.restartNode(ctx, node, fmt.Errorf("failed to write config files of node %s: %w", name, err))
+	}
+	zap.L().Info("Updating node", zap.String("name", name), zap.String("executable", nodeDef.Executable), zap.Any("config", nodeConfig))
+	node.reconfigure(nodeDef, executable.Path, executable.SHA256, args)
+	if err := node.start(ctx); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// restartNode starts [node] again on its previous config after its update failed with [err] once it was halted, so
+// that the failed update does not leave the node stopped. Returns [err] along with any error restarting the node.
+func (c *networkConstructor) restartNode(ctx context.Context, node *node, err error) error {
+	zap.L().Warn("Restarting node after failed update", zap.String("name", node.config.Name), zap.Error(err))
+	if startErr := node.start(ctx); startErr != nil {
+		return fmt.Errorf("%w, and failed to restart the node: %s", err, startErr)
+	}
+	return err
+}
+
+// localNodeConfig returns a copy of the config of [nodeDef] modified to advertise "127.0.0.1" as the public IP for the
+// local network, or to connect to its peers through link proxies if fault injection is enabled.
+func (c *networkConstructor) localNodeConfig(nodeDef backend.NodeConfig) (map[string]interface{}, error) {
+	nodeConfig := backend.CopyConfig(nodeDef.Config)
+	if c.faults != nil {
+		if err := faultInjectionConfig(nodeDef, nodeConfig); err != nil {
+			return nil, err
+		}
+		return nodeConfig, nil
+	}
+	nodeConfig[config.PublicIPKey] = "127.0.0.1"
+	return nodeConfig, nil
+}
+
+// reservePorts reserves the ports set explicitly in [nodeConfig], so that they are not allocated to another node, and
+// allocates ports from the network's range for the port keys that are not set, which are added to [nodeConfig]. A port
+// of 0 lets the node bind any free port and is reported once the node has started.
+func (c *networkConstructor) reservePorts(name string, nodeConfig map[string]interface{}) (nodePorts, error) {
+	httpPort, httpPortSet, err := parsePort(nodeConfig, config.HTTPPortKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal node config: %w", err)
+		return nodePorts{}, err
+	}
+	stakingPort, stakingPortSet, err := parsePort(nodeConfig, config.StakingPortKey)
+	if err != nil {
+		return nodePorts{}, err
+	}
+	reservedPorts := make([]int, 0, 2)
+	for _, port := range []int{httpPort, stakingPort} {
+		if port != 0 {
+			reservedPorts = append(reservedPorts, port)
+		}
+	}
+	if err := c.ports.Reserve(reservedPorts...); err != nil {
+		return nodePorts{}, fmt.Errorf("failed to reserve ports for node %s: %w", name, err)
+	}
+	missing := make([]string, 0, 2)
+	if !httpPortSet {
+		missing = append(missing, config.HTTPPortKey)
+	}
+	if !stakingPortSet {
+		missing = append(missing, config.StakingPortKey)
+	}
+	allocated, err := c.ports.Allocate(len(missing))
+	if err != nil {
+		c.ports.Release(reservedPorts...)
+		return nodePorts{}, fmt.Errorf("failed to allocate ports for node %s: %w", name, err)
 	}
+	for i, key := range missing {
+		nodeConfig[key] = allocated[i]
+		if key == config.HTTPPortKey {
+			httpPort = allocated[i]
+		} else {
+			stakingPort = allocated[i]
+		}
+	}
+	return nodePorts{
+		allocator:   c.ports,
+		httpPort:    httpPort,
+		stakingPort: stakingPort,
+		reserved:    append(reservedPorts, allocated...),
+	}, nil
+}
 
-	zap.L().Info("Starting node", zap.String("name", nodeDef.Name), zap.String("executable", nodeDef.Executable), zap.String("config", string(nodeConfigBytes)))
-	cmdParams := []string{
-		"./avalanchego",
-		fmt.Sprintf("--%s=%s", config.ConfigContentKey, base64.StdEncoding.EncodeToString(nodeConfigBytes)),
-		fmt.Sprintf("--%s=json", config.ConfigContentTypeKey),
+// createNode creates a node that runs the executable of [nodeDef] with [nodeConfig] on [ports] without starting it.
+func (c *networkConstructor) createNode(nodeDef backend.NodeConfig, nodeConfig map[string]interface{}, ports nodePorts) (*node, error) {
+	executable, plugins, err := c.executables(nodeDef)
+	if err != nil {
+		return nil, err
 	}
 
 	// Seet $HOME to [networkBaseDir] so that the process will start with the base data directory as a sub-directory
 	// of the network data directory.
 	// TODO: switch from using HOME directory to a new AvalancheGo flag to set the base directory
-	// TODO pipe stdout into a specific file or color/pipe it to normal stdout
-	cmd := exec.Command(executable, cmdParams...)
 	baseDataDir := filepath.Join(c.networkBaseDir, nodeDef.Name)
-	cmd.Env = append(cmd.Env, fmt.Sprintf("HOME=%s", baseDataDir))
-	node, err := newNode(cmd, nodeDef)
+	env := []string{fmt.Sprintf("HOME=%s", baseDataDir)}
+	if err := installPlugins(baseDataDir, executable.Path, nodeConfig, plugins); err != nil {
+		return nil, fmt.Errorf("failed to install plugins of node %s: %w", nodeDef.Name, err)
+	}
+	if err := writeConfigFiles(baseDataDir, nodeDef, nodeConfig); err != nil {
+		return nil, fmt.Errorf("failed to write config files of node %s: %w", nodeDef.Name, err)
+	}
+	cmdParams, err := nodeArgs(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Info("Starting node", zap.String("name", nodeDef.Name), zap.String("executable", nodeDef.Executable), zap.Any("config", nodeConfig))
+	logs, err := newNodeLogs(nodeDef.Name, baseDataDir, c.logsConfig)
+	if err != nil {
+		return nil, err
+	}
+	node := c.newNode(nodeDef, executable.Path, cmdParams, env, logs, ports)
+	node.checksum = executable.SHA256
+	return node, nil
+}
+
+// writeConfigFiles writes the chain and subnet configs of [nodeDef] to [nodeDir] and points [nodeConfig] at them. The
+// directories are given relative to $HOME, which is the directory of the node, so that they move with the node ie. when
+// a snapshot is restored.
+func writeConfigFiles(nodeDir string, nodeDef backend.NodeConfig, nodeConfig map[string]interface{}) error {
+	if err := backend.SetConfigDirs(nodeDef, nodeConfig, filepath.Join("$HOME", chainConfigDir), filepath.Join("$HOME", subnetConfigDir)); err != nil {
+		return err
+	}
+	return backend.WriteConfigFiles(nodeDef, filepath.Join(nodeDir, chainConfigDir), filepath.Join(nodeDir, subnetConfigDir))
+}
+
+// executables returns the executor of [nodeDef] and the path of the binary of each of its plugins by VM ID
+func (c *networkConstructor) executables(nodeDef backend.NodeConfig) (backend.Executor, map[string]string, error) {
+	executable, exists := c.registry.LookupExecutor(nodeDef.Executable)
+	if !exists {
+		return backend.Executor{}, nil, fmt.Errorf("no executable found for node %s to execute command %s", nodeDef.Name, nodeDef.Executable)
+	}
+	plugins := make(map[string]string, len(nodeDef.Plugins))
+	for vmID, name := range nodeDef.Plugins {
+		if _, err := ids.FromString(vmID); err != nil {
+			return backend.Executor{}, nil, fmt.Errorf("invalid VM ID %q of plugin %s of node %s: %w", vmID, name, nodeDef.Name, err)
+		}
+		plugin, exists := c.registry.GetExecutor(name)
+		if !exists {
+			return backend.Executor{}, nil, fmt.Errorf("no executable found for plugin %s of node %s", name, nodeDef.Name)
+		}
+		plugins[vmID] = plugin
+	}
+	return executable, plugins, nil
+}
+
+// CheckExecutable returns an error if no executable is registered as [name]
+func (c *networkConstructor) CheckExecutable(name string) error {
+	if _, exists := c.registry.GetExecutor(name); !exists {
+		return fmt.Errorf("no executable registered as %s", name)
+	}
+	return nil
+}
+
+// newNode creates a node of the network, which connects to its peers through link proxies if fault injection is
+// enabled
+func (c *networkConstructor) newNode(nodeDef backend.NodeConfig, executable string, args []string, env []string, logs *nodeLogs, ports nodePorts) *node {
+	node := newNode(nodeDef, executable, args, env, logs, ports, c)
+	if c.faults != nil {
+		node.configure = func(args []string) ([]string, error) {
+			return c.linkArgs(nodeDef.Name, args)
+		}
+	}
+	return node
+}
+
+// nodeArgs returns the arguments that start AvalancheGo with [nodeConfig]
+func nodeArgs(nodeConfig map[string]interface{}) ([]string, error) {
+	nodeConfigBytes, err := json.Marshal(nodeConfig)
 	if err != nil {
+		return nil, fmt.Errorf("failed to marshal node config: %w", err)
+	}
+	return []string{
+		"./avalanchego",
+		fmt.Sprintf("--%s=%s", config.ConfigContentKey, base64.StdEncoding.EncodeToString(nodeConfigBytes)),
+		fmt.Sprintf("--%s=json", config.ConfigContentTypeKey),
+	}, nil
+}
+
+// nodeConfigFromArgs returns the node config that [args] were created with by nodeArgs
+func nodeConfigFromArgs(args []string) (map[string]interface{}, error) {
+	prefix := fmt.Sprintf("--%s=", config.ConfigContentKey)
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, prefix) {
+			continue
+		}
+		nodeConfigBytes, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode node config: %w", err)
+		}
+		nodeConfig := make(map[string]interface{})
+		if err := json.Unmarshal(nodeConfigBytes, &nodeConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node config: %w", err)
+		}
+		return nodeConfig, nil
+	}
+	return nil, errors.New("no node config found in arguments")
+}
+
+// restoreNode restores a node from its persisted [state]. A process that is still running for the node is adopted and
+// otherwise the node is restarted from its data directory, unless it had already crashed. A node that fails to restart
+// is restored as crashed, so that it can be restarted or removed.
+func (c *networkConstructor) restoreNode(ctx context.Context, state nodeState) (*node, error) {
+	name := state.Config.Name
+	status, err := backend.ParseNodeStatus(state.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore node %s: %w", name, err)
+	}
+	if err := c.claim(name); err != nil {
+		return nil, err
+	}
+	if err := c.ports.Reserve(state.Reserved...); err != nil {
+		c.remove(name)
+		return nil, fmt.Errorf("failed to reserve ports for node %s: %w", name, err)
+	}
+	logs, err := newNodeLogs(name, filepath.Join(c.networkBaseDir, name), c.logsConfig)
+	if err != nil {
+		c.ports.Release(state.Reserved...)
+		c.remove(name)
 		return nil, err
 	}
+	node := c.newNode(state.Config, state.Executable, state.Args, state.Env, logs, nodePorts{
+		allocator:   c.ports,
+		httpPort:    state.HTTPPort,
+		stakingPort: state.StakingPort,
+		reserved:    state.Reserved,
+	})
+	node.httpBaseURI, node.bootstrapIP = state.HTTPBaseURI, state.BootstrapIP
+	node.checksum = state.Checksum
+
+	switch {
+	case status == backend.NodeCrashed:
+		zap.L().Info("Restoring crashed node", zap.String("name", name))
+		node.restoreCrashed(nil)
+	case state.PID != 0 && processMatches(state.PID, state.Args):
+		zap.L().Info("Adopting running node", zap.String("name", name), zap.Int("pid", state.PID))
+		if status != backend.NodePaused {
+			status = backend.NodeRunning
+		}
+		if err := node.adopt(state.PID, status); err != nil {
+			node.restoreCrashed(err)
+		} else if c.faults != nil && status == backend.NodeRunning {
+			// The node connected to its peers through the link proxies of the previous orchestrator and would only
+			// reconnect to them at their unreachable advertised IPs, so restart it to connect through the new proxies.
+			zap.L().Info("Restarting adopted node to reconnect through link proxies", zap.String("name", name))
+			if err := node.Restart(ctx, snapshotStopTimeout); err != nil {
+				zap.L().Error("failed to restart node", zap.String("name", name), zap.Error(err))
+				node.restoreCrashed(err)
+			}
+		}
+	default:
+		zap.L().Info("Restarting node", zap.String("name", name))
+		if err := node.start(ctx); err != nil {
+			zap.L().Error("failed to restart node", zap.String("name", name), zap.Error(err))
+			node.restoreCrashed(err)
+		}
+	}
+	c.track(node)
 	return node, nil
 }
 
+// claim reserves the name of a node that is being added to the network. The state of the network is persisted by node
+// name, and two nodes with the same name would share a data directory, so names cannot be shared.
+func (c *networkConstructor) claim(name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.tornDown {
+		return fmt.Errorf("cannot add node %s to torn down network: %s", name, c.name)
+	}
+	if _, exists := c.nodes[name]; exists {
+		return fmt.Errorf("cannot create duplicate node under name: %s", name)
+	}
+	c.nodes[name] = nil
+	return nil
+}
+
+// track tracks [node] under the name it claimed
+func (c *networkConstructor) track(node *node) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.nodes[node.config.Name] = node
+}
+
+// getNodes returns the nodes that are part of the network
+func (c *networkConstructor) getNodes() []backend.Node {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes := make([]backend.Node, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// save persists [state] as the state of its node along with the rest of the network, unless the node is no longer
+// part of the network.
+func (c *networkConstructor) save(state nodeState) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, exists := c.nodes[state.Config.Name]; !exists || c.tornDown {
+		return
+	}
+	c.states[state.Config.Name] = state
+	c.persist()
+}
+
+// remove removes the node [name] from the network and its persisted state
+func (c *networkConstructor) remove(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.nodes, name)
+	if c.faults != nil {
+		c.faults.closeNode(name)
+	}
+	if _, exists := c.states[name]; exists && !c.tornDown {
+		delete(c.states, name)
+		c.persist()
+	}
+}
+
+// persist writes the state of the network to disk. Assumes the lock is held.
+func (c *networkConstructor) persist() {
+	start, end := c.ports.Range()
+	state := &networkState{
+		Name:           c.name,
+		PortRangeStart: start,
+		PortRangeEnd:   end,
+		FaultInjection: c.faults != nil,
+		Nodes:          make([]nodeState, 0, len(c.states)),
+	}
+	for _, nodeState := range c.states {
+		state.Nodes = append(state.Nodes, nodeState)
+	}
+	if err := saveNetworkState(c.networkBaseDir, state); err != nil {
+		zap.L().Error("failed to persist network state", zap.String("network", c.name), zap.Error(err))
+	}
+}
+
+// Teardown returns the ports reserved for the network and removes its persisted state, so that it is not restored.
 // TODO: optionally remove associated data
 func (c *networkConstructor) Teardown(ctx context.Context) error {
-	return nil
+	c.lock.Lock()
+	c.tornDown = true
+	c.lock.Unlock()
+
+	if c.faults != nil {
+		c.faults.close()
+	}
+	c.ports.Close()
+	c.removed()
+	return removeNetworkState(c.networkBaseDir)
+}
+
+// parsePort returns the port set for [key] in [nodeConfig] and whether it was set. The config may have been decoded
+// from JSON or flags, so the port may be a number or a string.
+func parsePort(nodeConfig map[string]interface{}, key string) (int, bool, error) {
+	val, ok := nodeConfig[key]
+	if !ok {
+		return 0, false, nil
+	}
+
+	var port int
+	switch v := val.(type) {
+	case int:
+		port = v
+	case float64:
+		port = int(v)
+		if float64(port) != v {
+			return 0, false, fmt.Errorf("invalid %s %v", key, val)
+		}
+	case string:
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s %q: %w", key, v, err)
+		}
+		port = parsed
+	default:
+		return 0, false, fmt.Errorf("invalid %s %v of type %T", key, val, val)
+	}
+	if port < 0 || port > 65535 {
+		return 0, false, fmt.Errorf("invalid %s %d", key, port)
+	}
+	return port, true, nil
+}
+
+// DiskUsage returns the total size of the files under the base directory of the network, which includes the data
+// directories and logs of its nodes.
+func (c *networkConstructor) DiskUsage(ctx context.Context) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(c.networkBaseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can be removed by the nodes while the directory is walked.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute disk usage of network %s: %w", c.name, err)
+	}
+	return size, nil
 }
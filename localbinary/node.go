@@ -4,96 +4,543 @@
 package localbinary
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
-	"github.com/ava-labs/avalanchego/config"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
 	"go.uber.org/zap"
 )
 
-var _ backend.Node = &node{}
+const (
+	// httpPollInterval is the interval at which a starting node is checked for whether it accepts connections
+	httpPollInterval = 100 * time.Millisecond
+	// processPollInterval is the interval at which an adopted process, which cannot be waited on, is checked for
+	// whether it is still running
+	processPollInterval = 500 * time.Millisecond
+)
+
+var (
+	_ backend.Node                  = &node{}
+	_ backend.NodeLogger            = &node{}
+	_ backend.StatusNotifier        = &node{}
+	_ backend.RestartPolicyReporter = &node{}
+
+	errProcessExited = errors.New("adopted process exited")
+
+	// AvalancheGo logs the address of its API server and the IP it advertises to peers on startup, which are used to
+	// report the ports the node actually bound.
+	httpListeningRegex = regexp.MustCompile(`(HTTPS?) API server listening on "(.*):(\d+)"`)
+	publicIPRegex      = regexp.MustCompile(`this node's IP is set to: "(.*):(\d+)"`)
+)
+
+// nodePorts are the ports of a node and the allocator they were reserved from
+type nodePorts struct {
+	allocator *utils.PortAllocator
+	// httpPort and stakingPort are the ports set in the config of the node. A port of 0 lets the node bind any free
+	// port, which is reported once the node has started.
+	httpPort    int
+	stakingPort int
+	// reserved are the ports reserved for the node, which are released when the node is stopped
+	reserved []int
+}
 
 type node struct {
-	cmd *exec.Cmd
+	// lock protects the process related fields below, which are replaced each time the node is started.
+	lock sync.RWMutex
 
 	config backend.NodeConfig
 
+	// executable, args, and env are used to start the node process, so that the node can be restarted
+	// with exactly the same parameters.
+	executable string
+	args       []string
+	env        []string
+	// checksum is the SHA-256 checksum that [executable] was registered with if known, which is verified before each
+	// process is started, so that a binary replaced after it was registered is never run
+	checksum string
+	// configure returns the arguments to start the node with in place of [args] if non-nil, which may change between
+	// starts of the node ie. as its peers change
+	configure func(args []string) ([]string, error)
+
+	ports nodePorts
+	// portsReleased is set when the ports of the node have been released, so that they are reserved again on restart
+	portsReleased bool
+
 	httpBaseURI string
 	bootstrapIP string
+	// httpBound is closed once the current process reports the address of its API server
+	httpBound chan struct{}
+
+	// logs captures the output of every process started for the node
+	logs *nodeLogs
 
-	nodeStopped chan struct{}
-	stopErr     error
+	process *os.Process
+	// store persists the state of the node each time its status changes if non-nil
+	store nodeStore
+
+	backend.NodeStatusTracker
 }
 
-func newNode(cmd *exec.Cmd, nodeDef backend.NodeConfig) (*node, error) {
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start process for node %s: %w", nodeDef.Name, err)
-	}
+// newNode creates a node that runs [executable] with [args] and [env] on [ports]. The node is not started.
+func newNode(nodeDef backend.NodeConfig, executable string, args []string, env []string, logs *nodeLogs, ports nodePorts, store nodeStore) *node {
 	node := &node{
-		cmd:         cmd,
 		config:      nodeDef,
-		nodeStopped: make(chan struct{}),
+		executable:  executable,
+		args:        args,
+		env:         env,
+		logs:        logs,
+		ports:       ports,
+		httpBaseURI: fmt.Sprintf("http://127.0.0.1:%d", ports.httpPort),
+		bootstrapIP: fmt.Sprintf("127.0.0.1:%d", ports.stakingPort),
+		store:       store,
 	}
+	node.NodeStatusTracker = backend.NewNodeStatusTracker(&node.lock, node.save)
+	logs.stdout.watch(node.observeOutput)
+	return node
+}
+
+// definition returns the config that the node was created with, updated by any later call to reconfigure
+func (n *node) definition() backend.NodeConfig {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.config
+}
+
+// reconfigure replaces the config of the node along with the [executable], its [checksum], and the [args] it is started
+// with, which take effect the next time the node is started. The name of the node never changes, so it is left in place
+// to be read without the lock.
+func (n *node) reconfigure(nodeDef backend.NodeConfig, executable string, checksum string, args []string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.config.Executable = nodeDef.Executable
+	n.config.Config = nodeDef.Config
+	n.config.Plugins = nodeDef.Plugins
+	n.config.ChainConfigs = nodeDef.ChainConfigs
+	n.config.SubnetConfigs = nodeDef.SubnetConfigs
+	n.executable = executable
+	n.checksum = checksum
+	n.args = args
+}
 
-	go func() {
-		err := cmd.Wait()
+// start starts a new process for the node and waits for it to accept connections on its HTTP port.
+func (n *node) start(ctx context.Context) error {
+	n.lock.RLock()
+	executable, checksum, args := n.executable, n.checksum, n.args
+	n.lock.RUnlock()
+	if err := verifyChecksum(executable, checksum); err != nil {
+		return fmt.Errorf("cannot start node %s: %w", n.config.Name, err)
+	}
+	if n.configure != nil {
+		configured, err := n.configure(args)
 		if err != nil {
-			zap.L().Error("node stopped", zap.Error(err))
-		} else {
-			zap.L().Debug("node stopped", zap.String("name", nodeDef.Name))
+			return fmt.Errorf("failed to configure node %s: %w", n.config.Name, err)
 		}
+		args = configured
+	}
 
-		node.stopErr = err
-		close(node.nodeStopped)
-	}()
+	n.lock.Lock()
+	if n.portsReleased {
+		if err := n.ports.allocator.Reserve(n.ports.reserved...); err != nil {
+			n.lock.Unlock()
+			return fmt.Errorf("failed to reserve ports for node %s: %w", n.config.Name, err)
+		}
+		n.portsReleased = false
+	}
+	httpBound := make(chan struct{})
+	n.httpBound = httpBound
+	n.args = args
+	stdout, stderr, err := n.logs.open()
+	if err != nil {
+		n.lock.Unlock()
+		return fmt.Errorf("failed to open logs of node %s: %w", n.config.Name, err)
+	}
+	// The process writes to its log files directly and runs in its own process group, so that it keeps running if the
+	// orchestrator exits or is interrupted from the terminal.
+	cmd := exec.Command(executable, args...)
+	cmd.Env = n.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err = cmd.Start()
+	// The process has its own copies of the log files once it has started.
+	_ = stdout.Close()
+	_ = stderr.Close()
+	if err != nil {
+		_ = n.logs.close()
+		n.lock.Unlock()
+		return fmt.Errorf("failed to start process for node %s: %w", n.config.Name, err)
+	}
+	n.process = cmd.Process
+	nodeStopped := n.StartRun(backend.NodeStarting)
+	n.lock.Unlock()
 
-	// Wait 500ms to optimistically try to ensure the node has started successfully.
-	// If it fails within the first 500ms, return the error that occurs on startup.
-	select {
-	case <-node.nodeStopped:
-		return nil, node.stopErr
-	case <-time.After(500 * time.Millisecond):
+	go n.wait(cmd, nodeStopped)
+
+	// Wait for the node to accept connections on its HTTP port, which it binds once it has finished initializing. If
+	// the node exits first, return the error that occurred on startup.
+	ticker := time.NewTicker(httpPollInterval)
+	defer ticker.Stop()
+	for bound := false; !bound; {
+		select {
+		case <-nodeStopped:
+			n.lock.RLock()
+			defer n.lock.RUnlock()
+			if err := n.StopErr(); err != nil {
+				return err
+			}
+			return fmt.Errorf("node %s exited on startup", n.config.Name)
+		case <-ctx.Done():
+			// Kill the process rather than leaving it running, since the caller releases the ports of the node and
+			// forgets it once start fails. Wait for it to exit, so that its logs are closed.
+			n.lock.Lock()
+			n.RequestStop()
+			n.lock.Unlock()
+			if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				zap.L().Warn("failed to kill node", zap.String("name", n.config.Name), zap.Error(err))
+			}
+			<-nodeStopped
+			return ctx.Err()
+		case <-httpBound:
+			bound = true
+		case <-ticker.C:
+			// The output of the node may not include the address of its API server depending on its log config,
+			// so probe the port directly if it is known.
+			bound = n.ports.httpPort != 0 && isListening(n.ports.httpPort)
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.MarkRunning(nodeStopped)
+	return nil
+}
+
+// wait waits for [cmd] to exit and marks the node as stopped or crashed depending on whether
+// the node was asked to stop.
+func (n *node) wait(cmd *exec.Cmd, nodeStopped chan struct{}) {
+	err := cmd.Wait()
+	n.exited(err, nodeStopped)
+}
+
+// exited closes the logs of the node once its process has exited with [err] and marks the node as stopped or crashed
+// depending on whether the node was asked to stop.
+func (n *node) exited(err error, nodeStopped chan struct{}) {
+	// The process has exited and can no longer write to its log files, so they can be closed.
+	if logErr := n.logs.close(); logErr != nil {
+		zap.L().Warn("failed to close node logs", zap.String("name", n.config.Name), zap.Error(logErr))
 	}
 
-	// TODO use defaults from AvalancheGo for ports
-	stakingPort := "9651"
-	if val, ok := nodeDef.Config[config.StakingPortKey]; ok {
-		stakingPort = fmt.Sprintf("%v", val)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.Exited(n.config.Name, err, nodeStopped)
+}
+
+// save saves the state of the node each time its status changes. Assumes the lock is held.
+func (n *node) save() {
+	if n.store != nil {
+		n.store.save(n.state())
+	}
+}
+
+// state returns the state of the node to persist. Assumes the lock is held.
+func (n *node) state() nodeState {
+	pid := 0
+	if n.process != nil {
+		pid = n.process.Pid
+	}
+	return nodeState{
+		Config:      n.config,
+		Executable:  n.executable,
+		Checksum:    n.checksum,
+		Args:        n.args,
+		Env:         n.env,
+		HTTPPort:    n.ports.httpPort,
+		StakingPort: n.ports.stakingPort,
+		Reserved:    n.ports.reserved,
+		HTTPBaseURI: n.httpBaseURI,
+		BootstrapIP: n.bootstrapIP,
+		PID:         pid,
+		Status:      n.CurrentStatus().String(),
+	}
+}
+
+// adopt tracks the running process [pid], which was started for the node by a previous orchestrator, as the process of
+// the node with the given [status]. The process keeps writing to the log files it was started with, which are followed
+// from now on.
+func (n *node) adopt(pid int, status backend.NodeStatus) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := n.logs.follow(); err != nil {
+		return fmt.Errorf("failed to follow logs of node %s: %w", n.config.Name, err)
 	}
-	node.bootstrapIP = fmt.Sprintf("127.0.0.1:%s", stakingPort)
 
-	httpPort := "9650"
-	if val, ok := nodeDef.Config[config.HTTPPortKey]; ok {
-		httpPort = fmt.Sprintf("%v", val)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.process = process
+	nodeStopped := n.StartRun(status)
+	go n.watchProcess(process, nodeStopped)
+	return nil
+}
+
+// watchProcess polls the adopted [process] until it exits, since only the parent of a process can wait on it.
+func (n *node) watchProcess(process *os.Process, nodeStopped chan struct{}) {
+	ticker := time.NewTicker(processPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := process.Signal(syscall.Signal(0)); err != nil {
+			// The exit status of the process is unknown, so an exit is only an error if the node was not asked to stop.
+			n.lock.RLock()
+			exitErr := errProcessExited
+			if n.StopRequested() {
+				exitErr = nil
+			}
+			n.lock.RUnlock()
+			n.exited(exitErr, nodeStopped)
+			return
+		}
 	}
-	node.httpBaseURI = fmt.Sprintf("http://127.0.0.1:%s", httpPort)
+}
 
-	return node, nil
+// restoreCrashed marks the node as crashed without a process, so that it can be restarted or removed.
+func (n *node) restoreCrashed(err error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.MarkCrashed(err)
 }
 
+func (n *node) RestartPolicy() *backend.RestartPolicy { return n.config.RestartPolicy }
+
 func (n *node) GetName() string { return n.config.Name }
 
-func (n *node) GetHTTPBaseURI() string { return n.httpBaseURI }
+// observeOutput updates the HTTP base URI and bootstrap IP of the node from the addresses reported in [line]
+func (n *node) observeOutput(line string) {
+	if match := httpListeningRegex.FindStringSubmatch(line); match != nil {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+
+		n.httpBaseURI = fmt.Sprintf("%s://%s", strings.ToLower(match[1]), net.JoinHostPort(reportedHost(match[2]), match[3]))
+		// An adopted process was started by a previous orchestrator, so nothing waits for it to bind its HTTP port.
+		if n.httpBound != nil {
+			select {
+			case <-n.httpBound:
+			default:
+				close(n.httpBound)
+			}
+		}
+		if n.store != nil {
+			n.store.save(n.state())
+		}
+		return
+	}
+	if match := publicIPRegex.FindStringSubmatch(line); match != nil {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+
+		n.bootstrapIP = net.JoinHostPort(reportedHost(match[1]), match[2])
+		if n.store != nil {
+			n.store.save(n.state())
+		}
+	}
+}
+
+// isListening returns true if a connection can be established to [port] on the loopback interface
+func isListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), httpPollInterval)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// reportedHost returns the host to connect to for an address reported by the node. Unspecified addresses are
+// reachable through the loopback interface.
+func reportedHost(host string) string {
+	host = strings.Trim(host, "[]")
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		return "127.0.0.1"
+	}
+	return host
+}
+
+func (n *node) Executable() string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
 
-func (n *node) GetBootstrapIP() string { return n.bootstrapIP }
+	return n.config.Executable
+}
+
+func (n *node) GetHTTPBaseURI() string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.httpBaseURI
+}
+
+func (n *node) GetBootstrapIP() string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.bootstrapIP
+}
 
 func (n *node) Config() map[string]interface{} {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
 	return backend.CopyConfig(n.config.Config)
 }
 
+func (n *node) Status() backend.NodeStatus {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.CurrentStatus()
+}
+
+// Stop stops the node and releases its ports. The ports of a crashed node remain reserved until it is stopped, so that
+// it can be restarted with the same ports.
 func (n *node) Stop(stopTimeout time.Duration) error {
-	if err := n.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+	err := n.stop(stopTimeout)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if !n.portsReleased {
+		n.ports.allocator.Release(n.ports.reserved...)
+		n.portsReleased = true
+	}
+	if n.store != nil {
+		n.store.remove(n.config.Name)
+	}
+	return err
+}
+
+func (n *node) stop(stopTimeout time.Duration) error {
+	n.lock.Lock()
+	// A node whose process failed to start has no process to stop.
+	if n.process == nil {
+		n.lock.Unlock()
+		return nil
+	}
+	process := n.process
+	paused := n.CurrentStatus() == backend.NodePaused
+	nodeStopped, running := n.RequestStop()
+	n.lock.Unlock()
+	if !running {
+		return nil
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
 		return err
 	}
+	// A suspended process will not handle SIGTERM until it is continued.
+	if paused {
+		if err := process.Signal(syscall.SIGCONT); err != nil {
+			return err
+		}
+	}
 
 	// Attempt to wait for the process to stop before killing the process
 	select {
-	case <-n.nodeStopped:
-		return n.stopErr
+	case <-nodeStopped:
+		n.lock.RLock()
+		defer n.lock.RUnlock()
+		return n.StopErr()
 	case <-time.After(stopTimeout):
-		return n.cmd.Process.Kill()
+		if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		// Wait for the killed process to exit, so that it no longer holds the ports of the node once they are released.
+		<-nodeStopped
+		return nil
+	}
+}
+
+func (n *node) Restart(ctx context.Context, stopTimeout time.Duration) error {
+	if err := n.halt(ctx, stopTimeout); err != nil {
+		return err
+	}
+	return n.start(ctx)
+}
+
+// halt stops the process of the node and waits for it to exit, so that it releases its ports and data directory. Unlike
+// Stop, the ports remain reserved for the node and the node is not removed from the network.
+func (n *node) halt(ctx context.Context, stopTimeout time.Duration) error {
+	if err := n.stop(stopTimeout); err != nil {
+		zap.L().Debug("node exited with an error while stopping", zap.String("name", n.config.Name), zap.Error(err))
+	}
+
+	n.lock.RLock()
+	nodeStopped := n.Stopped()
+	n.lock.RUnlock()
+	if nodeStopped == nil {
+		return nil
+	}
+	select {
+	case <-nodeStopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *node) Pause() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if status := n.CurrentStatus(); status != backend.NodeStarting && status != backend.NodeRunning {
+		return fmt.Errorf("cannot pause node %s with status %s", n.config.Name, status)
+	}
+	if err := n.process.Signal(syscall.SIGSTOP); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodePaused, nil)
+	return nil
+}
+
+func (n *node) Resume() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if status := n.CurrentStatus(); status != backend.NodePaused {
+		return fmt.Errorf("cannot resume node %s with status %s", n.config.Name, status)
+	}
+	if err := n.process.Signal(syscall.SIGCONT); err != nil {
+		return err
+	}
+	n.SetStatus(backend.NodeRunning, nil)
+	return nil
+}
+
+func (n *node) GetLogs(ctx context.Context, stream backend.LogStream, lines int) ([]string, error) {
+	log, err := n.logs.get(stream)
+	if err != nil {
+		return nil, err
+	}
+	return log.readLines(ctx, lines)
+}
+
+func (n *node) TailLogs(ctx context.Context, stream backend.LogStream, f func(line string) error) error {
+	log, err := n.logs.get(stream)
+	if err != nil {
+		return err
 	}
+	return log.tail(ctx, f)
 }
@@ -0,0 +1,424 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/fatih/color"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNodeLogMaxSize    = 10 * 1024 * 1024
+	defaultNodeLogMaxBackups = 5
+
+	// maxLogLineSize is the longest line that will be read back from a log file
+	maxLogLineSize = 1024 * 1024
+	// tailBufferSize is the number of lines a tail may fall behind before it is dropped
+	tailBufferSize = 1024
+	// logFollowInterval is the interval at which the output appended to the log files is forwarded
+	logFollowInterval = 100 * time.Millisecond
+)
+
+var (
+	errTailFellBehind = errors.New("log tail fell behind the node output")
+
+	consoleColors = []color.Attribute{
+		color.FgCyan,
+		color.FgGreen,
+		color.FgYellow,
+		color.FgBlue,
+		color.FgMagenta,
+		color.FgHiCyan,
+		color.FgHiGreen,
+		color.FgHiYellow,
+		color.FgHiBlue,
+		color.FgHiMagenta,
+	}
+)
+
+// NodeLogsConfig configures how the output of each node process is captured.
+type NodeLogsConfig struct {
+	// MaxSize is the size in bytes that a log file can grow to before it is rotated. Defaults to 10 MiB if 0.
+	MaxSize int64 `json:"maxSize"`
+	// MaxBackups is the number of rotated log files retained for each stream. Defaults to 5 if 0.
+	MaxBackups int `json:"maxBackups"`
+	// Console additionally writes the output of each node to the console, prefixed with the colored name of the node.
+	Console bool `json:"console"`
+}
+
+// nodeLogs holds the captured stdout and stderr of a node process, which are written to
+// <nodeDir>/stdout.log and <nodeDir>/stderr.log respectively.
+type nodeLogs struct {
+	stdout *nodeLog
+	stderr *nodeLog
+}
+
+func newNodeLogs(name string, nodeDir string, config NodeLogsConfig) (*nodeLogs, error) {
+	maxSize := config.MaxSize
+	if maxSize == 0 {
+		maxSize = defaultNodeLogMaxSize
+	}
+	maxBackups := config.MaxBackups
+	if maxBackups == 0 {
+		maxBackups = defaultNodeLogMaxBackups
+	}
+
+	var stdoutConsole, stderrConsole io.Writer
+	prefix := ""
+	if config.Console {
+		stdoutConsole = os.Stdout
+		stderrConsole = os.Stderr
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(name))
+		prefix = color.New(consoleColors[h.Sum32()%uint32(len(consoleColors))]).Sprintf("[%s]", name)
+	}
+
+	stdoutFile, err := utils.NewRotatingFile(filepath.Join(nodeDir, "stdout.log"), maxSize, maxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout log for node %s: %w", name, err)
+	}
+	stderrFile, err := utils.NewRotatingFile(filepath.Join(nodeDir, "stderr.log"), maxSize, maxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr log for node %s: %w", name, err)
+	}
+	return &nodeLogs{
+		stdout: newNodeLog(stdoutFile, maxSize, stdoutConsole, prefix),
+		stderr: newNodeLog(stderrFile, maxSize, stderrConsole, prefix),
+	}, nil
+}
+
+func (l *nodeLogs) get(stream backend.LogStream) (*nodeLog, error) {
+	switch stream {
+	case backend.Stdout:
+		return l.stdout, nil
+	case backend.Stderr:
+		return l.stderr, nil
+	default:
+		return nil, fmt.Errorf("unknown log stream %q", stream)
+	}
+}
+
+// open starts following the log files and returns them opened for appending, so that they can be given to a new node
+// process as its stdout and stderr. The caller must close the returned files once the process has started.
+func (l *nodeLogs) open() (*os.File, *os.File, error) {
+	stdout, err := l.stdout.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err := l.stderr.open()
+	if err != nil {
+		_ = stdout.Close()
+		_ = l.stdout.close()
+		return nil, nil, err
+	}
+	return stdout, stderr, nil
+}
+
+// follow starts following the log files, which are written by a process that was started by a previous orchestrator.
+func (l *nodeLogs) follow() error {
+	if err := l.stdout.follow(); err != nil {
+		return err
+	}
+	if err := l.stderr.follow(); err != nil {
+		_ = l.stdout.close()
+		return err
+	}
+	return nil
+}
+
+// close stops following the log files once the process writing to them has exited and ends every tail. The logs can
+// be opened again afterwards.
+func (l *nodeLogs) close() error {
+	stdoutErr := l.stdout.close()
+	stderrErr := l.stderr.close()
+	if stdoutErr != nil {
+		return stdoutErr
+	}
+	return stderrErr
+}
+
+// nodeLog captures a single output stream of a node process. The process writes to the log file directly rather than
+// through a pipe, so that it keeps running if the orchestrator exits. The log follows the file to forward each complete
+// line to the watcher, the console (if enabled) and every tail, and rotates the file once it grows beyond [maxSize].
+type nodeLog struct {
+	file    *utils.RotatingFile
+	maxSize int64
+
+	// console is nil if the output should not be written to the console
+	console io.Writer
+	prefix  string
+
+	// lock protects the fields below
+	lock    sync.Mutex
+	partial []byte
+	tails   map[*logTail]struct{}
+	// watcher is called with every complete line if non-nil
+	watcher func(line string)
+	// stopFollowing is closed to stop following the file, after which [followed] is closed. Both are nil if the file
+	// is not being followed.
+	stopFollowing chan struct{}
+	followed      chan struct{}
+}
+
+// logTail receives the lines written to a nodeLog after it was created. [err] is set before [lines] is
+// closed if the tail ended abnormally.
+type logTail struct {
+	lines chan string
+	err   error
+}
+
+func newNodeLog(file *utils.RotatingFile, maxSize int64, console io.Writer, prefix string) *nodeLog {
+	return &nodeLog{
+		file:    file,
+		maxSize: maxSize,
+		console: console,
+		prefix:  prefix,
+		tails:   make(map[*logTail]struct{}),
+	}
+}
+
+// open starts following the file and returns it opened for appending
+func (l *nodeLog) open() (*os.File, error) {
+	file, err := os.OpenFile(l.file.Path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.follow(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// follow forwards everything appended to the file from now on until close is called
+func (l *nodeLog) follow() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.stopFollowing != nil {
+		return nil
+	}
+	reader, err := os.OpenFile(l.file.Path(), os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := reader.Stat()
+	if err != nil {
+		_ = reader.Close()
+		return err
+	}
+	stopFollowing := make(chan struct{})
+	followed := make(chan struct{})
+	l.stopFollowing = stopFollowing
+	l.followed = followed
+	go l.followFile(reader, info.Size(), stopFollowing, followed)
+	return nil
+}
+
+// followFile forwards the output appended to [reader] after [offset] every logFollowInterval until [stopFollowing] is
+// closed, and then forwards the remaining output before closing [followed].
+func (l *nodeLog) followFile(reader *os.File, offset int64, stopFollowing chan struct{}, followed chan struct{}) {
+	defer close(followed)
+	defer reader.Close()
+
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+
+	buf := make([]byte, 64*1024)
+	for {
+		stopping := false
+		select {
+		case <-ticker.C:
+		case <-stopFollowing:
+			stopping = true
+		}
+
+		info, err := reader.Stat()
+		if err != nil {
+			zap.L().Warn("failed to follow node log", zap.String("path", l.file.Path()), zap.Error(err))
+			return
+		}
+		// The file was truncated by a previous orchestrator rotating it, so start over from the beginning.
+		if info.Size() < offset {
+			offset = 0
+		}
+		for {
+			n, err := reader.ReadAt(buf, offset)
+			if n > 0 {
+				l.consume(buf[:n])
+				offset += int64(n)
+			}
+			if err != nil || n == 0 {
+				break
+			}
+		}
+		if stopping {
+			return
+		}
+		if offset >= l.maxSize {
+			if err := l.file.CopyTruncate(); err != nil {
+				zap.L().Warn("failed to rotate node log", zap.String("path", l.file.Path()), zap.Error(err))
+				continue
+			}
+			offset = 0
+		}
+	}
+}
+
+// consume forwards each complete line of the output [p]
+func (l *nodeLog) consume(p []byte) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(l.partial[:i])
+		l.partial = l.partial[i+1:]
+		l.publish(line)
+	}
+}
+
+// watch calls [f] with every line written from now on. [f] is called synchronously with the output of the node, so it
+// must not block.
+func (l *nodeLog) watch(f func(line string)) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.watcher = f
+}
+
+// publish forwards [line] to the watcher, the console, and every tail. Assumes the lock is held.
+func (l *nodeLog) publish(line string) {
+	if l.watcher != nil {
+		l.watcher(line)
+	}
+	if l.console != nil {
+		fmt.Fprintf(l.console, "%s %s\n", l.prefix, line)
+	}
+	for tail := range l.tails {
+		select {
+		case tail.lines <- line:
+		default:
+			// Never block the node process on a slow reader.
+			tail.err = errTailFellBehind
+			close(tail.lines)
+			delete(l.tails, tail)
+		}
+	}
+}
+
+// close stops following the file once everything written to it has been forwarded, publishes any trailing partial
+// line, and ends every tail.
+func (l *nodeLog) close() error {
+	l.lock.Lock()
+	stopFollowing, followed := l.stopFollowing, l.followed
+	l.stopFollowing, l.followed = nil, nil
+	l.lock.Unlock()
+	if stopFollowing != nil {
+		close(stopFollowing)
+		<-followed
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.partial) > 0 {
+		l.publish(string(l.partial))
+		l.partial = nil
+	}
+	for tail := range l.tails {
+		close(tail.lines)
+		delete(l.tails, tail)
+	}
+	return nil
+}
+
+// readLines returns the last [n] lines retained across the current log file and its backups. If [n] is 0,
+// every retained line is returned.
+func (l *nodeLog) readLines(ctx context.Context, n int) ([]string, error) {
+	lines := make([]string, 0, n)
+	for _, path := range l.file.Paths() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := readFileLines(path, func(line string) {
+			if n > 0 && len(lines) == n {
+				lines = append(lines[1:], line)
+			} else {
+				lines = append(lines, line)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// tail calls [f] with every line written from now on until the log is closed, [ctx] is done, or [f] returns an error.
+func (l *nodeLog) tail(ctx context.Context, f func(line string) error) error {
+	tail := &logTail{lines: make(chan string, tailBufferSize)}
+	l.lock.Lock()
+	l.tails[tail] = struct{}{}
+	l.lock.Unlock()
+
+	defer func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if _, ok := l.tails[tail]; ok {
+			delete(l.tails, tail)
+			close(tail.lines)
+		}
+	}()
+
+	for {
+		select {
+		case line, ok := <-tail.lines:
+			if !ok {
+				return tail.err
+			}
+			if err := f(line); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func readFileLines(path string, f func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file may have been rotated away since it was listed.
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		f(scanner.Text())
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalNodeStartCanceled(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	// The node never binds its HTTP port, so it does not finish starting before the context is done.
+	pidFile := filepath.Join(dir, "pid")
+	executable := filepath.Join(dir, "hang")
+	writeFile(t, executable, "#!/bin/sh\necho $$ > "+pidFile+"\nexec sleep 600\n")
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:           filepath.Join(dir, "networks"),
+		Registry:          map[string]string{"hang": executable},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(context.Background()))
+	}()
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = network.AddNode(ctx, backend.NodeConfig{Name: "node0", Executable: "hang"})
+	assert.ErrorIs(err, context.DeadlineExceeded)
+
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(syscall.Kill(pid, 0), syscall.ESRCH, "expected the process of the node to be killed")
+
+	// The name of the node is free to reuse once start fails.
+	_, err = network.GetNode("node0")
+	assert.Error(err)
+	assert.NoError(network.Teardown(context.Background()))
+}
+
+func TestLocalNodeStopKilled(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	dir := t.TempDir()
+
+	// The node ignores SIGTERM, so it is only stopped once it is killed.
+	executable := filepath.Join(dir, "stubborn")
+	writeFile(t, executable, `#!/bin/sh
+trap '' TERM
+echo 'HTTP API server listening on "127.0.0.1:9650"'
+while true; do sleep 0.1; done
+`)
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:           filepath.Join(dir, "networks"),
+		Registry:          map[string]string{"stubborn": executable},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(context.Background()))
+	}()
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(context.Background()))
+	}()
+	added, err := network.AddNode(ctx, backend.NodeConfig{Name: "node0", Executable: "stubborn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := added.(*node).process.Pid
+
+	// The ports of the node are released once Stop returns, so the killed process must have exited by then.
+	assert.NoError(added.Stop(100 * time.Millisecond))
+	assert.ErrorIs(syscall.Kill(pid, 0), syscall.ESRCH, "expected the killed process to have exited")
+}
+
+func TestLocalNodeVerifiesChecksum(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:           filepath.Join(dir, "networks"),
+		Registry:          map[string]string{},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(context.Background()))
+	}()
+	executable := filepath.Join(dir, "avalanchego")
+	writeBuild(t, executable, "1.7.10")
+	if _, err := orchestrator.(backend.ExecutorManager).RegisterExecutor(context.Background(), "registered", executable, ""); err != nil {
+		t.Fatal(err)
+	}
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The executable is replaced after it was registered, so the node must not run it.
+	ran := filepath.Join(dir, "ran")
+	writeFile(t, executable, "#!/bin/sh\ntouch "+ran+"\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = network.AddNode(ctx, backend.NodeConfig{Name: "node0", Executable: "registered"})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "checksum")
+	}
+	_, err = os.Stat(ran)
+	assert.True(os.IsNotExist(err), "expected the replaced executable not to be run")
+	assert.NoError(network.Teardown(context.Background()))
+}
+
+func TestNodeLogFollow(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "node", "stdout.log")
+	file, err := utils.NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := newNodeLog(file, 10, nil, "")
+	var (
+		lock  sync.Mutex
+		lines []string
+	)
+	log.watch(func(line string) {
+		lock.Lock()
+		defer lock.Unlock()
+		lines = append(lines, line)
+	})
+	watched := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), lines...)
+	}
+
+	// The output written to the file by the process is forwarded, and the file is rotated in place once it grows
+	// beyond its max size.
+	output, err := log.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = output.WriteString("first line\n")
+	assert.NoError(err)
+	assert.Eventually(func() bool {
+		_, backupErr := os.Stat(path + ".1")
+		info, err := os.Stat(path)
+		return backupErr == nil && err == nil && info.Size() == 0
+	}, 5*time.Second, 10*time.Millisecond, "expected the log file to be rotated")
+	assert.Equal([]string{"first line"}, watched())
+
+	// Output written before the log is closed is forwarded, including a trailing partial line.
+	_, err = output.WriteString("second\nthird")
+	assert.NoError(err)
+	assert.NoError(output.Close())
+	assert.NoError(log.close())
+	assert.Equal([]string{"first line", "second", "third"}, watched())
+
+	retained, err := log.readLines(context.Background(), 0)
+	assert.NoError(err)
+	assert.Equal([]string{"first line", "second", "third"}, retained)
+}
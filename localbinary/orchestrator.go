@@ -6,25 +6,73 @@ package localbinary
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
 	"go.uber.org/zap"
 )
 
-var _ backend.OrchestratorBackend = &orchestrator{}
+// restoreTimeout bounds the time taken to restore the nodes of a network. Nodes that are not restored in time are
+// reported as crashed.
+const restoreTimeout = time.Minute
+
+var (
+	_ backend.OrchestratorBackend = &orchestrator{}
+	_ backend.NetworkAttacher     = &orchestrator{}
+)
 
 type orchestrator struct {
 	orchestratorBaseDir string
 	removeBaseDir       bool
 	registry            backend.ExecutorRegistry
+	nodeLogs            NodeLogsConfig
+	// snapshotDir is the directory that snapshots of networks are saved to
+	snapshotDir string
+	// restore is set if networks persisted under [orchestratorBaseDir] by a previous orchestrator should be restored
+	restore bool
+	// faultInjection is set if the nodes of new networks should connect to each other through link proxies
+	faultInjection bool
+
+	// lock protects [networks] and [restoring]
+	lock sync.Mutex
+	// networks maps the name of each network that has not been torn down to its constructor
+	networks map[string]*networkConstructor
+	// restoring maps the name of each network that is being restored to a channel that is closed once its restore
+	// finishes. Nodes are restored without holding [lock].
+	restoring map[string]chan struct{}
+
+	// ports reserves a range of ports for each network. If the port range is invalid, [portsErr] is returned when
+	// creating a network instead.
+	ports            *utils.PortAllocator
+	portsErr         error
+	networkRangeSize int
 }
 
 type OrchestratorConfig struct {
 	BaseDir           string            `json:"baseDir"`
 	Registry          map[string]string `json:"registry"`
 	DestroyOnTeardown bool              `json:"destroyOnTeardown"`
+	NodeLogs          NodeLogsConfig    `json:"nodeLogs"`
+	// Ports configures the ports allocated to nodes that do not set [http-port] or [staking-port] explicitly
+	Ports utils.PortsConfig `json:"ports"`
+	// Restore restores the networks persisted under BaseDir when the orchestrator is created, so that networks survive
+	// a restart of the orchestrator. Running node processes are adopted and the remaining nodes are restarted from their
+	// data directories.
+	Restore bool `json:"restore"`
+	// SnapshotDir is the directory that snapshots of networks are saved to and restored from. Defaults to
+	// <BaseDir>/snapshots if empty.
+	SnapshotDir string `json:"snapshotDir"`
+	// FaultInjection connects the nodes of each network to each other through link proxies, so that the network can be
+	// partitioned and faults can be injected into the links between its nodes. Nodes must set their NodeID and the
+	// proxies use ports from the range of their network.
+	FaultInjection bool `json:"faultInjection"`
 }
 
 func NewNetworkOrchestratorFromBytes(configBytes []byte) (backend.NetworkOrchestrator, error) {
@@ -38,20 +86,220 @@ func NewNetworkOrchestratorFromBytes(configBytes []byte) (backend.NetworkOrchest
 
 // NewNetworkOrchestrator creates a new orchestator that generates networks using processes started on the local machine
 // If [wipeDir] is true, then the network orchestrator will attempt to wipe the contents of [baseDir] when Teardown is called.
+// The state of each network is persisted under [baseDir], so that it can be restored if [config.Restore] is set.
 func NewNetworkOrchestrator(config *OrchestratorConfig) backend.NetworkOrchestrator {
-	return backend.NewOrchestrator(&orchestrator{
+	ports, portsErr := config.Ports.NewPortAllocator()
+	snapshotDir := config.SnapshotDir
+	if snapshotDir == "" {
+		snapshotDir = filepath.Join(config.BaseDir, "snapshots")
+	}
+	orchestrator := backend.NewOrchestrator(&orchestrator{
 		orchestratorBaseDir: config.BaseDir,
 		removeBaseDir:       config.DestroyOnTeardown,
 		registry:            backend.NewExecutorRegistry(config.Registry),
+		nodeLogs:            config.NodeLogs,
+		snapshotDir:         snapshotDir,
+		restore:             config.Restore,
+		faultInjection:      config.FaultInjection,
+		networks:            make(map[string]*networkConstructor),
+		restoring:           make(map[string]chan struct{}),
+		ports:               ports,
+		portsErr:            portsErr,
+		networkRangeSize:    config.Ports.GetNetworkRangeSize(),
 	})
+	if config.Restore {
+		// Listing the networks attaches every persisted network, which restores it.
+		networks, err := orchestrator.GetNetworks()
+		if err != nil {
+			zap.L().Error("failed to restore networks", zap.String("baseDir", config.BaseDir), zap.Error(err))
+		} else if len(networks) > 0 {
+			zap.L().Info("Restored networks", zap.Int("count", len(networks)))
+		}
+	}
+	return orchestrator
 }
 
 func (o *orchestrator) CreateNetworkConstructor(name string) (backend.NetworkConstructor, error) {
+	if o.portsErr != nil {
+		return nil, o.portsErr
+	}
+	ports, err := o.ports.AllocateRange(o.networkRangeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve ports for network %s: %w", name, err)
+	}
+
 	zap.L().Info("Creating network", zap.String("name", name))
-	constructor := newNetworkConstructor(filepath.Join(o.orchestratorBaseDir, name), o.registry)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if _, restoring := o.restoring[name]; restoring {
+		ports.Close()
+		return nil, fmt.Errorf("cannot create network %s while it is being restored", name)
+	}
+	if _, exists := o.networks[name]; exists {
+		ports.Close()
+		return nil, fmt.Errorf("cannot create duplicate network under name: %s", name)
+	}
+	constructor := o.newNetworkConstructor(name, ports, o.faultInjection)
+	o.networks[name] = constructor
 	return constructor, nil
 }
 
+// newNetworkConstructor returns a constructor for the network [name] that is removed from [networks] once it is torn
+// down.
+func (o *orchestrator) newNetworkConstructor(name string, ports *utils.PortAllocator, faultInjection bool) *networkConstructor {
+	var constructor *networkConstructor
+	constructor = newNetworkConstructor(name, filepath.Join(o.orchestratorBaseDir, name), o.snapshotDir, o.registry, o.nodeLogs, ports, faultInjection, func() {
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		if o.networks[name] == constructor {
+			delete(o.networks, name)
+		}
+	})
+	return constructor
+}
+
+// ListNetworks returns the networks that have not been torn down along with, if networks are restored, every network
+// persisted under the base directory. Persisted networks whose state cannot be read are logged and skipped.
+func (o *orchestrator) ListNetworks(ctx context.Context) ([]string, error) {
+	o.lock.Lock()
+	names := make([]string, 0, len(o.networks))
+	listed := make(map[string]struct{}, len(o.networks)+len(o.restoring))
+	for name := range o.networks {
+		names = append(names, name)
+		listed[name] = struct{}{}
+	}
+	for name := range o.restoring {
+		names = append(names, name)
+		listed[name] = struct{}{}
+	}
+	o.lock.Unlock()
+	if !o.restore {
+		return names, nil
+	}
+
+	entries, err := os.ReadDir(o.orchestratorBaseDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, exists := listed[name]; exists || !entry.IsDir() {
+			continue
+		}
+		networkDir := filepath.Join(o.orchestratorBaseDir, name)
+		if _, err := os.Stat(filepath.Join(networkDir, networkStateFile)); err != nil {
+			continue
+		}
+		if _, err := loadNetworkState(networkDir); err != nil {
+			zap.L().Warn("skipping network with unreadable state", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AttachNetworkConstructor returns the constructor of the network [name] along with its nodes. If networks are
+// restored and the network is not running, it is restored from the state persisted under the base directory. The nodes
+// of the network are restored concurrently without holding [lock], and callers attaching the network meanwhile wait for
+// the restore to finish, as do callers attaching a network that is being restored from a snapshot.
+func (o *orchestrator) AttachNetworkConstructor(ctx context.Context, name string) (backend.NetworkConstructor, []backend.Node, error) {
+	for {
+		o.lock.Lock()
+		if constructor, exists := o.networks[name]; exists {
+			o.lock.Unlock()
+			return constructor, constructor.getNodes(), nil
+		}
+		restored, restoring := o.restoring[name]
+		if !restoring && !o.restore {
+			o.lock.Unlock()
+			return nil, nil, fmt.Errorf("network %s does not exist", name)
+		}
+		if !restoring {
+			restored = make(chan struct{})
+			o.restoring[name] = restored
+			o.lock.Unlock()
+			break
+		}
+		o.lock.Unlock()
+
+		select {
+		case <-restored:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	constructor, nodes, err := o.restoreNetwork(ctx, name)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err == nil {
+		o.networks[name] = constructor
+	}
+	close(o.restoring[name])
+	delete(o.restoring, name)
+	return constructor, nodes, err
+}
+
+// restoreNetwork restores the network [name] from the state persisted under the base directory
+func (o *orchestrator) restoreNetwork(ctx context.Context, name string) (*networkConstructor, []backend.Node, error) {
+	networkDir := filepath.Join(o.orchestratorBaseDir, name)
+	state, err := loadNetworkState(networkDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.portsErr != nil {
+		return nil, nil, o.portsErr
+	}
+	// Keep the port range of the network if possible. The ports of its nodes are reserved regardless, since they are set
+	// in the config of each node.
+	ports, err := o.ports.ReserveRange(state.PortRangeStart, state.PortRangeEnd-state.PortRangeStart)
+	if err != nil {
+		zap.L().Warn("failed to reserve the previous port range of network", zap.String("name", name), zap.Error(err))
+		ports, err = o.ports.AllocateRange(o.networkRangeSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to reserve ports for network %s: %w", name, err)
+		}
+	}
+
+	zap.L().Info("Restoring network", zap.String("name", name), zap.Int("nodes", len(state.Nodes)))
+	ctx, cancel := context.WithTimeout(ctx, restoreTimeout)
+	defer cancel()
+
+	// The network keeps fault injection enabled if it was created with it, since its nodes advertise unreachable IPs.
+	constructor := o.newNetworkConstructor(name, ports, state.FaultInjection)
+	restored := make([]*node, len(state.Nodes))
+	var wg sync.WaitGroup
+	for i := range state.Nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			nodeState := state.Nodes[i]
+			node, err := constructor.restoreNode(ctx, nodeState)
+			if err != nil {
+				zap.L().Error("failed to restore node", zap.String("network", name), zap.String("name", nodeState.Config.Name), zap.Error(err))
+				return
+			}
+			restored[i] = node
+		}(i)
+	}
+	wg.Wait()
+
+	nodes := make([]backend.Node, 0, len(restored))
+	for _, node := range restored {
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return constructor, nodes, nil
+}
+
 func (o *orchestrator) Teardown(ctx context.Context) error {
 	if o.removeBaseDir {
 		return os.RemoveAll(o.orchestratorBaseDir)
@@ -4,12 +4,30 @@
 package localbinary
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
 	"github.com/aaronbuchwald/avalanche-network-runner/e2e"
+	"github.com/aaronbuchwald/avalanche-network-runner/networks"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/genesis"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +51,895 @@ func TestLocalNetworkOrchestrator(t *testing.T) {
 
 	e2e.TestNetworkOrchestrator(ctx, t, orchestrator)
 }
+
+func TestLocalNodeLifecycle(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeLifecycle(ctx, t, orchestrator)
+}
+
+func TestLocalNodeRemoval(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeRemoval(ctx, t, orchestrator)
+}
+
+func TestLocalNodeReadiness(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	e2e.TestNodeReadiness(ctx, t, orchestrator)
+}
+
+func TestLocalTopology(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	// Write the default local network as a topology file, where the staking credentials are read from separate files.
+	dir := t.TempDir()
+	topologyYAML := fmt.Sprintf("version: 1\nname: topology\nexecutable: %s\nconfig:\n", constants.NormalExecution)
+	for key, value := range networks.CreateBasicLocalNodeConfig() {
+		topologyYAML += fmt.Sprintf("  %s: %q\n", key, value)
+	}
+	topologyYAML += "nodes:\n"
+	for i := range constants.LocalNetworkStakerKeys {
+		keyFile := fmt.Sprintf("staker%d.key", i)
+		certFile := fmt.Sprintf("staker%d.crt", i)
+		if err := os.WriteFile(filepath.Join(dir, keyFile), []byte(constants.LocalNetworkStakerKeys[i]), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, certFile), []byte(constants.LocalNetworkStakerCerts[i]), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		topologyYAML += fmt.Sprintf("  - name: node%d\n    stakingKeyFile: %s\n    stakingCertFile: %s\n    config:\n      http-port: %d\n      staking-port: %d\n",
+			i, keyFile, certFile, 9650+i*2, 9651+i*2)
+	}
+	topologyFile := filepath.Join(dir, "topology.yaml")
+	if err := os.WriteFile(topologyFile, []byte(topologyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	topology, err := networks.LoadTopology(topologyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := networks.StartTopology(ctx, orchestrator, topology)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, nodes, len(constants.LocalNetworkStakerKeys))
+	assert.NoError(t, network.Teardown(ctx))
+}
+
+// TestLocalNetworkGeneratedValidators tests that a network with generated staking credentials starts with every node as a validator.
+func TestLocalNetworkGeneratedValidators(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	numNodes := 7
+	network, err := networks.NewLocalNetwork(ctx, orchestrator, constants.NormalExecution, numNodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(t, network.Teardown(ctx), "failed to teardown network")
+	}()
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, nodes, numNodes)
+
+	validators, err := platformvm.NewClient(nodes[0].GetHTTPBaseURI()).GetCurrentValidators(ctx, avagoconstants.PrimaryNetworkID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, validators, numNodes)
+	infoClient := info.NewClient(nodes[0].GetHTTPBaseURI())
+	networkID, err := infoClient.GetNetworkID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(t, networks.CustomNetworkID, networkID)
+}
+
+// TestLocalNetworksSideBySide tests that networks with allocated ports can run concurrently and that a node started
+// with a dynamic HTTP port reports the port it bound.
+func TestLocalNetworksSideBySide(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(2*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	uris := make(map[string]struct{})
+	for i := 0; i < 2; i++ {
+		networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		networkConfig.Nodes[1].Config[config.HTTPPortKey] = 0
+		network, err := networks.NewNetwork(ctx, orchestrator, fmt.Sprintf("network%d", i), networkConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			assert.NoError(t, network.Teardown(ctx), "failed to teardown network")
+		}()
+		if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		nodes, err := network.GetNodes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range nodes {
+			assert.NotContains(t, uris, node.GetHTTPBaseURI())
+			uris[node.GetHTTPBaseURI()] = struct{}{}
+			assert.NotEqual(t, "127.0.0.1:0", node.GetBootstrapIP())
+		}
+	}
+	assert.Len(t, uris, 4)
+}
+
+// detach stops the nodes of [network] from persisting their state, as if the orchestrator running them had exited.
+// The network is torn down once the test completes.
+func detach(t *testing.T, network backend.Network) []*node {
+	t.Cleanup(func() {
+		assert.NoError(t, network.Teardown(context.Background()), "failed to teardown network")
+	})
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	detached := make([]*node, 0, len(nodes))
+	for _, n := range nodes {
+		node := n.(*node)
+		node.lock.Lock()
+		node.store = nil
+		node.lock.Unlock()
+		detached = append(detached, node)
+	}
+	return detached
+}
+
+// TestLocalNetworkRestore tests that a network is restored from the state persisted under the base directory, by
+// adopting the processes of its nodes while they are running and otherwise restarting them from their data directories.
+func TestLocalNetworkRestore(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(3*time.Minute))
+	defer cancel()
+
+	orchestratorConfig := &OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		Restore: true,
+	}
+	newOrchestrator := func() backend.NetworkOrchestrator {
+		orchestrator := NewNetworkOrchestrator(orchestratorConfig)
+		t.Cleanup(func() {
+			assert.NoError(t, orchestrator.Teardown(ctx), "failed to teardown orchestator")
+		})
+		return orchestrator
+	}
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := networks.NewNetwork(ctx, newOrchestrator(), "restore", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	original := detach(t, network)
+
+	// The processes of the nodes are still running, so they are adopted.
+	network, err = newOrchestrator().GetNetwork("restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adopted := detach(t, network)
+	assert.Len(t, adopted, 2)
+	for _, originalNode := range original {
+		restored, err := network.GetNode(originalNode.GetName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, originalNode.process.Pid, restored.(*node).process.Pid)
+		assert.Equal(t, originalNode.GetHTTPBaseURI(), restored.GetHTTPBaseURI())
+		assert.Equal(t, backend.NodeRunning, restored.Status())
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the processes have exited, the nodes are restarted from their data directories on the same ports.
+	for _, originalNode := range original {
+		if err := originalNode.process.Kill(); err != nil {
+			t.Fatal(err)
+		}
+		<-originalNode.Stopped()
+	}
+	network, err = newOrchestrator().GetNetwork("restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, originalNode := range original {
+		restarted, err := network.GetNode(originalNode.GetName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotEqual(t, originalNode.process.Pid, restarted.(*node).process.Pid)
+		assert.Equal(t, originalNode.GetHTTPBaseURI(), restarted.GetHTTPBaseURI())
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// A network that has been torn down is not restored.
+	if err := network.Teardown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	restoredNetworks, err := newOrchestrator().GetNetworks()
+	assert.NoError(t, err)
+	assert.Empty(t, restoredNetworks)
+}
+
+// TestLocalNetworkRestoreConcurrently tests that the nodes of a network are restored concurrently and that a network
+// whose state cannot be read does not prevent the other networks from being restored.
+func TestLocalNetworkRestoreConcurrently(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	baseDir := t.TempDir()
+	orchestratorConfig := &OrchestratorConfig{
+		BaseDir:  baseDir,
+		Registry: map[string]string{"slow": filepath.Join(baseDir, "slow")},
+		Restore:  true,
+	}
+	writeFile(t, filepath.Join(baseDir, "slow"), `#!/bin/sh
+trap 'exit 0' TERM
+sleep 1
+echo 'HTTP API server listening on "127.0.0.1:9650"'
+while true; do sleep 1; done
+`)
+	network, err := NewNetworkOrchestrator(orchestratorConfig).CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numNodes := 3
+	for i := 0; i < numNodes; i++ {
+		if _, err := network.AddNode(ctx, backend.NodeConfig{Name: fmt.Sprintf("node%d", i), Executable: "slow"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Once the processes have exited, the nodes are restarted when the network is restored.
+	for _, original := range detach(t, network) {
+		if err := original.process.Kill(); err != nil {
+			t.Fatal(err)
+		}
+		<-original.Stopped()
+	}
+	writeFile(t, filepath.Join(baseDir, "broken", networkStateFile), "{")
+
+	start := time.Now()
+	orchestrator := NewNetworkOrchestrator(orchestratorConfig)
+	assert.Less(time.Since(start), time.Duration(numNodes-1)*time.Second, "expected the nodes to be restarted concurrently")
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx))
+	}()
+
+	restoredNetworks, err := orchestrator.GetNetworks()
+	assert.NoError(err)
+	if assert.Len(restoredNetworks, 1) {
+		assert.Equal("network", restoredNetworks[0].GetName())
+	}
+	restored, err := orchestrator.GetNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(restored.Teardown(ctx))
+	}()
+	nodes, err := restored.GetNodes()
+	assert.NoError(err)
+	assert.Len(nodes, numNodes)
+	for _, node := range nodes {
+		assert.Equal(backend.NodeRunning, node.Status(), "unexpected status of node %s", node.GetName())
+	}
+	_, err = orchestrator.GetNetwork("broken")
+	assert.Error(err, "expected a network with unreadable state not to be restored")
+}
+
+// orchestratorProcessDirEnv is set to the base directory of the orchestrator run by TestOrchestratorProcess
+const orchestratorProcessDirEnv = "LOCALBINARY_TEST_ORCHESTRATOR_DIR"
+
+// TestOrchestratorProcess is run as a subprocess by TestLocalNetworkRestoreAfterExit. It creates a network with a
+// single node that writes to its output continuously, reports that the node has started and runs until it is
+// interrupted.
+func TestOrchestratorProcess(t *testing.T) {
+	baseDir := os.Getenv(orchestratorProcessDirEnv)
+	if baseDir == "" {
+		t.Skip("run as a subprocess by TestLocalNetworkRestoreAfterExit")
+	}
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:  baseDir,
+		Registry: map[string]string{"ticker": filepath.Join(baseDir, "ticker")},
+		Restore:  true,
+	})
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.AddNode(context.Background(), backend.NodeConfig{Name: "node0", Executable: "ticker"}); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("started")
+	select {}
+}
+
+// TestLocalNetworkRestoreAfterExit tests that the nodes of a network keep running and writing to their logs after the
+// orchestrator that started them is interrupted, and that they are adopted by the next orchestrator.
+func TestLocalNetworkRestoreAfterExit(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	baseDir := t.TempDir()
+	writeFile(t, filepath.Join(baseDir, "ticker"), `#!/bin/sh
+trap 'exit 0' TERM
+echo 'HTTP API server listening on "127.0.0.1:9650"'
+while true; do echo tick; sleep 0.1; done
+`)
+
+	// Run the orchestrator in its own process group and interrupt the group, as a terminal would on Ctrl-C.
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestOrchestratorProcess$")
+	cmd.Env = append(os.Environ(), orchestratorProcessDirEnv+"="+baseDir)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && scanner.Text() != "started" {
+	}
+	if !assert.NoError(syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)) {
+		_ = cmd.Process.Kill()
+	}
+	assert.Error(cmd.Wait(), "expected the orchestrator to be interrupted")
+
+	state, err := loadNetworkState(filepath.Join(baseDir, "network"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(state.Nodes, 1) {
+		return
+	}
+	pid := state.Nodes[0].PID
+	defer func() {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+	}()
+	// The node keeps writing to its log without a reader.
+	time.Sleep(500 * time.Millisecond)
+	assert.True(processMatches(pid, state.Nodes[0].Args), "expected the node to keep running")
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir:  baseDir,
+		Registry: map[string]string{"ticker": filepath.Join(baseDir, "ticker")},
+		Restore:  true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx))
+	}()
+	network, err := orchestrator.GetNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	restored, err := network.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(pid, restored.(*node).process.Pid, "expected the running node to be adopted")
+	assert.Equal(backend.NodeRunning, restored.Status())
+
+	// The output of the adopted node is followed, and includes what it wrote while no orchestrator was running.
+	lines, err := restored.(*node).GetLogs(ctx, backend.Stdout, 0)
+	assert.NoError(err)
+	assert.Greater(len(lines), 5)
+	errTailDone := errors.New("tail done")
+	err = restored.(*node).TailLogs(ctx, backend.Stdout, func(line string) error {
+		return errTailDone
+	})
+	assert.ErrorIs(err, errTailDone)
+}
+
+// TestLocalNetworkSnapshot tests that a snapshot of a network can be restored alongside the network it was taken of, with
+// the config of each node updated to bootstrap from the restored nodes.
+func TestLocalNetworkSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(3*time.Minute))
+	defer cancel()
+
+	baseDir := t.TempDir()
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: baseDir,
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := networks.NewNetwork(ctx, orchestrator, "original", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uris := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		uris[node.GetName()] = node.GetHTTPBaseURI()
+	}
+	diskUsage, err := network.(backend.DiskUsageReporter).DiskUsage(ctx)
+	assert.NoError(err)
+	assert.Positive(diskUsage, "expected the data directories of the nodes to use disk space")
+
+	// The nodes are started again on the same ports once the snapshot has been taken.
+	if err := network.Snapshot(ctx, "snapshot"); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		assert.Equal(backend.NodeRunning, node.Status())
+		assert.Equal(uris[node.GetName()], node.GetHTTPBaseURI())
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := orchestrator.ListSnapshots(ctx)
+	assert.NoError(err)
+	assert.Equal([]string{"snapshot"}, snapshots)
+
+	restored, err := orchestrator.RestoreSnapshot(ctx, "snapshot", "restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(restored.Teardown(ctx))
+	}()
+	if err := e2e.AwaitHealthy(ctx, restored, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	restoredNodes, err := restored.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(restoredNodes, len(nodes))
+	bootstrapIPs := make(map[string]bool, len(restoredNodes))
+	for _, node := range restoredNodes {
+		bootstrapIPs[node.GetBootstrapIP()] = true
+		assert.NotEqual(uris[node.GetName()], node.GetHTTPBaseURI())
+		_, err := os.Stat(filepath.Join(baseDir, "restored", node.GetName(), ".avalanchego", "db"))
+		assert.NoError(err, "expected the database of node %s to be restored", node.GetName())
+	}
+	for _, node := range restoredNodes {
+		if ips, ok := node.Config()[config.BootstrapIPsKey].(string); ok && ips != "" {
+			assert.True(bootstrapIPs[ips], "expected node %s to bootstrap from a restored node, found %s", node.GetName(), ips)
+		}
+	}
+}
+
+func TestLocalNetworkPartition(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(3*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+		FaultInjection:    true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nodeConfig := range networkConfig.Nodes {
+		// Report the nodes unhealthy soon after they stop receiving messages from each other.
+		nodeConfig.Config[config.NetworkHealthMaxTimeSinceMsgReceivedKey] = "3s"
+	}
+	network, err := networks.NewNetwork(ctx, orchestrator, "partitioned", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	if err := e2e.AwaitHealthy(ctx, network, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	node0, err := network.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(node0.GetBootstrapIP(), faultInjectionPublicIP, "expected nodes to advertise an unreachable IP")
+
+	if err := network.Partition(ctx, [][]string{{"node0"}}); err != nil {
+		t.Fatal(err)
+	}
+	client := health.NewClient(node0.GetHTTPBaseURI())
+	for healthy := true; healthy; {
+		select {
+		case <-ctx.Done():
+			t.Fatal("expected node0 to become unhealthy once partitioned")
+		case <-time.After(time.Second):
+		}
+		reply, err := client.Health(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		healthy = reply.Healthy
+	}
+
+	// The connections between the nodes were stalled rather than closed, so they recover once the partition heals.
+	if err := network.Heal(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted node reconnects to its peers through the link proxies.
+	if err := node0.Restart(ctx, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := e2e.AwaitHealthy(ctx, network, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	peers, err := info.NewClient(node0.GetHTTPBaseURI()).Peers(ctx)
+	assert.NoError(err)
+	assert.Len(peers, 1)
+}
+
+const (
+	// subnetVMPathEnvKey is the path to the plugin binary of a VM that TestLocalSubnet deploys to a subnet
+	subnetVMPathEnvKey = "SUBNET_VM_PLUGIN_PATH"
+	// subnetVMGenesisPathEnvKey is the path to the genesis of the blockchain deployed by TestLocalSubnet, which defaults
+	// to the genesis of the C-Chain
+	subnetVMGenesisPathEnvKey = "SUBNET_VM_GENESIS_PATH"
+)
+
+// TestLocalSubnet deploys the VM at $SUBNET_VM_PLUGIN_PATH, ie. a build of subnet-evm or coreth, to a subnet of a two
+// node network.
+func TestLocalSubnet(t *testing.T) {
+	vmPath, exists := os.LookupEnv(subnetVMPathEnvKey)
+	if !exists {
+		t.Skipf("skipping subnet test since $%s is not set", subnetVMPathEnvKey)
+	}
+	vmGenesis := []byte(genesis.LocalConfig.CChainGenesis)
+	if genesisPath, exists := os.LookupEnv(subnetVMGenesisPathEnvKey); exists {
+		b, err := os.ReadFile(genesisPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vmGenesis = b
+	}
+
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(4*time.Minute))
+	defer cancel()
+
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: t.TempDir(),
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+			"subnetevm":               vmPath,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := networks.NewNetwork(ctx, orchestrator, "subnet", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	if err := e2e.AwaitHealthy(ctx, network, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	subnet, err := networks.DeploySubnet(ctx, network, networks.SubnetConfig{
+		Blockchains: []networks.BlockchainConfig{{
+			Name:    "subnetevm",
+			VM:      "subnetevm",
+			Genesis: vmGenesis,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(subnet.Blockchains, 1)
+	blockchain := subnet.Blockchains[0]
+	vmID, err := networks.VMID("subnetevm")
+	assert.NoError(err)
+	assert.Equal(vmID.String(), blockchain.VMID)
+	assert.Len(blockchain.RPCURLs, 2)
+
+	nodes, err := network.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		assert.Contains(node.Config()[config.WhitelistedSubnetsKey], subnet.SubnetID)
+		bootstrapped, err := info.NewClient(node.GetHTTPBaseURI()).IsBootstrapped(ctx, blockchain.BlockchainID)
+		assert.NoError(err)
+		assert.True(bootstrapped, "expected node %s to bootstrap the blockchain", node.GetName())
+	}
+	status, err := platformvm.NewClient(nodes[0].GetHTTPBaseURI()).GetBlockchainStatus(ctx, blockchain.BlockchainID)
+	assert.NoError(err)
+	assert.Equal("Validating", status.String())
+}
+
+// TestLocalNodeChainConfigs tests that the chain configs of a node are written to its chain config directory and that
+// they can be changed when the node is updated.
+func TestLocalNodeChainConfigs(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(3*time.Minute))
+	defer cancel()
+
+	baseDir := t.TempDir()
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: baseDir,
+		Registry: map[string]string{
+			constants.NormalExecution: constants.AvalancheGoBinary,
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx), "failed to teardown orchestator")
+	}()
+
+	networkConfig, err := networks.CreateNetworkConfig(constants.NormalExecution, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The web3 API is enabled by default and disabled by the config of node0.
+	networkConfig.Nodes[0].ChainConfigs = map[string]backend.ChainConfig{
+		"C": {Config: []byte(`{"eth-apis": ["public-eth"]}`)},
+	}
+	network, err := networks.NewNetwork(ctx, orchestrator, "configs", networkConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	if err := e2e.AwaitHealthy(ctx, network, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	chainConfig, err := os.ReadFile(filepath.Join(baseDir, "configs", "node0", chainConfigDir, "C", "config.json"))
+	assert.NoError(err)
+	assert.JSONEq(`{"eth-apis": ["public-eth"]}`, string(chainConfig))
+	node0, err := network.GetNode("node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node1, err := network.GetNode("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(web3Enabled(ctx, t, node0), "expected the chain config of node0 to disable the web3 API")
+	assert.True(web3Enabled(ctx, t, node1))
+
+	node0, err = network.UpdateNode(ctx, "node0", backend.NodeUpdate{
+		ChainConfigs: map[string]backend.ChainConfig{"C": {}},
+	}, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.AwaitReady(ctx, node0, backend.ReadinessConfig{Level: backend.ReadinessBootstrapped, Chains: []string{"C"}}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(baseDir, "configs", "node0", chainConfigDir, "C"))
+	assert.True(os.IsNotExist(err), "expected the removed chain config to be deleted")
+	assert.True(web3Enabled(ctx, t, node0), "expected the removed chain config to enable the web3 API")
+}
+
+// TestLocalNodeUpdateFailure tests that a node is started again on its previous config if its update fails once it has
+// been stopped.
+func TestLocalNodeUpdateFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	baseDir := t.TempDir()
+	writeFile(t, filepath.Join(baseDir, "ticker"), `#!/bin/sh
+trap 'exit 0' TERM
+echo 'HTTP API server listening on "127.0.0.1:9650"'
+while true; do sleep 0.1; done
+`)
+	writeFile(t, filepath.Join(baseDir, "vm"), "vm")
+	orchestrator := NewNetworkOrchestrator(&OrchestratorConfig{
+		BaseDir: filepath.Join(baseDir, "networks"),
+		Registry: map[string]string{
+			"ticker": filepath.Join(baseDir, "ticker"),
+			"vm":     filepath.Join(baseDir, "vm"),
+		},
+		DestroyOnTeardown: true,
+	})
+	defer func() {
+		assert.NoError(orchestrator.Teardown(ctx))
+	}()
+	network, err := orchestrator.CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(network.Teardown(ctx))
+	}()
+	added, err := network.AddNode(ctx, backend.NodeConfig{Name: "node0", Executable: "ticker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := added.(*node).process.Pid
+
+	// The executable has no build directory, so the plugin cannot be installed once the node has been stopped.
+	vmID, err := networks.VMID("vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = network.UpdateNode(ctx, "node0", backend.NodeUpdate{
+		Plugins: map[string]string{vmID.String(): "vm"},
+	}, 10*time.Second)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "failed to install plugins")
+	}
+
+	restarted := added.(*node)
+	assert.Equal(backend.NodeRunning, restarted.Status(), "expected the node to be restarted after the failed update")
+	assert.NotEqual(pid, restarted.process.Pid)
+	assert.Empty(restarted.definition().Plugins, "expected the node to keep its previous config")
+}
+
+// web3Enabled returns true if the C-Chain of [node] serves the web3 API
+func web3Enabled(ctx context.Context, t *testing.T, node backend.Node) bool {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, node.GetHTTPBaseURI()+"/ext/bc/C/rpc",
+		strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "web3_clientVersion", "params": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	return result.Error == nil
+}
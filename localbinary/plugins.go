@@ -0,0 +1,103 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/config"
+)
+
+const (
+	// pluginsDirName is the name of the plugin directory within a build directory of AvalancheGo
+	pluginsDirName = "plugins"
+	// nodeBuildDirName is the name of the build directory created for a node with plugins within the directory of the
+	// node
+	nodeBuildDirName = "build"
+)
+
+// installPlugins creates a build directory for the node in [nodeDir] if it has [plugins], which maps VM IDs to the
+// paths of their binaries, and sets [build-dir] in [nodeConfig] to it. The plugin directory contains a copy of each of
+// [plugins], so that the node is unaffected by later changes to the binaries, along with links to the plugins of the
+// build directory that the node would use otherwise ie. the plugin of the C-Chain.
+func installPlugins(nodeDir string, executable string, nodeConfig map[string]interface{}, plugins map[string]string) error {
+	if len(plugins) == 0 {
+		return nil
+	}
+	sourceDir, err := buildDir(executable, nodeConfig)
+	if err != nil {
+		return err
+	}
+	sourcePlugins, err := os.ReadDir(filepath.Join(sourceDir, pluginsDirName))
+	if err != nil {
+		return err
+	}
+
+	pluginDir := filepath.Join(nodeDir, nodeBuildDirName, pluginsDirName)
+	if err := os.RemoveAll(pluginDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(pluginDir, 0o755); err != nil {
+		return err
+	}
+	for vmID, path := range plugins {
+		if err := installPlugin(path, filepath.Join(pluginDir, vmID)); err != nil {
+			return fmt.Errorf("failed to install plugin %s: %w", vmID, err)
+		}
+	}
+	for _, entry := range sourcePlugins {
+		// AvalancheGo identifies a plugin by its file name without the extension.
+		name := entry.Name()
+		if _, replaced := plugins[strings.TrimSuffix(name, filepath.Ext(name))]; entry.IsDir() || replaced {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(sourceDir, pluginsDirName, name), filepath.Join(pluginDir, name)); err != nil {
+			return err
+		}
+	}
+	// AvalancheGo expands environment variables in the build directory, and $HOME is the directory of the node, so the
+	// build directory moves with the node ie. when a snapshot is restored.
+	nodeConfig[config.BuildDirKey] = filepath.Join("$HOME", nodeBuildDirName)
+	return nil
+}
+
+// buildDir returns the build directory that AvalancheGo uses when it is started from [executable] with [nodeConfig],
+// which is either set in the config or is the directory of the executable or its parent.
+func buildDir(executable string, nodeConfig map[string]interface{}) (string, error) {
+	if dir, ok := nodeConfig[config.BuildDirKey].(string); ok {
+		return dir, nil
+	}
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return "", err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", err
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range []string{filepath.Dir(path), filepath.Dir(filepath.Dir(path))} {
+		if info, err := os.Stat(filepath.Join(dir, pluginsDirName)); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("failed to find the build directory of %s, which can be set with %s in the config of the node", executable, config.BuildDirKey)
+}
+
+// installPlugin copies the plugin binary at [src] to [dst]
+func installPlugin(src string, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return extractFile(file, dst, 0o755)
+}
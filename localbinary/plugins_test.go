@@ -0,0 +1,73 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFile writes [content] to [path], creating its parent directories
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallPlugins(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "avalanchego", "build", "avalanchego")
+	writeFile(t, executable, "avalanchego")
+	writeFile(t, filepath.Join(dir, "avalanchego", "build", pluginsDirName, "evm"), "evm")
+	writeFile(t, filepath.Join(dir, "avalanchego", "build", pluginsDirName, "replaced.so"), "replaced")
+	vm := filepath.Join(dir, "vm")
+	writeFile(t, vm, "vm-v1")
+
+	nodeDir := filepath.Join(dir, "node0")
+	nodeConfig := map[string]interface{}{}
+	assert.NoError(installPlugins(nodeDir, executable, nodeConfig, nil))
+	assert.NotContains(nodeConfig, config.BuildDirKey, "expected a node without plugins to use the default build directory")
+
+	plugins := map[string]string{"vmID": vm, "replaced": vm}
+	if err := installPlugins(nodeDir, executable, nodeConfig, plugins); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(filepath.Join("$HOME", nodeBuildDirName), nodeConfig[config.BuildDirKey])
+
+	pluginDir := filepath.Join(nodeDir, nodeBuildDirName, pluginsDirName)
+	entries, err := os.ReadDir(pluginDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch([]string{"evm", "replaced", "vmID"}, names)
+	evm, err := os.ReadFile(filepath.Join(pluginDir, "evm"))
+	assert.NoError(err)
+	assert.Equal("evm", string(evm))
+
+	// The installed plugins are copies, which are replaced when the plugins are installed again.
+	writeFile(t, vm, "vm-v2")
+	installed, err := os.ReadFile(filepath.Join(pluginDir, "vmID"))
+	assert.NoError(err)
+	assert.Equal("vm-v1", string(installed))
+	assert.NoError(installPlugins(nodeDir, executable, map[string]interface{}{}, plugins))
+	installed, err = os.ReadFile(filepath.Join(pluginDir, "vmID"))
+	assert.NoError(err)
+	assert.Equal("vm-v2", string(installed))
+
+	_, err = buildDir(filepath.Join(dir, "vm"), map[string]interface{}{})
+	assert.Error(err, "expected an executable without a plugin directory to have no build directory")
+}
@@ -5,6 +5,7 @@ package runner
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
@@ -18,6 +19,7 @@ const (
 	logLevelKey              = "log-level"
 	avalanchegoBinaryPathKey = "avalanchego-binary-path"
 	cleanDataDirKey          = "clean-data-directory"
+	executablesKey           = "executables"
 )
 
 func buildFlagSet() *flag.FlagSet {
@@ -30,6 +32,7 @@ func addLocalBinaryFlags(fs *flag.FlagSet) {
 	fs.String(dataDirectoryKey, constants.BaseDataDir, "This flag sets the data directory where the Avalanche Network Runner stores network data.")
 	fs.String(logLevelKey, zapcore.InfoLevel.String(), "Sets the log level of the Avalanche Network Runner.")
 	fs.String(avalanchegoBinaryPathKey, constants.AvalancheGoBinary, "Sets the path to the AvalancheGo binary.")
+	fs.String(executablesKey, "", "Comma separated list of additional AvalancheGo binaries to register as name=path ie. v1.7.9=/path/to/avalanchego, which the network callback can run nodes with.")
 	fs.Bool(cleanDataDirKey, false, "If enabled, the data directory will be wiped after the network runner exits.")
 }
 
@@ -57,3 +60,26 @@ func buildViper(fs *flag.FlagSet, args []string) (*viper.Viper, error) {
 	}
 	return v, nil
 }
+
+// parseExecutables parses the comma separated name=path pairs of [executables] into the registry of the runner
+// along with [avalanchegoBinaryPath], which is registered as constants.NormalExecution.
+func parseExecutables(avalanchegoBinaryPath string, executables string) (map[string]string, error) {
+	registry := map[string]string{
+		constants.NormalExecution: avalanchegoBinaryPath,
+	}
+	if executables == "" {
+		return registry, nil
+	}
+	for _, executable := range strings.Split(executables, ",") {
+		parts := strings.SplitN(executable, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid executable %q, expected name=path", executable)
+		}
+		name, path := parts[0], parts[1]
+		if _, exists := registry[name]; exists {
+			return nil, fmt.Errorf("cannot register %s under name %q, which is already registered", path, name)
+		}
+		registry[name] = path
+	}
+	return registry, nil
+}
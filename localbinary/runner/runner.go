@@ -34,11 +34,13 @@ func RunNetwork(ctx context.Context, args []string, networkCallback func(backend
 	}
 	log.SetGlobalLogLevel(level)
 
+	registry, err := parseExecutables(v.GetString(avalanchegoBinaryPathKey), v.GetString(executablesKey))
+	if err != nil {
+		return err
+	}
 	orchestrator := localbinary.NewNetworkOrchestrator(&localbinary.OrchestratorConfig{
-		BaseDir: v.GetString(dataDirectoryKey),
-		Registry: map[string]string{
-			constants.NormalExecution: v.GetString(avalanchegoBinaryPathKey),
-		},
+		BaseDir:           v.GetString(dataDirectoryKey),
+		Registry:          registry,
 		DestroyOnTeardown: v.GetBool(cleanDataDirKey),
 	})
 	network, err := networks.NewDefaultLocalNetwork(ctx, orchestrator, constants.NormalExecution)
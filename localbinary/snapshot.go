@@ -0,0 +1,556 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/ava-labs/avalanchego/config"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// snapshotExtension is the extension of the archive each snapshot is saved to under the snapshot directory
+	snapshotExtension = ".tar.gz"
+	// snapshotManifest is the first entry of every snapshot archive, which describes the saved network
+	snapshotManifest = "snapshot.json"
+	// snapshotStopTimeout is the time to wait for each node to stop gracefully before it is killed to take a snapshot
+	snapshotStopTimeout = 10 * time.Second
+)
+
+var (
+	_ backend.Snapshotter      = &networkConstructor{}
+	_ backend.SnapshotRestorer = &orchestrator{}
+
+	// nodeLogFileRegex matches the files that the output of a node is captured to, which are not part of its state.
+	nodeLogFileRegex = regexp.MustCompile(`^std(out|err)\.log(\.\d+)?$`)
+)
+
+// networkSnapshot describes the network saved in a snapshot archive. The data directory of each node is archived under
+// the name of the node.
+type networkSnapshot struct {
+	Network string         `json:"network"`
+	Created time.Time      `json:"created"`
+	Nodes   []snapshotNode `json:"nodes"`
+}
+
+// snapshotNode is a node saved in a snapshot
+type snapshotNode struct {
+	// Config is the config the node was added with, which does not include the ports allocated to it
+	Config backend.NodeConfig `json:"config"`
+	// BootstrapIP is the address the node was reachable at by its peers, which is replaced in the config of the other
+	// nodes when the snapshot is restored.
+	BootstrapIP string `json:"bootstrapIP"`
+	Status      string `json:"status"`
+}
+
+// snapshotPath returns the path of the snapshot [name] under [snapshotDir]
+func snapshotPath(snapshotDir string, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(snapshotDir, name+snapshotExtension), nil
+}
+
+// listSnapshots returns the names of the snapshots saved under [snapshotDir]
+func listSnapshots(snapshotDir string) ([]string, error) {
+	entries, err := os.ReadDir(snapshotDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, snapshotExtension) {
+			names = append(names, strings.TrimSuffix(name, snapshotExtension))
+		}
+	}
+	return names, nil
+}
+
+// Snapshot stops every node in the network, archives its config and data directory to the snapshot [name], and starts
+// the nodes that were running again.
+func (c *networkConstructor) Snapshot(ctx context.Context, name string) error {
+	path, err := snapshotPath(c.snapshotDir, name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	c.lock.Lock()
+	if c.tornDown {
+		c.lock.Unlock()
+		return fmt.Errorf("cannot snapshot torn down network: %s", c.name)
+	}
+	nodes := make([]*node, 0, len(c.nodes))
+	for nodeName, node := range c.nodes {
+		if node == nil {
+			c.lock.Unlock()
+			return fmt.Errorf("cannot snapshot network %s while node %s is being added", c.name, nodeName)
+		}
+		nodes = append(nodes, node)
+	}
+	c.lock.Unlock()
+
+	zap.L().Info("Taking snapshot", zap.String("network", c.name), zap.String("snapshot", name))
+	snapshot := &networkSnapshot{
+		Network: c.name,
+		Created: time.Now(),
+		Nodes:   make([]snapshotNode, 0, len(nodes)),
+	}
+	statuses := make([]backend.NodeStatus, len(nodes))
+	eg := errgroup.Group{}
+	for i, node := range nodes {
+		node := node
+		statuses[i] = node.Status()
+		snapshot.Nodes = append(snapshot.Nodes, snapshotNode{
+			Config:      node.config,
+			BootstrapIP: node.GetBootstrapIP(),
+			Status:      statuses[i].String(),
+		})
+		eg.Go(func() error {
+			return node.halt(ctx, snapshotStopTimeout)
+		})
+	}
+	err = eg.Wait()
+	if err == nil {
+		err = writeSnapshot(path, snapshot, c.networkBaseDir)
+	}
+
+	// Start the nodes that were running again regardless of whether the snapshot was saved.
+	eg = errgroup.Group{}
+	for i, node := range nodes {
+		node, status := node, statuses[i]
+		if status == backend.NodeStopped || status == backend.NodeCrashed {
+			continue
+		}
+		eg.Go(func() error {
+			if err := node.start(ctx); err != nil {
+				return err
+			}
+			if status == backend.NodePaused {
+				return node.Pause()
+			}
+			return nil
+		})
+	}
+	if startErr := eg.Wait(); startErr != nil {
+		if err != nil {
+			return fmt.Errorf("failed to save snapshot %s: %w", name, err)
+		}
+		return fmt.Errorf("failed to start nodes after saving snapshot %s: %w", name, startErr)
+	}
+	return err
+}
+
+// restoreSnapshot extracts the snapshot saved at [path] into the directory of the network and recreates its nodes,
+// which are started again unless they had crashed. The ports of the nodes are allocated from the range of the network,
+// so the bootstrap IPs in the config of each node are replaced with the new addresses of its peers.
+func (c *networkConstructor) restoreSnapshot(ctx context.Context, path string) ([]backend.Node, error) {
+	snapshot, err := extractSnapshot(path, c.networkBaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*node, 0, len(snapshot.Nodes))
+	abort := func(err error) ([]backend.Node, error) {
+		c.abort(nodes)
+		return nil, err
+	}
+
+	// Reserve the ports of every node before creating any of them, so that the new address of each peer is known.
+	nodeConfigs := make([]map[string]interface{}, len(snapshot.Nodes))
+	ports := make([]nodePorts, len(snapshot.Nodes))
+	bootstrapIPs := make(map[string]string, len(snapshot.Nodes))
+	for i, snapshotNode := range snapshot.Nodes {
+		name := snapshotNode.Config.Name
+		if err := c.claim(name); err != nil {
+			return abort(err)
+		}
+		nodeConfigs[i], err = c.localNodeConfig(snapshotNode.Config)
+		if err != nil {
+			return abort(err)
+		}
+		ports[i], err = c.reservePorts(name, nodeConfigs[i])
+		if err != nil {
+			return abort(err)
+		}
+		if snapshotNode.BootstrapIP != "" && ports[i].stakingPort != 0 {
+			bootstrapIPs[snapshotNode.BootstrapIP] = fmt.Sprintf("127.0.0.1:%d", ports[i].stakingPort)
+		}
+	}
+
+	for i, snapshotNode := range snapshot.Nodes {
+		nodeDef := snapshotNode.Config
+		nodeDef.Config = backend.CopyConfig(nodeDef.Config)
+		replaceBootstrapIPs(nodeDef.Config, bootstrapIPs)
+		replaceBootstrapIPs(nodeConfigs[i], bootstrapIPs)
+		node, err := c.createNode(nodeDef, nodeConfigs[i], ports[i])
+		if err != nil {
+			return abort(err)
+		}
+		nodes = append(nodes, node)
+		c.track(node)
+	}
+
+	eg := errgroup.Group{}
+	for i, node := range nodes {
+		node := node
+		status, err := backend.ParseNodeStatus(snapshot.Nodes[i].Status)
+		if err != nil {
+			return abort(fmt.Errorf("failed to restore node %s: %w", node.config.Name, err))
+		}
+		if status == backend.NodeStopped || status == backend.NodeCrashed {
+			node.restoreCrashed(nil)
+			continue
+		}
+		eg.Go(func() error {
+			if err := node.start(ctx); err != nil {
+				return err
+			}
+			if status == backend.NodePaused {
+				return node.Pause()
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return abort(err)
+	}
+
+	restored := make([]backend.Node, 0, len(nodes))
+	for _, node := range nodes {
+		restored = append(restored, node)
+	}
+	return restored, nil
+}
+
+// abort stops [nodes] after the network failed to be created and removes its persisted state. The ports of the network
+// are released when its range is closed.
+func (c *networkConstructor) abort(nodes []*node) {
+	c.lock.Lock()
+	c.tornDown = true
+	c.lock.Unlock()
+
+	for _, node := range nodes {
+		if err := node.Stop(snapshotStopTimeout); err != nil {
+			zap.L().Warn("failed to stop node", zap.String("name", node.config.Name), zap.Error(err))
+		}
+	}
+	if err := removeNetworkState(c.networkBaseDir); err != nil {
+		zap.L().Warn("failed to remove network state", zap.String("network", c.name), zap.Error(err))
+	}
+}
+
+// replaceBootstrapIPs replaces each of the bootstrap IPs in [nodeConfig] that is a key of [bootstrapIPs] with its value
+func replaceBootstrapIPs(nodeConfig map[string]interface{}, bootstrapIPs map[string]string) {
+	ips, ok := nodeConfig[config.BootstrapIPsKey].(string)
+	if !ok || ips == "" {
+		return
+	}
+	replaced := strings.Split(ips, ",")
+	for i, ip := range replaced {
+		if newIP, ok := bootstrapIPs[strings.TrimSpace(ip)]; ok {
+			replaced[i] = newIP
+		}
+	}
+	nodeConfig[config.BootstrapIPsKey] = strings.Join(replaced, ",")
+}
+
+// writeSnapshot archives [snapshot] along with the data directory of each of its nodes under [networkBaseDir] to
+// [path]. The archive is written to a temporary file first, so that a partial archive is never listed as a snapshot.
+func writeSnapshot(path string, snapshot *networkSnapshot, networkBaseDir string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := writeSnapshotArchive(tmpPath, snapshot, networkBaseDir); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func writeSnapshotArchive(path string, snapshot *networkSnapshot, networkBaseDir string) error {
+	manifest, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    snapshotManifest,
+		Mode:    0o644,
+		Size:    int64(len(manifest)),
+		ModTime: snapshot.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(manifest); err != nil {
+		return err
+	}
+	for _, node := range snapshot.Nodes {
+		if err := archiveNodeDir(tarWriter, filepath.Join(networkBaseDir, node.Config.Name), node.Config.Name); err != nil {
+			return fmt.Errorf("failed to archive data of node %s: %w", node.Config.Name, err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// archiveNodeDir writes the directories and regular files under [nodeDir] to [tarWriter] under [prefix], except for the
+// files that the output of the node is captured to.
+func archiveNodeDir(tarWriter *tar.Writer, nodeDir string, prefix string) error {
+	return filepath.WalkDir(nodeDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(nodeDir, path)
+		if err != nil {
+			return err
+		}
+		if nodeLogFileRegex.MatchString(rel) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+}
+
+// openSnapshot opens the snapshot archive at [path] and reads its manifest. The returned reader is positioned after the
+// manifest and must be closed by the caller.
+func openSnapshot(path string) (*networkSnapshot, *tar.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, fmt.Errorf("snapshot %s does not exist", strings.TrimSuffix(filepath.Base(path), snapshotExtension))
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	header, err := tarReader.Next()
+	if err == nil && header.Name != snapshotManifest {
+		err = fmt.Errorf("expected %s as the first entry, found %s", snapshotManifest, header.Name)
+	}
+	snapshot := new(networkSnapshot)
+	if err == nil {
+		err = json.NewDecoder(tarReader).Decode(snapshot)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	return snapshot, tarReader, file, nil
+}
+
+// readSnapshot returns the manifest of the snapshot archive at [path]
+func readSnapshot(path string) (*networkSnapshot, error) {
+	snapshot, _, closer, err := openSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	_ = closer.Close()
+	return snapshot, nil
+}
+
+// extractSnapshot extracts the data directory of each node saved in the snapshot archive at [path] into
+// [networkBaseDir] and returns the manifest of the snapshot. Any data left in the directory of a node by a previous
+// network with the same name is removed first.
+func extractSnapshot(path string, networkBaseDir string) (*networkSnapshot, error) {
+	snapshot, tarReader, closer, err := openSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	nodes := make(map[string]struct{}, len(snapshot.Nodes))
+	for _, node := range snapshot.Nodes {
+		name := node.Config.Name
+		if _, err := snapshotPath("", name); err != nil {
+			return nil, fmt.Errorf("invalid node name %q in snapshot", name)
+		}
+		nodes[name] = struct{}{}
+		if err := os.RemoveAll(filepath.Join(networkBaseDir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+		}
+		// Only extract entries under the directory of one of the saved nodes.
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		nodeName := strings.SplitN(name, string(filepath.Separator), 2)[0]
+		if _, ok := nodes[nodeName]; !ok || filepath.IsAbs(name) {
+			return nil, fmt.Errorf("invalid entry %s in snapshot %s", header.Name, path)
+		}
+		target := filepath.Join(networkBaseDir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tarReader, target, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// extractFile writes the contents of [reader] to a new file at [path] with [mode]
+func extractFile(reader io.Reader, path string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ListSnapshots returns the names of the snapshots saved under the snapshot directory
+func (o *orchestrator) ListSnapshots(ctx context.Context) ([]string, error) {
+	return listSnapshots(o.snapshotDir)
+}
+
+// RestoreSnapshotConstructor recreates the network saved in [snapshot] under the name [name], or under the name of the
+// saved network if [name] is empty.
+func (o *orchestrator) RestoreSnapshotConstructor(ctx context.Context, snapshot string, name string) (string, backend.NetworkConstructor, []backend.Node, error) {
+	path, err := snapshotPath(o.snapshotDir, snapshot)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	savedSnapshot, err := readSnapshot(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if name == "" {
+		name = savedSnapshot.Network
+	}
+	if o.portsErr != nil {
+		return "", nil, nil, o.portsErr
+	}
+
+	// Reserve the name while the snapshot is restored, rather than holding the lock while the nodes are started.
+	o.lock.Lock()
+	_, exists := o.networks[name]
+	_, restoring := o.restoring[name]
+	if exists || restoring {
+		o.lock.Unlock()
+		return "", nil, nil, fmt.Errorf("cannot create duplicate network under name: %s", name)
+	}
+	networkDir := filepath.Join(o.orchestratorBaseDir, name)
+	if _, err := os.Stat(filepath.Join(networkDir, networkStateFile)); o.restore && err == nil {
+		o.lock.Unlock()
+		return "", nil, nil, fmt.Errorf("cannot create duplicate network under name: %s", name)
+	}
+	restored := make(chan struct{})
+	o.restoring[name] = restored
+	o.lock.Unlock()
+
+	constructor, nodes, err := o.restoreSnapshot(ctx, snapshot, path, name)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if err == nil {
+		o.networks[name] = constructor
+	}
+	close(restored)
+	delete(o.restoring, name)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return name, constructor, nodes, nil
+}
+
+// restoreSnapshot restores the snapshot [snapshot] saved at [path] as the network [name]
+func (o *orchestrator) restoreSnapshot(ctx context.Context, snapshot string, path string, name string) (*networkConstructor, []backend.Node, error) {
+	ports, err := o.ports.AllocateRange(o.networkRangeSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reserve ports for network %s: %w", name, err)
+	}
+
+	zap.L().Info("Restoring snapshot", zap.String("snapshot", snapshot), zap.String("network", name))
+	constructor := o.newNetworkConstructor(name, ports, o.faultInjection)
+	nodes, err := constructor.restoreSnapshot(ctx, path)
+	if err != nil {
+		ports.Close()
+		return nil, nil, err
+	}
+	return constructor, nodes, nil
+}
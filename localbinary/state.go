@@ -0,0 +1,121 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package localbinary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+)
+
+// networkStateFile is the name of the file under the directory of each network that its state is persisted to
+const networkStateFile = "network.json"
+
+// networkState is the state of a network that is persisted to <networkDir>/network.json each time one of its nodes
+// changes, so that the network can be restored if the orchestrator is restarted.
+type networkState struct {
+	Name string `json:"name"`
+	// PortRangeStart and PortRangeEnd bound the range of ports reserved for the network
+	PortRangeStart int `json:"portRangeStart"`
+	PortRangeEnd   int `json:"portRangeEnd"`
+	// FaultInjection is set if the nodes of the network connect to each other through link proxies
+	FaultInjection bool        `json:"faultInjection,omitempty"`
+	Nodes          []nodeState `json:"nodes"`
+}
+
+// nodeState is the persisted state of a node, which contains everything needed to restart its process
+type nodeState struct {
+	Config     backend.NodeConfig `json:"config"`
+	Executable string             `json:"executable"`
+	// Checksum is the checksum that the executable was registered with, which is verified each time the node starts
+	Checksum string   `json:"checksum,omitempty"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env"`
+	// HTTPPort and StakingPort are the ports set in the config of the node, which are 0 if the node binds any free port
+	HTTPPort    int    `json:"httpPort"`
+	StakingPort int    `json:"stakingPort"`
+	Reserved    []int  `json:"reserved"`
+	HTTPBaseURI string `json:"httpBaseURI"`
+	BootstrapIP string `json:"bootstrapIP"`
+	// PID is the process ID of the last process started for the node, or 0 if it has not been started
+	PID    int    `json:"pid"`
+	Status string `json:"status"`
+}
+
+// nodeStore persists the state of the nodes of a network
+type nodeStore interface {
+	// save persists [state] as the current state of its node
+	save(state nodeState)
+	// remove removes the node [name] once it has been stopped, so that it is not restored
+	remove(name string)
+}
+
+// loadNetworkState reads the state persisted to [networkDir]
+func loadNetworkState(networkDir string) (*networkState, error) {
+	b, err := os.ReadFile(filepath.Join(networkDir, networkStateFile))
+	if err != nil {
+		return nil, err
+	}
+	state := new(networkState)
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state of network in %s: %w", networkDir, err)
+	}
+	return state, nil
+}
+
+// saveNetworkState writes [state] to [networkDir]. The state is written to a temporary file first, so that a crash
+// part way through never leaves a truncated state file behind.
+func saveNetworkState(networkDir string, state *networkState) error {
+	sort.Slice(state.Nodes, func(i, j int) bool { return state.Nodes[i].Config.Name < state.Nodes[j].Config.Name })
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(networkDir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(networkDir, networkStateFile)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// removeNetworkState removes the state persisted to [networkDir], so that the network is not restored
+func removeNetworkState(networkDir string) error {
+	err := os.Remove(filepath.Join(networkDir, networkStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// processMatches returns true if the command line of the process [pid] ends with [args], which contain the config of
+// the node, so that a process ID that has been reused by an unrelated process is never adopted. The executable is not
+// compared, since it may be a wrapper that execs avalanchego with additional flags. Always returns false where /proc is
+// not available.
+func processMatches(pid int, args []string) bool {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || len(args) == 0 {
+		return false
+	}
+	cmdline := strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00")
+	if len(cmdline) < len(args) {
+		return false
+	}
+	cmdline = cmdline[len(cmdline)-len(args):]
+	for i := range args {
+		if cmdline[i] != args[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/avalanchego/utils/units"
+)
+
+const (
+	// CustomNetworkID is the network ID used by networks with a generated genesis, since AvalancheGo does not allow
+	// the genesis of the standard networks to be overridden.
+	CustomNetworkID = 1337
+
+	DefaultValidatorWeight     = 2 * units.KiloAvax
+	DefaultStakeDuration       = 365 * 24 * time.Hour
+	DefaultStakeDurationOffset = 90 * time.Minute
+	DefaultDelegationFee       = 20_000 // 2%
+
+	// zeroETHAddr is the ETH address recorded for X-Chain and P-Chain allocations, which only use it as a memo
+	zeroETHAddr = "0x0000000000000000000000000000000000000000"
+)
+
+var (
+	errNoValidators      = errors.New("genesis requires at least one validator")
+	errNoValidatorWeight = errors.New("genesis requires a non-zero validator weight")
+	errNoStakeDuration   = errors.New("genesis requires a non-zero stake duration")
+)
+
+// GenesisConfig describes the genesis of a custom network.
+//
+// Note: AvalancheGo splits the initially staked funds evenly between the initial validators and staggers the end of
+// their staking periods by a fixed offset. By default, every validator stakes ValidatorWeight and the i-th validator
+// stops validating StakeDuration - i*StakeDurationOffset after StartTime. A validator may override its weight and stake
+// duration, but Build rejects a config unless every validator stakes the same weight and the stake durations differ by
+// a fixed offset once they are ordered from longest to shortest.
+type GenesisConfig struct {
+	NetworkID uint32
+	// StartTime is the genesis timestamp and the time at which the initial validators start validating.
+	// It must not be in the future.
+	StartTime time.Time
+
+	Validators          []GenesisValidator
+	ValidatorWeight     uint64
+	StakeDuration       time.Duration
+	StakeDurationOffset time.Duration
+	// StakingAddress owns the funds staked by the initial validators. It cannot be given a P-Chain allocation,
+	// since AvalancheGo stakes every P-Chain allocation of an initial staking address.
+	StakingAddress string
+
+	// XChainAllocations and PChainAllocations are funded in nAVAX
+	XChainAllocations []GenesisAllocation
+	PChainAllocations []GenesisAllocation
+	// CChainAllocations are funded in wei
+	CChainAllocations []CChainAllocation
+	// CChainGenesis is the genesis JSON of the C-Chain, to which CChainAllocations are added.
+	CChainGenesis string
+
+	Message string
+}
+
+// GenesisValidator is an initial validator of the primary network.
+type GenesisValidator struct {
+	NodeID        string
+	RewardAddress string
+	// DelegationFee is the fee charged to delegators in units of 1/10,000th of a percent
+	DelegationFee uint32
+	// Weight overrides the ValidatorWeight of the genesis if non-zero
+	Weight uint64
+	// StakeDuration overrides the stake duration derived from the position of the validator if non-zero
+	StakeDuration time.Duration
+}
+
+// GenesisAllocation funds the X-Chain or P-Chain address [Address] with [Amount] nAVAX.
+type GenesisAllocation struct {
+	Address string
+	Amount  uint64
+}
+
+// CChainAllocation funds the hex encoded C-Chain address [Address] with [Balance] wei.
+type CChainAllocation struct {
+	Address string
+	Balance *big.Int
+}
+
+// NewGenesisConfig returns a config for a genesis of [networkID] where [nodeIDs] are the initial validators. The
+// staking address and the allocations match the built-in local genesis, so that the well known local keys are funded.
+func NewGenesisConfig(networkID uint32, nodeIDs []string) (*GenesisConfig, error) {
+	hrp := avagoconstants.GetHRP(networkID)
+	ewoqAddress, err := formatting.FormatAddress("X", hrp, genesis.EWOQKey.PublicKey().Address().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	vmrqAddress, err := formatting.FormatAddress("X", hrp, genesis.VMRQKey.PublicKey().Address().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make([]GenesisValidator, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		validators = append(validators, GenesisValidator{
+			NodeID:        nodeID,
+			RewardAddress: ewoqAddress,
+			DelegationFee: DefaultDelegationFee,
+		})
+	}
+
+	return &GenesisConfig{
+		NetworkID:           networkID,
+		StartTime:           time.Now(),
+		Validators:          validators,
+		ValidatorWeight:     DefaultValidatorWeight,
+		StakeDuration:       DefaultStakeDuration,
+		StakeDurationOffset: DefaultStakeDurationOffset,
+		StakingAddress:      vmrqAddress,
+		XChainAllocations: []GenesisAllocation{
+			{Address: ewoqAddress, Amount: 300 * units.MegaAvax},
+		},
+		PChainAllocations: []GenesisAllocation{
+			{Address: ewoqAddress, Amount: 30 * units.MegaAvax},
+		},
+		CChainGenesis: genesis.LocalConfig.CChainGenesis,
+	}, nil
+}
+
+// Build returns the genesis described by [c] in the format expected by [genesis-content]. The genesis is deterministic
+// for a given config.
+func (c *GenesisConfig) Build() ([]byte, error) {
+	switch c.NetworkID {
+	case avagoconstants.MainnetID, avagoconstants.FujiID, avagoconstants.LocalID:
+		return nil, fmt.Errorf("cannot override the genesis of the standard network %s", avagoconstants.NetworkName(c.NetworkID))
+	}
+	if len(c.Validators) == 0 {
+		return nil, errNoValidators
+	}
+	if c.StartTime.After(time.Now()) {
+		return nil, fmt.Errorf("genesis start time %s cannot be in the future", c.StartTime)
+	}
+
+	hrp := avagoconstants.GetHRP(c.NetworkID)
+	stakingAddress, err := formatGenesisAddress(hrp, c.StakingAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid staking address: %w", err)
+	}
+
+	weight, err := c.validatorWeight()
+	if err != nil {
+		return nil, err
+	}
+	validators, stakeDuration, stakeDurationOffset, err := c.stakingPeriods()
+	if err != nil {
+		return nil, err
+	}
+
+	unparsedConfig := genesis.UnparsedConfig{
+		NetworkID:                  c.NetworkID,
+		StartTime:                  uint64(c.StartTime.Unix()),
+		InitialStakeDuration:       uint64(stakeDuration / time.Second),
+		InitialStakeDurationOffset: uint64(stakeDurationOffset / time.Second),
+		InitialStakedFunds:         []string{stakingAddress},
+		Message:                    c.Message,
+	}
+
+	totalStake := weight * uint64(len(c.Validators))
+	if totalStake/uint64(len(c.Validators)) != weight {
+		return nil, fmt.Errorf("total stake of %d validators with weight %d overflows", len(c.Validators), weight)
+	}
+	unparsedConfig.Allocations = append(unparsedConfig.Allocations, genesis.UnparsedAllocation{
+		ETHAddr:        zeroETHAddr,
+		AVAXAddr:       stakingAddress,
+		UnlockSchedule: []genesis.LockedAmount{{Amount: totalStake}},
+	})
+	for _, allocation := range c.XChainAllocations {
+		address, err := formatGenesisAddress(hrp, allocation.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid X-Chain allocation: %w", err)
+		}
+		unparsedConfig.Allocations = append(unparsedConfig.Allocations, genesis.UnparsedAllocation{
+			ETHAddr:       zeroETHAddr,
+			AVAXAddr:      address,
+			InitialAmount: allocation.Amount,
+		})
+	}
+	for _, allocation := range c.PChainAllocations {
+		address, err := formatGenesisAddress(hrp, allocation.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid P-Chain allocation: %w", err)
+		}
+		if address == stakingAddress {
+			return nil, fmt.Errorf("cannot allocate P-Chain funds to the staking address %s", address)
+		}
+		unparsedConfig.Allocations = append(unparsedConfig.Allocations, genesis.UnparsedAllocation{
+			ETHAddr:        zeroETHAddr,
+			AVAXAddr:       address,
+			UnlockSchedule: []genesis.LockedAmount{{Amount: allocation.Amount}},
+		})
+	}
+
+	for _, validator := range validators {
+		rewardAddress, err := formatGenesisAddress(hrp, validator.RewardAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reward address of validator %s: %w", validator.NodeID, err)
+		}
+		if _, err := ids.ShortFromPrefixedString(validator.NodeID, avagoconstants.NodeIDPrefix); err != nil {
+			return nil, fmt.Errorf("invalid validator %s: %w", validator.NodeID, err)
+		}
+		unparsedConfig.InitialStakers = append(unparsedConfig.InitialStakers, genesis.UnparsedStaker{
+			NodeID:        validator.NodeID,
+			RewardAddress: rewardAddress,
+			DelegationFee: validator.DelegationFee,
+		})
+	}
+
+	unparsedConfig.CChainGenesis, err = addCChainAllocations(c.CChainGenesis, c.CChainAllocations)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisBytes, err := json.Marshal(unparsedConfig)
+	if err != nil {
+		return nil, err
+	}
+	// Ensure that AvalancheGo will accept the genesis, so that an invalid genesis is reported before any node is started.
+	if _, _, err := genesis.FromFlag(c.NetworkID, base64.StdEncoding.EncodeToString(genesisBytes)); err != nil {
+		return nil, err
+	}
+	return genesisBytes, nil
+}
+
+// validatorWeight returns the weight staked by every validator of the genesis, since AvalancheGo splits the staked funds
+// evenly between the initial validators.
+func (c *GenesisConfig) validatorWeight() (uint64, error) {
+	weight := uint64(0)
+	for i, validator := range c.Validators {
+		validatorWeight := validator.Weight
+		if validatorWeight == 0 {
+			validatorWeight = c.ValidatorWeight
+		}
+		if validatorWeight == 0 {
+			return 0, errNoValidatorWeight
+		}
+		if i > 0 && validatorWeight != weight {
+			return 0, fmt.Errorf("validator %s stakes %d, but the genesis requires every validator to stake the same weight %d", validator.NodeID, validatorWeight, weight)
+		}
+		weight = validatorWeight
+	}
+	return weight, nil
+}
+
+// stakingPeriods returns the validators of the genesis ordered from the longest stake duration to the shortest along
+// with the initial stake duration and offset that AvalancheGo uses to derive the stake duration of each validator from
+// its position.
+func (c *GenesisConfig) stakingPeriods() ([]GenesisValidator, time.Duration, time.Duration, error) {
+	validators := make([]GenesisValidator, len(c.Validators))
+	copy(validators, c.Validators)
+	for i := range validators {
+		if validators[i].StakeDuration == 0 {
+			validators[i].StakeDuration = c.StakeDuration - time.Duration(i)*c.StakeDurationOffset
+		}
+		// AvalancheGo specifies stake durations in seconds.
+		validators[i].StakeDuration = validators[i].StakeDuration.Truncate(time.Second)
+		if validators[i].StakeDuration <= 0 {
+			return nil, 0, 0, fmt.Errorf("%w: validator %s has stake duration %s", errNoStakeDuration, validators[i].NodeID, validators[i].StakeDuration)
+		}
+	}
+	sort.SliceStable(validators, func(i, j int) bool {
+		return validators[i].StakeDuration > validators[j].StakeDuration
+	})
+
+	offset := time.Duration(0)
+	if len(validators) > 1 {
+		offset = validators[0].StakeDuration - validators[1].StakeDuration
+	}
+	for i := 1; i < len(validators); i++ {
+		if validators[i-1].StakeDuration-validators[i].StakeDuration != offset {
+			return nil, 0, 0, fmt.Errorf("stake duration %s of validator %s cannot be represented, since the stake durations of the genesis validators must differ by a fixed offset", validators[i].StakeDuration, validators[i].NodeID)
+		}
+	}
+	return validators, validators[0].StakeDuration, offset, nil
+}
+
+// SetGenesis sets [genesis-content] and [network-id] in the config of every node in [netConfig], so that the
+// nodes start with [genesis].
+func (netConfig *InitialNetworkConfig) SetGenesis(networkID uint32, genesis []byte) {
+	genesisContent := base64.StdEncoding.EncodeToString(genesis)
+	for _, node := range netConfig.Nodes {
+		node.Config[config.NetworkNameKey] = fmt.Sprintf("%d", networkID)
+		node.Config[config.GenesisConfigContentKey] = genesisContent
+	}
+}
+
+// formatGenesisAddress returns [address] as an X-Chain address with [hrp], so that addresses of any chain or network
+// can be used in the genesis.
+func formatGenesisAddress(hrp string, address string) (string, error) {
+	_, _, addressBytes, err := formatting.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse address %q: %w", address, err)
+	}
+	return formatting.FormatAddress("X", hrp, addressBytes)
+}
+
+// addCChainAllocations returns [cChainGenesis] with the balance of each allocation in [allocations] added to its [alloc].
+func addCChainAllocations(cChainGenesis string, allocations []CChainAllocation) (string, error) {
+	if len(allocations) == 0 {
+		return cChainGenesis, nil
+	}
+
+	var cChain map[string]interface{}
+	if err := json.Unmarshal([]byte(cChainGenesis), &cChain); err != nil {
+		return "", fmt.Errorf("failed to parse C-Chain genesis: %w", err)
+	}
+	alloc, ok := cChain["alloc"].(map[string]interface{})
+	if !ok {
+		alloc = make(map[string]interface{}, len(allocations))
+		cChain["alloc"] = alloc
+	}
+	for _, allocation := range allocations {
+		address := strings.TrimPrefix(strings.ToLower(allocation.Address), "0x")
+		if b, err := hex.DecodeString(address); err != nil || len(b) != 20 {
+			return "", fmt.Errorf("invalid C-Chain address %q", allocation.Address)
+		}
+		if allocation.Balance == nil || allocation.Balance.Sign() < 0 {
+			return "", fmt.Errorf("invalid balance for C-Chain address %s", allocation.Address)
+		}
+		// Addresses are case insensitive, so replace any existing allocation regardless of its case.
+		for existing := range alloc {
+			if strings.EqualFold(strings.TrimPrefix(existing, "0x"), address) {
+				delete(alloc, existing)
+			}
+		}
+		alloc[address] = map[string]interface{}{
+			"balance": "0x" + allocation.Balance.Text(16),
+		}
+	}
+
+	b, err := json.Marshal(cChain)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/ava-labs/avalanchego/genesis"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisConfigBuild(t *testing.T) {
+	assert := assert.New(t)
+
+	nodeIDs := constants.LocalNetworkStakerIDs[:3]
+	genesisConfig, err := NewGenesisConfig(CustomNetworkID, nodeIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesisConfig.StartTime = time.Unix(1_600_000_000, 0)
+	genesisConfig.XChainAllocations = append(genesisConfig.XChainAllocations, GenesisAllocation{
+		Address: "X-local1ur873jhz9qnaqv5qthk5sn3e8nj3e0kmggalnu",
+		Amount:  1000,
+	})
+	genesisConfig.PChainAllocations = append(genesisConfig.PChainAllocations, GenesisAllocation{
+		Address: "P-local1ur873jhz9qnaqv5qthk5sn3e8nj3e0kmggalnu",
+		Amount:  2000,
+	})
+	genesisConfig.CChainAllocations = []CChainAllocation{
+		{Address: "0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC", Balance: big.NewInt(3000)},
+	}
+
+	genesisBytes, err := genesisConfig.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuiltGenesisBytes, err := genesisConfig.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(genesisBytes, rebuiltGenesisBytes, "expected genesis to be deterministic")
+
+	parsedConfig, err := genesis.GetConfigContent(base64.StdEncoding.EncodeToString(genesisBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(CustomNetworkID, parsedConfig.NetworkID)
+	assert.EqualValues(1_600_000_000, parsedConfig.StartTime)
+
+	validators := make([]string, 0, len(parsedConfig.InitialStakers))
+	for _, staker := range parsedConfig.InitialStakers {
+		validators = append(validators, staker.NodeID.PrefixedString(avagoconstants.NodeIDPrefix))
+	}
+	assert.Equal(nodeIDs, validators)
+
+	_, _, fundedAddress, err := formatting.ParseAddress("X-local1ur873jhz9qnaqv5qthk5sn3e8nj3e0kmggalnu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xAmount, pAmount := uint64(0), uint64(0)
+	for _, allocation := range parsedConfig.Allocations {
+		if string(allocation.AVAXAddr.Bytes()) != string(fundedAddress) {
+			continue
+		}
+		xAmount += allocation.InitialAmount
+		for _, unlock := range allocation.UnlockSchedule {
+			pAmount += unlock.Amount
+		}
+	}
+	assert.EqualValues(1000, xAmount)
+	assert.EqualValues(2000, pAmount)
+	supply, err := parsedConfig.InitialSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(3*DefaultValidatorWeight+300*1_000_000*1_000_000_000+30*1_000_000*1_000_000_000+3000, supply)
+
+	var cChainGenesis struct {
+		Alloc map[string]struct {
+			Balance string `json:"balance"`
+		} `json:"alloc"`
+	}
+	if err := json.Unmarshal([]byte(parsedConfig.CChainGenesis), &cChainGenesis); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(cChainGenesis.Alloc, 1, "expected the existing allocation of the address to be replaced")
+	assert.Equal("0xbb8", cChainGenesis.Alloc["8db97c7cece249c2b98bdc0226cc4c2a57bf52fc"].Balance)
+}
+
+func TestGenesisConfigValidatorOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	nodeIDs := constants.LocalNetworkStakerIDs[:3]
+	genesisConfig, err := NewGenesisConfig(CustomNetworkID, nodeIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesisConfig.ValidatorWeight = 0
+	for i, stakeDuration := range []time.Duration{24 * time.Hour, 72 * time.Hour, 48 * time.Hour} {
+		genesisConfig.Validators[i].Weight = 5 * DefaultValidatorWeight
+		genesisConfig.Validators[i].StakeDuration = stakeDuration
+	}
+
+	genesisBytes, err := genesisConfig.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedConfig, err := genesis.GetConfigContent(base64.StdEncoding.EncodeToString(genesisBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The validators are ordered from the longest stake duration to the shortest, so that AvalancheGo derives the
+	// requested stake duration of each validator from its position.
+	validators := make([]string, 0, len(parsedConfig.InitialStakers))
+	for _, staker := range parsedConfig.InitialStakers {
+		validators = append(validators, staker.NodeID.PrefixedString(avagoconstants.NodeIDPrefix))
+	}
+	assert.Equal([]string{nodeIDs[1], nodeIDs[2], nodeIDs[0]}, validators)
+	assert.EqualValues(72*60*60, parsedConfig.InitialStakeDuration)
+	assert.EqualValues(24*60*60, parsedConfig.InitialStakeDurationOffset)
+	supply, err := parsedConfig.InitialSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(15*DefaultValidatorWeight+330*1_000_000*1_000_000_000, supply)
+}
+
+func TestGenesisConfigBuildErrors(t *testing.T) {
+	tests := map[string]func(c *GenesisConfig){
+		"standard network":  func(c *GenesisConfig) { c.NetworkID = avagoconstants.LocalID },
+		"no validators":     func(c *GenesisConfig) { c.Validators = nil },
+		"no weight":         func(c *GenesisConfig) { c.ValidatorWeight = 0 },
+		"no stake duration": func(c *GenesisConfig) { c.StakeDuration = 0 },
+		"future start time": func(c *GenesisConfig) { c.StartTime = time.Now().Add(time.Hour) },
+		"invalid validator": func(c *GenesisConfig) { c.Validators[0].NodeID = "NodeID-invalid" },
+		"invalid address":   func(c *GenesisConfig) { c.XChainAllocations[0].Address = "X-invalid" },
+		"invalid C address": func(c *GenesisConfig) {
+			c.CChainAllocations = []CChainAllocation{{Address: "0x1234", Balance: big.NewInt(1)}}
+		},
+		"staked P-Chain":     func(c *GenesisConfig) { c.PChainAllocations[0].Address = c.StakingAddress },
+		"offset too large":   func(c *GenesisConfig) { c.StakeDurationOffset = c.StakeDuration },
+		"weight overflowing": func(c *GenesisConfig) { c.ValidatorWeight = 1 << 63 },
+		"unequal weights":    func(c *GenesisConfig) { c.Validators[1].Weight = 2 * c.ValidatorWeight },
+		"uneven durations":   func(c *GenesisConfig) { c.Validators[1].StakeDuration = c.StakeDuration - time.Minute },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			genesisConfig, err := NewGenesisConfig(CustomNetworkID, constants.LocalNetworkStakerIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modify(genesisConfig)
+			_, err = genesisConfig.Build()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -5,6 +5,7 @@ package networks
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,27 +16,55 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-const defaultLocalNetworkName = "defaultLocalNetwork"
+const (
+	defaultLocalNetworkName = "defaultLocalNetwork"
+	localNetworkName        = "localNetwork"
+)
+
+var errNoInitialNodes = errors.New("network config must contain at least one initial node")
 
 // NewDefaultLocalNetwork uses orchestrator to generate a new network that runs with 5 nodes on the default local network
 func NewDefaultLocalNetwork(ctx context.Context, orchestrator backend.NetworkOrchestrator, executable string) (backend.Network, error) {
-	network, err := orchestrator.CreateNetwork(fmt.Sprintf("%s-%v", defaultLocalNetworkName, time.Now().Unix()))
+	networkConfig := CreateLocalNetworkConfig(executable)
+	if len(networkConfig.Nodes) != len(constants.LocalNetworkStakerIDs) {
+		return nil, fmt.Errorf("unexpected number of nodes in local network config: %d", len(networkConfig.Nodes))
+	}
+
+	return NewNetwork(ctx, orchestrator, fmt.Sprintf("%s-%v", defaultLocalNetworkName, time.Now().Unix()), networkConfig)
+}
+
+// NewLocalNetwork uses orchestrator to generate a new network of [numNodes] validators, which run with generated staking
+// credentials and a generated genesis. See CreateNetworkConfig.
+func NewLocalNetwork(ctx context.Context, orchestrator backend.NetworkOrchestrator, executable string, numNodes int) (backend.Network, error) {
+	networkConfig, err := CreateNetworkConfig(executable, numNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNetwork(ctx, orchestrator, fmt.Sprintf("%s-%v", localNetworkName, time.Now().Unix()), networkConfig)
+}
+
+// NewNetwork uses orchestrator to create a network under [name] and adds each of the initial nodes in [networkConfig].
+// The first node is treated as the bootstrap node and is started before all of the others, so that the remaining nodes
+// can bootstrap from it unless they explicitly set [bootstrap-ips] themselves.
+// If any node fails to start, the network is torn down and an error is returned.
+func NewNetwork(ctx context.Context, orchestrator backend.NetworkOrchestrator, name string, networkConfig *InitialNetworkConfig) (backend.Network, error) {
+	if len(networkConfig.Nodes) == 0 {
+		return nil, errNoInitialNodes
+	}
+
+	network, err := orchestrator.CreateNetwork(name)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
 		if err != nil {
 			if err := network.Teardown(ctx); err != nil {
-				zap.L().Error("Failed to tear down network after failing to start local network", zap.Error(err))
+				zap.L().Error("Failed to tear down network after failing to start network", zap.String("network", name), zap.Error(err))
 			}
 		}
 	}()
 
-	networkConfig := CreateLocalNetworkConfig(executable)
-	if len(networkConfig.Nodes) != len(constants.LocalNetworkStakerIDs) {
-		return nil, fmt.Errorf("unexpected number of nodes in local network config: %d", len(networkConfig.Nodes))
-	}
-
 	bootNode, err := network.AddNode(ctx, networkConfig.Nodes[0])
 	if err != nil {
 		return nil, fmt.Errorf("failed to add node %s: %w", networkConfig.Nodes[0].Name, err)
@@ -43,25 +72,24 @@ func NewDefaultLocalNetwork(ctx context.Context, orchestrator backend.NetworkOrc
 
 	bootstrapIP := bootNode.GetBootstrapIP()
 	eg := errgroup.Group{}
-	for i := 1; i < 5; i++ {
-		i := i
+	for _, nodeConfig := range networkConfig.Nodes[1:] {
+		nodeConfig := nodeConfig
 		eg.Go(func() error {
-			nodeConfig := networkConfig.Nodes[i]
 			// Must override [bootstrap-ips] since we cannot know this before this point.
-			nodeConfig.Config[config.BootstrapIPsKey] = bootstrapIP
+			nodeConfig.Config = backend.CopyConfig(nodeConfig.Config)
+			if _, ok := nodeConfig.Config[config.BootstrapIPsKey]; !ok {
+				nodeConfig.Config[config.BootstrapIPsKey] = bootstrapIP
+			}
 
-			_, err := network.AddNode(ctx, nodeConfig)
-			if err != nil {
-				return err
+			if _, err := network.AddNode(ctx, nodeConfig); err != nil {
+				return fmt.Errorf("failed to add node %s: %w", nodeConfig.Name, err)
 			}
 			return nil
 		})
 	}
 
-	if err := eg.Wait(); err != nil {
-		if teardownErr := network.Teardown(ctx); err != nil {
-			zap.L().Error("failed to tear down network after wait group errored", zap.NamedError("teardownErr", teardownErr), zap.Error(err))
-		}
+	// Assign to [err] so that the deferred teardown is triggered on failure.
+	if err = eg.Wait(); err != nil {
 		return nil, err
 	}
 	return network, nil
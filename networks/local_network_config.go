@@ -5,11 +5,14 @@ package networks
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 
 	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
 	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
 	"github.com/ava-labs/avalanchego/config"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -22,6 +25,8 @@ const (
 	DefaultLogLevel              = "debug"
 )
 
+var errInvalidNumNodes = errors.New("network must have at least one node")
+
 type InitialNetworkConfig struct {
 	Nodes []backend.NodeConfig `json:"initialNodes"`
 }
@@ -65,3 +70,58 @@ func CreateLocalNetworkConfig(executable string) *InitialNetworkConfig {
 
 	return netConfig
 }
+
+// CreateNetworkConfig creates the initial network config for a local network of [numNodes] validators. Each node is given
+// freshly generated staking credentials and the nodes share a generated genesis, in which they are the initial validators.
+// Unlike the default local network, the ports of the nodes are not set, so they are allocated by the backend.
+func CreateNetworkConfig(executable string, numNodes int) (*InitialNetworkConfig, error) {
+	if numNodes < 1 {
+		return nil, errInvalidNumNodes
+	}
+
+	// Generating staking keys is slow, so generate them concurrently.
+	stakingKeys := make([][]byte, numNodes)
+	stakingCerts := make([][]byte, numNodes)
+	nodeIDs := make([]string, numNodes)
+	eg := errgroup.Group{}
+	for i := 0; i < numNodes; i++ {
+		i := i
+		eg.Go(func() error {
+			var err error
+			stakingKeys[i], stakingCerts[i], nodeIDs[i], err = utils.NewStakingCredentials()
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	netConfig := &InitialNetworkConfig{Nodes: make([]backend.NodeConfig, 0, numNodes)}
+	for i := 0; i < numNodes; i++ {
+		nodeConfig := CreateBasicLocalNodeConfig()
+
+		if i != 0 {
+			nodeConfig[config.BootstrapIDsKey] = nodeIDs[0]
+		}
+		nodeConfig[config.StakingKeyContentKey] = base64.StdEncoding.EncodeToString(stakingKeys[i])
+		nodeConfig[config.StakingCertContentKey] = base64.StdEncoding.EncodeToString(stakingCerts[i])
+
+		netConfig.Nodes = append(netConfig.Nodes, backend.NodeConfig{
+			Name:       fmt.Sprintf("node%d", i),
+			Executable: executable,
+			Config:     nodeConfig,
+			NodeID:     nodeIDs[i],
+		})
+	}
+
+	genesisConfig, err := NewGenesisConfig(CustomNetworkID, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	genesis, err := genesisConfig.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create genesis: %w", err)
+	}
+	netConfig.SetGenesis(CustomNetworkID, genesis)
+	return netConfig, nil
+}
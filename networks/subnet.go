@@ -0,0 +1,301 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	"go.uber.org/zap"
+)
+
+const (
+	// SubnetValidatorWeight is the weight of each validator of a subnet deployed by DeploySubnet
+	SubnetValidatorWeight = 1000
+
+	// subnetValidatorStartDelay is the time from when the validators of a subnet are added until they start
+	// validating it. The start time of a validator must still be in the future when the transaction that adds it is
+	// accepted.
+	subnetValidatorStartDelay = 30 * time.Second
+	subnetPollInterval        = time.Second
+	subnetNodeStopTimeout     = 10 * time.Second
+)
+
+var errNoBlockchains = errors.New("subnet config must contain at least one blockchain")
+
+// SubnetConfig describes a subnet to deploy to a network along with the blockchains it validates
+type SubnetConfig struct {
+	// Validators are the names of the nodes that validate the subnet, which must validate the primary network.
+	// Defaults to every node of the network.
+	Validators  []string           `json:"validators,omitempty"`
+	Blockchains []BlockchainConfig `json:"blockchains"`
+}
+
+// BlockchainConfig describes a blockchain that runs a custom VM
+type BlockchainConfig struct {
+	Name string `json:"name"`
+	// VM is the name of the binary of the VM in the executor registry of the backend
+	VM string `json:"vm"`
+	// VMID is the ID of the VM. Defaults to the ID derived from [VM] by VMID.
+	VMID    string `json:"vmID,omitempty"`
+	Genesis []byte `json:"genesis"`
+}
+
+// Subnet describes a subnet deployed by DeploySubnet
+type Subnet struct {
+	SubnetID    string       `json:"subnetID"`
+	Blockchains []Blockchain `json:"blockchains"`
+}
+
+// Blockchain describes a blockchain deployed by DeploySubnet
+type Blockchain struct {
+	Name         string `json:"name"`
+	VMID         string `json:"vmID"`
+	BlockchainID string `json:"blockchainID"`
+	// RPCURLs maps the name of each validator of the subnet to the URL of the RPC endpoint of the blockchain on it
+	RPCURLs map[string]string `json:"rpcURLs"`
+}
+
+// VMID returns the ID of the VM [name], which is the name padded with zeros like the IDs of the VMs built into
+// AvalancheGo ie. "evm".
+func VMID(name string) (ids.ID, error) {
+	vmID := ids.ID{}
+	if len(name) == 0 || len(name) > len(vmID) {
+		return ids.ID{}, fmt.Errorf("VM name %q must be between 1 and %d bytes long", name, len(vmID))
+	}
+	copy(vmID[:], name)
+	return vmID, nil
+}
+
+// vmID returns the ID of the VM of the blockchain
+func (c BlockchainConfig) vmID() (ids.ID, error) {
+	if c.VMID != "" {
+		return ids.FromString(c.VMID)
+	}
+	return VMID(c.VM)
+}
+
+// DeploySubnet creates a subnet on [network] that runs each of the blockchains of [config]. The VM of each blockchain is
+// installed into the plugin directory of every validator, which is then restarted to validate the subnet. The
+// transactions that create the subnet and its blockchains and add its validators are issued with the pre-funded key of
+// local networks. DeploySubnet returns once every validator has bootstrapped each of the blockchains.
+func DeploySubnet(ctx context.Context, network backend.Network, subnetConfig SubnetConfig) (*Subnet, error) {
+	if len(subnetConfig.Blockchains) == 0 {
+		return nil, errNoBlockchains
+	}
+	plugins := make(map[string]string, len(subnetConfig.Blockchains))
+	vmIDs := make([]ids.ID, 0, len(subnetConfig.Blockchains))
+	for _, blockchain := range subnetConfig.Blockchains {
+		if blockchain.Name == "" || blockchain.VM == "" {
+			return nil, fmt.Errorf("blockchain must have both a name and a VM: %q, %q", blockchain.Name, blockchain.VM)
+		}
+		vmID, err := blockchain.vmID()
+		if err != nil {
+			return nil, fmt.Errorf("invalid VM ID of blockchain %s: %w", blockchain.Name, err)
+		}
+		if vm, exists := plugins[vmID.String()]; exists && vm != blockchain.VM {
+			return nil, fmt.Errorf("VMs %s and %s cannot share the VM ID %s", vm, blockchain.VM, vmID)
+		}
+		plugins[vmID.String()] = blockchain.VM
+		vmIDs = append(vmIDs, vmID)
+	}
+	validators, err := subnetValidators(network, subnetConfig.Validators)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := validators[0].GetHTTPBaseURI()
+	wallet, err := primary.NewWalletFromURI(ctx, uri, secp256k1fx.NewKeychain(genesis.EWOQKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	owner := &secp256k1fx.OutputOwners{
+		Threshold: 1,
+		Addrs:     []ids.ShortID{genesis.EWOQKey.PublicKey().Address()},
+	}
+	subnetID, err := wallet.P().IssueCreateSubnetTx(owner, common.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subnet: %w", err)
+	}
+	zap.L().Info("Created subnet", zap.String("network", network.GetName()), zap.Stringer("subnetID", subnetID))
+
+	// A node only runs the blockchains of the subnets it whitelists, which it reads on startup along with its plugins.
+	for i, node := range validators {
+		update := backend.NodeUpdate{
+			Config:  map[string]interface{}{config.WhitelistedSubnetsKey: whitelistSubnet(node.Config(), subnetID)},
+			Plugins: plugins,
+		}
+		updated, err := network.UpdateNode(ctx, node.GetName(), update, subnetNodeStopTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to install the VMs of subnet %s on node %s: %w", subnetID, node.GetName(), err)
+		}
+		validators[i] = updated
+	}
+	for _, node := range validators {
+		if err := backend.AwaitReady(ctx, node, backend.ReadinessConfig{Level: backend.ReadinessHealthy, PollInterval: subnetPollInterval}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := addSubnetValidators(ctx, wallet, uri, subnetID, validators); err != nil {
+		return nil, err
+	}
+	subnet := &Subnet{
+		SubnetID:    subnetID.String(),
+		Blockchains: make([]Blockchain, 0, len(subnetConfig.Blockchains)),
+	}
+	chains := make([]string, 0, len(subnetConfig.Blockchains))
+	for i, blockchainConfig := range subnetConfig.Blockchains {
+		blockchainID, err := wallet.P().IssueCreateChainTx(subnetID, blockchainConfig.Genesis, vmIDs[i], nil, blockchainConfig.Name, common.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blockchain %s: %w", blockchainConfig.Name, err)
+		}
+		zap.L().Info("Created blockchain", zap.String("name", blockchainConfig.Name), zap.Stringer("blockchainID", blockchainID))
+		blockchain := Blockchain{
+			Name:         blockchainConfig.Name,
+			VMID:         vmIDs[i].String(),
+			BlockchainID: blockchainID.String(),
+			RPCURLs:      make(map[string]string, len(validators)),
+		}
+		for _, node := range validators {
+			blockchain.RPCURLs[node.GetName()] = fmt.Sprintf("%s/ext/bc/%s/rpc", node.GetHTTPBaseURI(), blockchainID)
+		}
+		subnet.Blockchains = append(subnet.Blockchains, blockchain)
+		chains = append(chains, blockchainID.String())
+	}
+
+	if err := awaitSubnetValidators(ctx, uri, subnetID, len(validators)); err != nil {
+		return nil, err
+	}
+	for _, node := range validators {
+		if err := backend.AwaitReady(ctx, node, backend.ReadinessConfig{Level: backend.ReadinessBootstrapped, Chains: chains, PollInterval: subnetPollInterval}); err != nil {
+			return nil, err
+		}
+	}
+	return subnet, nil
+}
+
+// subnetValidators returns the nodes of [network] named [names] sorted by name, or every node of the network if
+// [names] is empty
+func subnetValidators(network backend.Network, names []string) ([]backend.Node, error) {
+	var validators []backend.Node
+	if len(names) == 0 {
+		nodes, err := network.GetNodes()
+		if err != nil {
+			return nil, err
+		}
+		validators = nodes
+	} else {
+		for _, name := range names {
+			node, err := network.GetNode(name)
+			if err != nil {
+				return nil, err
+			}
+			validators = append(validators, node)
+		}
+	}
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("network %s has no nodes to validate the subnet", network.GetName())
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].GetName() < validators[j].GetName()
+	})
+	return validators, nil
+}
+
+// whitelistSubnet returns the value of [whitelisted-subnets] in [nodeConfig] with [subnetID] added to it
+func whitelistSubnet(nodeConfig map[string]interface{}, subnetID ids.ID) string {
+	whitelisted, _ := nodeConfig[config.WhitelistedSubnetsKey].(string)
+	subnets := []string{}
+	for _, subnet := range strings.Split(whitelisted, ",") {
+		if subnet = strings.TrimSpace(subnet); subnet != "" && subnet != subnetID.String() {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return strings.Join(append(subnets, subnetID.String()), ",")
+}
+
+// addSubnetValidators adds each of [validators] as a validator of [subnetID] until it stops validating the primary
+// network, which is looked up through the node at [uri].
+func addSubnetValidators(ctx context.Context, wallet primary.Wallet, uri string, subnetID ids.ID, validators []backend.Node) error {
+	reply, err := platformvm.NewClient(uri).GetCurrentValidators(ctx, avagoconstants.PrimaryNetworkID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get the validators of the primary network: %w", err)
+	}
+	// The client returns the validators as decoded JSON objects.
+	replyBytes, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	primaryValidators := []platformvm.APIStaker{}
+	if err := json.Unmarshal(replyBytes, &primaryValidators); err != nil {
+		return fmt.Errorf("failed to parse the validators of the primary network: %w", err)
+	}
+	endTimes := make(map[string]uint64, len(primaryValidators))
+	for _, validator := range primaryValidators {
+		endTimes[validator.NodeID] = uint64(validator.EndTime)
+	}
+
+	start := uint64(time.Now().Add(subnetValidatorStartDelay).Unix())
+	for _, node := range validators {
+		nodeIDStr, err := info.NewClient(node.GetHTTPBaseURI()).GetNodeID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the node ID of node %s: %w", node.GetName(), err)
+		}
+		nodeID, err := ids.ShortFromPrefixedString(nodeIDStr, avagoconstants.NodeIDPrefix)
+		if err != nil {
+			return err
+		}
+		end, ok := endTimes[nodeIDStr]
+		if !ok {
+			return fmt.Errorf("node %s cannot validate subnet %s, since it does not validate the primary network", node.GetName(), subnetID)
+		}
+		if _, err := wallet.P().IssueAddSubnetValidatorTx(&platformvm.SubnetValidator{
+			Validator: platformvm.Validator{
+				NodeID: nodeID,
+				Start:  start,
+				End:    end,
+				Wght:   SubnetValidatorWeight,
+			},
+			Subnet: subnetID,
+		}, common.WithContext(ctx)); err != nil {
+			return fmt.Errorf("failed to add node %s as a validator of subnet %s: %w", node.GetName(), subnetID, err)
+		}
+	}
+	return nil
+}
+
+// awaitSubnetValidators waits for [subnetID] to have [numValidators] current validators according to the node at [uri]
+func awaitSubnetValidators(ctx context.Context, uri string, subnetID ids.ID, numValidators int) error {
+	client := platformvm.NewClient(uri)
+	ticker := time.NewTicker(subnetPollInterval)
+	defer ticker.Stop()
+
+	for {
+		validators, err := client.GetCurrentValidators(ctx, subnetID, nil)
+		if err == nil && len(validators) == numValidators {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("validators of subnet %s did not start validating: %w (last error: %v)", subnetID, ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVMID(t *testing.T) {
+	assert := assert.New(t)
+
+	// The ID that subnet-evm is installed under
+	vmID, err := VMID("subnetevm")
+	assert.NoError(err)
+	assert.Equal("srEXiWaHuhNyGwPUi444Tu47ZEDwxTWrbQiuD7FmgSAQ6X7Dy", vmID.String())
+
+	vmID, err = BlockchainConfig{VM: "subnetevm", VMID: "srEXiWaHuhNyGwPUi444Tu47ZEDwxTWrbQiuD7FmgSAQ6X7Dy"}.vmID()
+	assert.NoError(err)
+	assert.Equal("srEXiWaHuhNyGwPUi444Tu47ZEDwxTWrbQiuD7FmgSAQ6X7Dy", vmID.String())
+
+	_, err = VMID("")
+	assert.Error(err)
+	_, err = VMID(strings.Repeat("a", 33))
+	assert.Error(err)
+	_, err = BlockchainConfig{VM: "subnetevm", VMID: "invalid"}.vmID()
+	assert.Error(err)
+}
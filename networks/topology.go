@@ -0,0 +1,486 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/utils"
+	"github.com/ava-labs/avalanchego/config"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v2"
+)
+
+// TopologyVersion is the version of the topology file format supported by LoadTopology.
+const TopologyVersion = 1
+
+var errMissingTopologyVersion = errors.New("topology is missing its version")
+
+// Topology describes the shape of a network, so that it can be checked in as a YAML or JSON file and started with StartTopology.
+type Topology struct {
+	// Version is the version of the topology format and must be set to TopologyVersion.
+	Version int `json:"version"`
+	// Name is the name of the network. Defaults to a generated name.
+	Name string `json:"name,omitempty"`
+	// Executable is the name of the registered executable used by every node that does not set its own.
+	Executable string `json:"executable,omitempty"`
+	// Config is applied to every node and is overridden by the config of each node.
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Genesis is the genesis passed to every node. It may also be given as a path with GenesisFile.
+	Genesis json.RawMessage `json:"genesis,omitempty"`
+	// GenesisFile is the path to a genesis file, relative to the topology file.
+	GenesisFile string `json:"genesisFile,omitempty"`
+	// ChainConfigs maps a chain ID or alias to its config and is applied to every node.
+	ChainConfigs map[string]ChainConfig `json:"chainConfigs,omitempty"`
+	// Nodes are started in dependency order, so that each node starts after the nodes it bootstraps from.
+	Nodes []TopologyNode `json:"nodes"`
+}
+
+// TopologyNode describes a node, or a group of identical nodes if Count is greater than 1.
+type TopologyNode struct {
+	// Name of the node. If Count is greater than 1, the nodes are named <name>0, <name>1, ...
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+	// Executable overrides the executable of the topology.
+	Executable string `json:"executable,omitempty"`
+	// Config overrides the config of the topology.
+	Config map[string]interface{} `json:"config,omitempty"`
+	// StakingKey and StakingCert are the PEM encoded staking key and certificate of the node. They may also
+	// be given as paths, relative to the topology file, with StakingKeyFile and StakingCertFile.
+	StakingKey      string `json:"stakingKey,omitempty"`
+	StakingCert     string `json:"stakingCert,omitempty"`
+	StakingKeyFile  string `json:"stakingKeyFile,omitempty"`
+	StakingCertFile string `json:"stakingCertFile,omitempty"`
+	// NodeID is derived from the staking certificate if it is not set.
+	NodeID string `json:"nodeID,omitempty"`
+	// Bootstrap lists the names of the nodes to bootstrap from. If it is omitted, every node other than the
+	// first bootstraps from the first node, unless its config sets [bootstrap-ips]. An empty list disables bootstrapping.
+	Bootstrap []string `json:"bootstrap,omitempty"`
+	// ChainConfigs overrides the chain configs of the topology.
+	ChainConfigs map[string]ChainConfig `json:"chainConfigs,omitempty"`
+}
+
+// ChainConfig holds the config and upgrade of a chain, which are written to the chain config directory of a node.
+type ChainConfig = backend.ChainConfig
+
+// topologyNode is a single node of a parsed topology, which is ready to be added to a network.
+type topologyNode struct {
+	config backend.NodeConfig
+	// bootstrap is nil if the node should not be assigned any bootstrappers
+	bootstrap []string
+}
+
+// NewTopology returns a topology with the nodes of [networkConfig]. As with NewNetwork, every node other than the first
+// bootstraps from the first node unless it sets [bootstrap-ips].
+func NewTopology(networkConfig *InitialNetworkConfig) *Topology {
+	topology := &Topology{
+		Version: TopologyVersion,
+		Nodes:   make([]TopologyNode, 0, len(networkConfig.Nodes)),
+	}
+	for _, node := range networkConfig.Nodes {
+		topology.Nodes = append(topology.Nodes, TopologyNode{
+			Name:       node.Name,
+			Executable: node.Executable,
+			Config:     backend.CopyConfig(node.Config),
+			NodeID:     node.NodeID,
+		})
+	}
+	return topology
+}
+
+// LoadTopology reads the topology at [path], which may be YAML or JSON, and resolves any files it references relative to [path].
+func LoadTopology(path string) (*Topology, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topology file %s: %w", path, err)
+	}
+	topology, err := ParseTopology(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topology file %s: %w", path, err)
+	}
+	if err := topology.resolveFiles(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("failed to load topology file %s: %w", path, err)
+	}
+	if _, err := topology.nodes(); err != nil {
+		return nil, fmt.Errorf("invalid topology file %s: %w", path, err)
+	}
+	return topology, nil
+}
+
+// ParseTopology parses a YAML or JSON topology from [b]. Files referenced by the topology are not read until it is started.
+func ParseTopology(b []byte) (*Topology, error) {
+	// YAML is a superset of JSON, so the topology is decoded as YAML and converted to JSON, so that it
+	// only needs to be described by a single set of struct tags.
+	var raw interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	raw, err := toJSONValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	topology := new(Topology)
+	if err := json.Unmarshal(jsonBytes, topology); err != nil {
+		return nil, err
+	}
+	switch topology.Version {
+	case TopologyVersion:
+	case 0:
+		return nil, errMissingTopologyVersion
+	default:
+		return nil, fmt.Errorf("unsupported topology version %d, expected %d", topology.Version, TopologyVersion)
+	}
+	return topology, nil
+}
+
+// StartTopology uses [orchestrator] to create a network with the nodes of [topology]. Each node is started after all
+// of the nodes it bootstraps from and nodes that do not depend on each other are started concurrently.
+// Files referenced by [topology] that have not been loaded by LoadTopology are read relative to the working directory.
+// If any node fails to start, the network is torn down and an error is returned.
+func StartTopology(ctx context.Context, orchestrator backend.NetworkOrchestrator, topology *Topology) (backend.Network, error) {
+	if err := topology.resolveFiles("."); err != nil {
+		return nil, err
+	}
+	nodes, err := topology.nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	name := topology.Name
+	if name == "" {
+		name = fmt.Sprintf("topology-%v", time.Now().Unix())
+	}
+	network, err := orchestrator.CreateNetwork(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := network.Teardown(ctx); err != nil {
+				zap.L().Error("Failed to tear down network after failing to start topology", zap.String("network", name), zap.Error(err))
+			}
+		}
+	}()
+
+	ids := nodeIDs(nodes)
+	started := make(map[string]backend.Node, len(nodes))
+	for len(started) < len(nodes) {
+		// Start every node whose bootstrappers have all been started.
+		wave := make([]topologyNode, 0, len(nodes))
+		for _, node := range nodes {
+			if _, ok := started[node.config.Name]; ok {
+				continue
+			}
+			ready := true
+			for _, bootstrapper := range node.bootstrap {
+				if _, ok := started[bootstrapper]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, node)
+			}
+		}
+
+		addedNodes := make([]backend.Node, len(wave))
+		eg := errgroup.Group{}
+		for i, node := range wave {
+			i, node := i, node
+			if len(node.bootstrap) > 0 {
+				bootstrapIPs := make([]string, 0, len(node.bootstrap))
+				bootstrapIDs := make([]string, 0, len(node.bootstrap))
+				for _, bootstrapper := range node.bootstrap {
+					bootstrapIPs = append(bootstrapIPs, started[bootstrapper].GetBootstrapIP())
+					bootstrapIDs = append(bootstrapIDs, ids[bootstrapper])
+				}
+				node.config.Config[config.BootstrapIPsKey] = strings.Join(bootstrapIPs, ",")
+				node.config.Config[config.BootstrapIDsKey] = strings.Join(bootstrapIDs, ",")
+			}
+			eg.Go(func() error {
+				addedNode, err := network.AddNode(ctx, node.config)
+				if err != nil {
+					return fmt.Errorf("failed to add node %s: %w", node.config.Name, err)
+				}
+				addedNodes[i] = addedNode
+				return nil
+			})
+		}
+		// Assign to [err] so that the deferred teardown is triggered on failure.
+		if err = eg.Wait(); err != nil {
+			return nil, err
+		}
+		for _, node := range addedNodes {
+			started[node.GetName()] = node
+		}
+	}
+	return network, nil
+}
+
+// resolveFiles reads the files referenced by the topology relative to [dir] and replaces the references with their contents.
+func (t *Topology) resolveFiles(dir string) error {
+	if t.GenesisFile != "" {
+		if len(t.Genesis) != 0 {
+			return errors.New("topology cannot set both genesis and genesisFile")
+		}
+		genesis, err := os.ReadFile(resolvePath(dir, t.GenesisFile))
+		if err != nil {
+			return fmt.Errorf("failed to read genesis file: %w", err)
+		}
+		t.Genesis = genesis
+		t.GenesisFile = ""
+	}
+
+	for i := range t.Nodes {
+		node := &t.Nodes[i]
+		if node.StakingKeyFile != "" {
+			if node.StakingKey != "" {
+				return fmt.Errorf("node %s cannot set both stakingKey and stakingKeyFile", node.Name)
+			}
+			key, err := os.ReadFile(resolvePath(dir, node.StakingKeyFile))
+			if err != nil {
+				return fmt.Errorf("failed to read staking key of node %s: %w", node.Name, err)
+			}
+			node.StakingKey = string(key)
+			node.StakingKeyFile = ""
+		}
+		if node.StakingCertFile != "" {
+			if node.StakingCert != "" {
+				return fmt.Errorf("node %s cannot set both stakingCert and stakingCertFile", node.Name)
+			}
+			cert, err := os.ReadFile(resolvePath(dir, node.StakingCertFile))
+			if err != nil {
+				return fmt.Errorf("failed to read staking certificate of node %s: %w", node.Name, err)
+			}
+			node.StakingCert = string(cert)
+			node.StakingCertFile = ""
+		}
+	}
+	return nil
+}
+
+// nodes expands the topology into the config of each node and validates the bootstrap relationships between them.
+func (t *Topology) nodes() ([]topologyNode, error) {
+	if len(t.Nodes) == 0 {
+		return nil, errNoInitialNodes
+	}
+
+	chainConfigs := t.ChainConfigs
+	nodes := make([]topologyNode, 0, len(t.Nodes))
+	for _, nodeDef := range t.Nodes {
+		if nodeDef.Name == "" {
+			return nil, errors.New("topology node is missing a name")
+		}
+		if nodeDef.Count < 0 {
+			return nil, fmt.Errorf("node %s has negative count %d", nodeDef.Name, nodeDef.Count)
+		}
+		names := []string{nodeDef.Name}
+		if nodeDef.Count > 1 {
+			if nodeDef.StakingKey != "" || nodeDef.StakingCert != "" || nodeDef.NodeID != "" {
+				return nil, fmt.Errorf("node %s cannot set a staking key, staking certificate or NodeID with a count of %d", nodeDef.Name, nodeDef.Count)
+			}
+			names = make([]string, 0, nodeDef.Count)
+			for i := 0; i < nodeDef.Count; i++ {
+				names = append(names, fmt.Sprintf("%s%d", nodeDef.Name, i))
+			}
+		}
+
+		executable := nodeDef.Executable
+		if executable == "" {
+			executable = t.Executable
+		}
+		if executable == "" {
+			return nil, fmt.Errorf("node %s does not specify an executable", nodeDef.Name)
+		}
+
+		nodeID := nodeDef.NodeID
+		if (nodeDef.StakingKey == "") != (nodeDef.StakingCert == "") {
+			return nil, fmt.Errorf("node %s must set both a staking key and a staking certificate", nodeDef.Name)
+		}
+		if nodeDef.StakingCert != "" {
+			derivedNodeID, err := utils.ToNodeID([]byte(nodeDef.StakingKey), []byte(nodeDef.StakingCert))
+			if err != nil {
+				return nil, fmt.Errorf("invalid staking credentials for node %s: %w", nodeDef.Name, err)
+			}
+			if nodeID != "" && nodeID != derivedNodeID {
+				return nil, fmt.Errorf("node %s has NodeID %s, but its staking certificate belongs to %s", nodeDef.Name, nodeID, derivedNodeID)
+			}
+			nodeID = derivedNodeID
+		}
+
+		for _, name := range names {
+			nodeConfig := backend.CopyConfig(t.Config)
+			if nodeConfig == nil {
+				nodeConfig = make(map[string]interface{})
+			}
+			for key, value := range nodeDef.Config {
+				nodeConfig[key] = value
+			}
+			if len(t.Genesis) != 0 {
+				if _, ok := nodeConfig[config.GenesisConfigContentKey]; !ok {
+					nodeConfig[config.GenesisConfigContentKey] = base64.StdEncoding.EncodeToString(rawBytes(t.Genesis))
+				}
+			}
+			if nodeDef.StakingKey != "" {
+				nodeConfig[config.StakingKeyContentKey] = base64.StdEncoding.EncodeToString([]byte(nodeDef.StakingKey))
+				nodeConfig[config.StakingCertContentKey] = base64.StdEncoding.EncodeToString([]byte(nodeDef.StakingCert))
+			}
+
+			bootstrap := nodeDef.Bootstrap
+			if bootstrap == nil && len(nodes) > 0 {
+				if _, ok := nodeConfig[config.BootstrapIPsKey]; !ok {
+					bootstrap = []string{nodes[0].config.Name}
+				}
+			}
+
+			nodes = append(nodes, topologyNode{
+				config: backend.NodeConfig{
+					Name:         name,
+					Executable:   executable,
+					Config:       nodeConfig,
+					NodeID:       nodeID,
+					ChainConfigs: mergeChainConfigs(chainConfigs, nodeDef.ChainConfigs),
+				},
+				bootstrap: bootstrap,
+			})
+		}
+	}
+
+	if err := validateBootstrapOrder(nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// validateBootstrapOrder ensures that every node has a unique name and bootstraps from known nodes, which have a NodeID,
+// without any cycles, so that StartTopology can always make progress.
+func validateBootstrapOrder(nodes []topologyNode) error {
+	ids := nodeIDs(nodes)
+	if len(ids) != len(nodes) {
+		return errors.New("topology contains duplicate node names")
+	}
+	for _, node := range nodes {
+		for _, bootstrapper := range node.bootstrap {
+			nodeID, ok := ids[bootstrapper]
+			if !ok {
+				return fmt.Errorf("node %s bootstraps from unknown node %s", node.config.Name, bootstrapper)
+			}
+			if nodeID == "" {
+				return fmt.Errorf("node %s bootstraps from node %s, which does not have a staking certificate or NodeID", node.config.Name, bootstrapper)
+			}
+		}
+	}
+
+	started := make(map[string]struct{}, len(nodes))
+	for len(started) < len(nodes) {
+		progress := false
+		for _, node := range nodes {
+			if _, ok := started[node.config.Name]; ok {
+				continue
+			}
+			ready := true
+			for _, bootstrapper := range node.bootstrap {
+				if _, ok := started[bootstrapper]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				started[node.config.Name] = struct{}{}
+				progress = true
+			}
+		}
+		if !progress {
+			return errors.New("topology contains a bootstrap cycle")
+		}
+	}
+	return nil
+}
+
+// nodeIDs returns a map from the name of each node to its NodeID.
+func nodeIDs(nodes []topologyNode) map[string]string {
+	ids := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		ids[node.config.Name] = node.config.NodeID
+	}
+	return ids
+}
+
+// mergeChainConfigs returns the combination of [chainConfigs] and [overrides], or nil if both are empty.
+func mergeChainConfigs(chainConfigs map[string]ChainConfig, overrides map[string]ChainConfig) map[string]ChainConfig {
+	if len(chainConfigs) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]ChainConfig, len(chainConfigs)+len(overrides))
+	for _, configs := range []map[string]ChainConfig{chainConfigs, overrides} {
+		for chain, c := range configs {
+			merged[chain] = c
+		}
+	}
+	return merged
+}
+
+// rawBytes returns the contents of [raw] if it is a JSON string, so that configs can be given either as an object
+// or as a string holding the config.
+func rawBytes(raw json.RawMessage) []byte {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []byte(s)
+	}
+	return raw
+}
+
+func resolvePath(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// toJSONValue converts the maps decoded by yaml.v2, which may have non-string keys, to maps that can be marshalled to JSON.
+func toJSONValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			keyString, ok := key.(string)
+			if !ok {
+				keyString = fmt.Sprintf("%v", key)
+			}
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			m[keyString] = converted
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, value := range v {
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = converted
+		}
+		return s, nil
+	default:
+		return v, nil
+	}
+}
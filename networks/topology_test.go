@@ -0,0 +1,138 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTopology = `
+version: 1
+name: test
+executable: avalanchego
+config:
+  log-level: info
+  network-id: 1337
+genesisFile: genesis.json
+chainConfigs:
+  C:
+    config:
+      eth-apis: [public-eth]
+nodes:
+  - name: boot
+    stakingKeyFile: staker1.key
+    stakingCertFile: staker1.crt
+    config:
+      http-port: 9650
+  - name: worker
+    count: 2
+    config:
+      log-level: debug
+  - name: isolated
+    bootstrap: []
+    chainConfigs:
+      C:
+        config: '{"eth-apis": ["debug"]}'
+`
+
+func TestLoadTopology(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"topology.yaml": testTopology,
+		"genesis.json":  `{"networkID": 1337}`,
+		"staker1.key":   constants.Staker1PrivateKey,
+		"staker1.crt":   constants.Staker1Cert,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	topology, err := LoadTopology(filepath.Join(dir, "topology.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("test", topology.Name)
+
+	nodes, err := topology.nodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.config.Name)
+		assert.Equal("avalanchego", node.config.Executable)
+		assert.EqualValues(1337, node.config.Config[config.NetworkNameKey])
+		assert.Equal(base64.StdEncoding.EncodeToString([]byte(`{"networkID": 1337}`)), node.config.Config[config.GenesisConfigContentKey])
+	}
+	assert.Equal([]string{"boot", "worker0", "worker1", "isolated"}, names)
+
+	boot := nodes[0]
+	assert.Equal(constants.Staker1NodeID, boot.config.NodeID)
+	assert.Nil(boot.bootstrap)
+	assert.Equal("info", boot.config.Config[config.LogLevelKey])
+	assert.EqualValues(9650, boot.config.Config[config.HTTPPortKey])
+	assert.Equal(base64.StdEncoding.EncodeToString([]byte(constants.Staker1Cert)), boot.config.Config[config.StakingCertContentKey])
+
+	worker := nodes[1]
+	assert.Equal([]string{"boot"}, worker.bootstrap)
+	assert.Equal("debug", worker.config.Config[config.LogLevelKey])
+	assert.JSONEq(`{"eth-apis":["public-eth"]}`, string(worker.config.ChainConfigs["C"].Config))
+
+	isolated := nodes[3]
+	assert.Empty(isolated.bootstrap)
+	assert.JSONEq(`"{\"eth-apis\": [\"debug\"]}"`, string(isolated.config.ChainConfigs["C"].Config))
+	assert.NotContains(isolated.config.Config, config.ChainConfigContentKey)
+}
+
+func TestParseTopologyErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing version":     `nodes: [{name: node0, executable: avalanchego}]`,
+		"unsupported version": `{"version": 2, "nodes": [{"name": "node0", "executable": "avalanchego"}]}`,
+		"no nodes":            `version: 1`,
+		"missing executable":  `{"version": 1, "nodes": [{"name": "node0"}]}`,
+		"duplicate names":     `{"version": 1, "executable": "avalanchego", "nodes": [{"name": "node0", "bootstrap": []}, {"name": "node0", "bootstrap": []}]}`,
+		"unknown bootstrapper": `
+version: 1
+executable: avalanchego
+nodes:
+  - name: node0
+    bootstrap: [node1]`,
+		"bootstrapper without NodeID": `
+version: 1
+executable: avalanchego
+nodes:
+  - name: node0
+  - name: node1`,
+		"bootstrap cycle": `
+version: 1
+executable: avalanchego
+nodes:
+  - name: node0
+    nodeID: NodeID-7Xhw2mDxuDS44j42TCB6U5579esbSt3Lg
+    bootstrap: [node1]
+  - name: node1
+    nodeID: NodeID-MFrZFVCXPv5iCn6M9K6XduxGTYp891xXZ
+    bootstrap: [node0]`,
+	}
+	for name, topologyYAML := range tests {
+		t.Run(name, func(t *testing.T) {
+			topology, err := ParseTopology([]byte(topologyYAML))
+			if err == nil {
+				_, err = topology.nodes()
+			}
+			assert.Error(t, err)
+		})
+	}
+}
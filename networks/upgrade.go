@@ -0,0 +1,161 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	upgradeNodeStopTimeout = 10 * time.Second
+	upgradePollInterval    = time.Second
+)
+
+var errInvalidBatchSize = errors.New("batch size must be at least 1")
+
+// RollingUpgrade restarts the nodes of [network] that run the executable registered as [fromExecutable] on the executable
+// registered as [toExecutable], [batchSize] nodes at a time in the order of their names. Each node keeps its config,
+// staking key, data directory and ports. After each batch, the nodes of the batch and the nodes that were running
+// before it must pass [healthGate] before the next batch is upgraded, so that no more than [batchSize] nodes are down at
+// once. If the network can check its executables, [toExecutable] must be usable before any node is stopped. The level of [healthGate] defaults
+// to ReadinessHealthy. RollingUpgrade returns the names of the nodes that were upgraded, which are also returned along
+// with an error for the batches that completed before it.
+func RollingUpgrade(ctx context.Context, network backend.Network, fromExecutable string, toExecutable string, batchSize int, healthGate backend.ReadinessConfig) ([]string, error) {
+	switch {
+	case batchSize < 1:
+		return nil, errInvalidBatchSize
+	case fromExecutable == "" || toExecutable == "":
+		return nil, fmt.Errorf("rolling upgrade requires both an executable to upgrade from and to: %q, %q", fromExecutable, toExecutable)
+	case fromExecutable == toExecutable:
+		return nil, fmt.Errorf("cannot upgrade nodes from executable %s to itself", fromExecutable)
+	}
+	if healthGate.Level == "" {
+		healthGate.Level = backend.ReadinessHealthy
+	}
+	if healthGate.PollInterval == 0 {
+		healthGate.PollInterval = upgradePollInterval
+	}
+	if err := healthGate.Validate(); err != nil {
+		return nil, err
+	}
+
+	names, err := nodesRunning(network, fromExecutable)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no nodes of network %s run executable %s", network.GetName(), fromExecutable)
+	}
+	if checker, ok := network.(backend.ExecutableChecker); ok {
+		if err := checker.CheckExecutable(toExecutable); err != nil {
+			return nil, fmt.Errorf("cannot upgrade nodes of network %s to executable %s: %w", network.GetName(), toExecutable, err)
+		}
+	}
+
+	upgraded := make([]string, 0, len(names))
+	for start := 0; start < len(names); start += batchSize {
+		end := start + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		batch := names[start:end]
+		// Nodes that are stopped or crashed before the batch are not expected to pass the health gate after it.
+		gated, err := runningNodes(network)
+		if err != nil {
+			return upgraded, err
+		}
+		for _, name := range batch {
+			gated[name] = struct{}{}
+		}
+		zap.L().Info("Upgrading nodes",
+			zap.String("network", network.GetName()),
+			zap.Strings("nodes", batch),
+			zap.String("from", fromExecutable),
+			zap.String("to", toExecutable),
+		)
+
+		eg, egCtx := errgroup.WithContext(ctx)
+		for _, name := range batch {
+			name := name
+			eg.Go(func() error {
+				if _, err := network.UpdateNode(egCtx, name, backend.NodeUpdate{Executable: toExecutable}, upgradeNodeStopTimeout); err != nil {
+					return fmt.Errorf("failed to upgrade node %s to executable %s: %w", name, toExecutable, err)
+				}
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return upgraded, err
+		}
+		upgraded = append(upgraded, batch...)
+
+		if err := awaitNodesReady(ctx, network, gated, healthGate); err != nil {
+			return upgraded, fmt.Errorf("network %s failed the health gate after upgrading %s: %w", network.GetName(), strings.Join(batch, ", "), err)
+		}
+	}
+	return upgraded, nil
+}
+
+// nodesRunning returns the names of the nodes of [network] that run [executable] sorted by name
+func nodesRunning(network backend.Network, executable string) ([]string, error) {
+	nodes, err := network.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, node := range nodes {
+		reporter, ok := node.(backend.ExecutableReporter)
+		if !ok {
+			return nil, fmt.Errorf("cannot determine the executable of node %s", node.GetName())
+		}
+		if reporter.Executable() == executable {
+			names = append(names, node.GetName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// runningNodes returns the names of the nodes of [network] that are running
+func runningNodes(network backend.Network) (map[string]struct{}, error) {
+	nodes, err := network.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		if node.Status() == backend.NodeRunning {
+			names[node.GetName()] = struct{}{}
+		}
+	}
+	return names, nil
+}
+
+// awaitNodesReady waits for the nodes of [network] in [names] to reach the readiness level of [config]
+func awaitNodesReady(ctx context.Context, network backend.Network, names map[string]struct{}, config backend.ReadinessConfig) error {
+	nodes, err := network.GetNodes()
+	if err != nil {
+		return err
+	}
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, node := range nodes {
+		node := node
+		if _, ok := names[node.GetName()]; !ok {
+			continue
+		}
+		eg.Go(func() error {
+			return backend.AwaitReady(egCtx, node, config)
+		})
+	}
+	return eg.Wait()
+}
@@ -0,0 +1,198 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/backend"
+	"github.com/aaronbuchwald/avalanche-network-runner/backend/fakebackend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingUpgrade(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		lock    sync.Mutex
+		stopped []string
+	)
+	errStop := errors.New("stop failed")
+	fake := fakebackend.New(fakebackend.Hooks{
+		StopNode: func(network string, node string) error {
+			if node == "node4" {
+				return errStop
+			}
+			lock.Lock()
+			defer lock.Unlock()
+
+			stopped = append(stopped, node)
+			return nil
+		},
+	})
+	network, err := backend.NewOrchestrator(fake).CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	executables := map[string]string{"node0": "v1", "node1": "v1", "node2": "v2", "node3": "v1", "node4": "v3"}
+	for name, executable := range executables {
+		if _, err := network.AddNode(ctx, backend.NodeConfig{Name: name, Executable: executable}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	started := backend.ReadinessConfig{Level: backend.ReadinessStarted}
+
+	_, err = RollingUpgrade(ctx, network, "v1", "v2", 0, started)
+	assert.ErrorIs(err, errInvalidBatchSize)
+	_, err = RollingUpgrade(ctx, network, "v1", "v1", 1, started)
+	assert.Error(err, "expected an upgrade to the same executable to fail")
+	_, err = RollingUpgrade(ctx, network, "v0", "v2", 1, started)
+	assert.Error(err, "expected an upgrade without matching nodes to fail")
+	_, err = RollingUpgrade(ctx, network, "v1", "v2", 1, backend.ReadinessConfig{Level: "unknown"})
+	assert.Error(err, "expected an unknown health gate to fail")
+	assert.Empty(stopped, "expected invalid upgrades to leave the nodes running")
+
+	upgraded, err := RollingUpgrade(ctx, network, "v1", "v2", 2, started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal([]string{"node0", "node1", "node3"}, upgraded)
+	if assert.Len(stopped, 3) {
+		assert.ElementsMatch([]string{"node0", "node1"}, stopped[:2], "expected the first batch to be upgraded first")
+		assert.Equal("node3", stopped[2])
+	}
+	constructor, ok := fake.Network("network")
+	if !assert.True(ok, "expected network to be created") {
+		return
+	}
+	for _, node := range constructor.RunningNodes() {
+		expected := executables[node.GetName()]
+		if expected == "v1" {
+			expected = "v2"
+		}
+		assert.Equal(expected, node.Executable(), "unexpected executable of node %s", node.GetName())
+	}
+
+	upgraded, err = RollingUpgrade(ctx, network, "v3", "v2", 1, started)
+	assert.ErrorIs(err, errStop)
+	assert.Empty(upgraded, "expected a failed batch not to be reported as upgraded")
+
+	// The fake nodes cannot be probed, so the health gate fails once the first batch is upgraded.
+	gateCtx, gateCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer gateCancel()
+	upgraded, err = RollingUpgrade(gateCtx, network, "v2", "v1", 2, backend.ReadinessConfig{Level: backend.ReadinessHTTP})
+	assert.Error(err, "expected the health gate to fail")
+	assert.Equal([]string{"node0", "node1"}, upgraded)
+}
+
+// probedNetwork is a network whose nodes serve their HTTP API at [uri] and that checks executables with
+// [checkExecutable]
+type probedNetwork struct {
+	backend.Network
+	uri             string
+	checkExecutable func(name string) error
+}
+
+// probedNode is a node that serves its HTTP API at [uri]
+type probedNode struct {
+	backend.Node
+	uri string
+}
+
+func (n *probedNode) GetHTTPBaseURI() string {
+	return n.uri
+}
+
+func (n *probedNode) Executable() string {
+	return n.Node.(backend.ExecutableReporter).Executable()
+}
+
+func (n *probedNetwork) GetNodes() ([]backend.Node, error) {
+	nodes, err := n.Network.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	probed := make([]backend.Node, 0, len(nodes))
+	for _, node := range nodes {
+		probed = append(probed, &probedNode{Node: node, uri: n.uri})
+	}
+	return probed, nil
+}
+
+func (n *probedNetwork) CheckExecutable(name string) error {
+	return n.checkExecutable(name)
+}
+
+func TestRollingUpgradeGate(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	fake := fakebackend.New(fakebackend.Hooks{})
+	network, err := backend.NewOrchestrator(fake).CreateNetwork("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, executable := range map[string]string{"node0": "v1", "node1": "v1", "node2": "v2"} {
+		if _, err := network.AddNode(ctx, backend.NodeConfig{Name: name, Executable: executable}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errUnregistered := errors.New("executable is not registered")
+	probed := &probedNetwork{
+		Network: network,
+		uri:     "http://" + listener.Addr().String(),
+		checkExecutable: func(name string) error {
+			if name == "v3" {
+				return errUnregistered
+			}
+			return nil
+		},
+	}
+
+	// The executable to upgrade to is checked before any node is stopped.
+	_, err = RollingUpgrade(ctx, probed, "v1", "v3", 1, backend.ReadinessConfig{Level: backend.ReadinessHTTP})
+	assert.ErrorIs(err, errUnregistered)
+	constructor, ok := fake.Network("network")
+	if !assert.True(ok, "expected network to be created") {
+		return
+	}
+	for _, node := range constructor.RunningNodes() {
+		assert.Zero(node.StopCount(), "expected node %s to keep running", node.GetName())
+	}
+
+	// A node that was stopped before the upgrade does not fail the health gate.
+	stopped, err := network.GetNode("node2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stopped.Stop(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, err := RollingUpgrade(ctx, probed, "v1", "v2", 1, backend.ReadinessConfig{Level: backend.ReadinessHTTP})
+	assert.NoError(err)
+	assert.Equal([]string{"node0", "node1"}, upgraded)
+}
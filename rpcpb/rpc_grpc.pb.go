@@ -109,11 +109,32 @@ var PingService_ServiceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type OrchestratorServiceClient interface {
 	CreateNetwork(ctx context.Context, in *CreateNetworkRequest, opts ...grpc.CallOption) (*CreateNetworkResponse, error)
+	ListNetworks(ctx context.Context, in *ListNetworksRequest, opts ...grpc.CallOption) (*ListNetworksResponse, error)
+	GetNetwork(ctx context.Context, in *GetNetworkRequest, opts ...grpc.CallOption) (*GetNetworkResponse, error)
 	GetNodes(ctx context.Context, in *GetNodesRequest, opts ...grpc.CallOption) (*GetNodesResponse, error)
 	GetNode(ctx context.Context, in *GetNodeRequest, opts ...grpc.CallOption) (*GetNodeResponse, error)
 	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error)
 	Teardown(ctx context.Context, in *TeardownRequest, opts ...grpc.CallOption) (*TeardownResponse, error)
 	NodeStop(ctx context.Context, in *NodeStopRequest, opts ...grpc.CallOption) (*NodeStopResponse, error)
+	NodeRestart(ctx context.Context, in *NodeRestartRequest, opts ...grpc.CallOption) (*NodeRestartResponse, error)
+	NodePause(ctx context.Context, in *NodePauseRequest, opts ...grpc.CallOption) (*NodePauseResponse, error)
+	NodeResume(ctx context.Context, in *NodeResumeRequest, opts ...grpc.CallOption) (*NodeResumeResponse, error)
+	GetNodeLogs(ctx context.Context, in *GetNodeLogsRequest, opts ...grpc.CallOption) (*GetNodeLogsResponse, error)
+	TailNodeLogs(ctx context.Context, in *TailNodeLogsRequest, opts ...grpc.CallOption) (OrchestratorService_TailNodeLogsClient, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (OrchestratorService_WatchEventsClient, error)
+	SnapshotNetwork(ctx context.Context, in *SnapshotNetworkRequest, opts ...grpc.CallOption) (*SnapshotNetworkResponse, error)
+	RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error)
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
+	PartitionNetwork(ctx context.Context, in *PartitionNetworkRequest, opts ...grpc.CallOption) (*PartitionNetworkResponse, error)
+	HealNetwork(ctx context.Context, in *HealNetworkRequest, opts ...grpc.CallOption) (*HealNetworkResponse, error)
+	SetLinkFaults(ctx context.Context, in *SetLinkFaultsRequest, opts ...grpc.CallOption) (*SetLinkFaultsResponse, error)
+	UpdateNode(ctx context.Context, in *UpdateNodeRequest, opts ...grpc.CallOption) (*UpdateNodeResponse, error)
+	DeploySubnet(ctx context.Context, in *DeploySubnetRequest, opts ...grpc.CallOption) (*DeploySubnetResponse, error)
+	RollingUpgrade(ctx context.Context, in *RollingUpgradeRequest, opts ...grpc.CallOption) (*RollingUpgradeResponse, error)
+	RegisterExecutor(ctx context.Context, in *RegisterExecutorRequest, opts ...grpc.CallOption) (*RegisterExecutorResponse, error)
+	DiscoverExecutors(ctx context.Context, in *DiscoverExecutorsRequest, opts ...grpc.CallOption) (*DiscoverExecutorsResponse, error)
+	ListExecutors(ctx context.Context, in *ListExecutorsRequest, opts ...grpc.CallOption) (*ListExecutorsResponse, error)
+	UnregisterExecutor(ctx context.Context, in *UnregisterExecutorRequest, opts ...grpc.CallOption) (*UnregisterExecutorResponse, error)
 }
 
 type orchestratorServiceClient struct {
@@ -133,6 +154,24 @@ func (c *orchestratorServiceClient) CreateNetwork(ctx context.Context, in *Creat
 	return out, nil
 }
 
+func (c *orchestratorServiceClient) ListNetworks(ctx context.Context, in *ListNetworksRequest, opts ...grpc.CallOption) (*ListNetworksResponse, error) {
+	out := new(ListNetworksResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/ListNetworks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) GetNetwork(ctx context.Context, in *GetNetworkRequest, opts ...grpc.CallOption) (*GetNetworkResponse, error) {
+	out := new(GetNetworkResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/GetNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *orchestratorServiceClient) GetNodes(ctx context.Context, in *GetNodesRequest, opts ...grpc.CallOption) (*GetNodesResponse, error) {
 	out := new(GetNodesResponse)
 	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/GetNodes", in, out, opts...)
@@ -178,16 +217,254 @@ func (c *orchestratorServiceClient) NodeStop(ctx context.Context, in *NodeStopRe
 	return out, nil
 }
 
+func (c *orchestratorServiceClient) NodeRestart(ctx context.Context, in *NodeRestartRequest, opts ...grpc.CallOption) (*NodeRestartResponse, error) {
+	out := new(NodeRestartResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/NodeRestart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) NodePause(ctx context.Context, in *NodePauseRequest, opts ...grpc.CallOption) (*NodePauseResponse, error) {
+	out := new(NodePauseResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/NodePause", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) NodeResume(ctx context.Context, in *NodeResumeRequest, opts ...grpc.CallOption) (*NodeResumeResponse, error) {
+	out := new(NodeResumeResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/NodeResume", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) GetNodeLogs(ctx context.Context, in *GetNodeLogsRequest, opts ...grpc.CallOption) (*GetNodeLogsResponse, error) {
+	out := new(GetNodeLogsResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/GetNodeLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) TailNodeLogs(ctx context.Context, in *TailNodeLogsRequest, opts ...grpc.CallOption) (OrchestratorService_TailNodeLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrchestratorService_ServiceDesc.Streams[0], "/rpcpb.OrchestratorService/TailNodeLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orchestratorServiceTailNodeLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrchestratorService_TailNodeLogsClient interface {
+	Recv() (*TailNodeLogsResponse, error)
+	grpc.ClientStream
+}
+
+type orchestratorServiceTailNodeLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *orchestratorServiceTailNodeLogsClient) Recv() (*TailNodeLogsResponse, error) {
+	m := new(TailNodeLogsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orchestratorServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (OrchestratorService_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrchestratorService_ServiceDesc.Streams[1], "/rpcpb.OrchestratorService/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orchestratorServiceWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrchestratorService_WatchEventsClient interface {
+	Recv() (*WatchEventsResponse, error)
+	grpc.ClientStream
+}
+
+type orchestratorServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *orchestratorServiceWatchEventsClient) Recv() (*WatchEventsResponse, error) {
+	m := new(WatchEventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orchestratorServiceClient) SnapshotNetwork(ctx context.Context, in *SnapshotNetworkRequest, opts ...grpc.CallOption) (*SnapshotNetworkResponse, error) {
+	out := new(SnapshotNetworkResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/SnapshotNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error) {
+	out := new(RestoreSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/RestoreSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	out := new(ListSnapshotsResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/ListSnapshots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) PartitionNetwork(ctx context.Context, in *PartitionNetworkRequest, opts ...grpc.CallOption) (*PartitionNetworkResponse, error) {
+	out := new(PartitionNetworkResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/PartitionNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) HealNetwork(ctx context.Context, in *HealNetworkRequest, opts ...grpc.CallOption) (*HealNetworkResponse, error) {
+	out := new(HealNetworkResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/HealNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) SetLinkFaults(ctx context.Context, in *SetLinkFaultsRequest, opts ...grpc.CallOption) (*SetLinkFaultsResponse, error) {
+	out := new(SetLinkFaultsResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/SetLinkFaults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) UpdateNode(ctx context.Context, in *UpdateNodeRequest, opts ...grpc.CallOption) (*UpdateNodeResponse, error) {
+	out := new(UpdateNodeResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/UpdateNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) DeploySubnet(ctx context.Context, in *DeploySubnetRequest, opts ...grpc.CallOption) (*DeploySubnetResponse, error) {
+	out := new(DeploySubnetResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/DeploySubnet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) RollingUpgrade(ctx context.Context, in *RollingUpgradeRequest, opts ...grpc.CallOption) (*RollingUpgradeResponse, error) {
+	out := new(RollingUpgradeResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/RollingUpgrade", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) RegisterExecutor(ctx context.Context, in *RegisterExecutorRequest, opts ...grpc.CallOption) (*RegisterExecutorResponse, error) {
+	out := new(RegisterExecutorResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/RegisterExecutor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) DiscoverExecutors(ctx context.Context, in *DiscoverExecutorsRequest, opts ...grpc.CallOption) (*DiscoverExecutorsResponse, error) {
+	out := new(DiscoverExecutorsResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/DiscoverExecutors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) ListExecutors(ctx context.Context, in *ListExecutorsRequest, opts ...grpc.CallOption) (*ListExecutorsResponse, error) {
+	out := new(ListExecutorsResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/ListExecutors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orchestratorServiceClient) UnregisterExecutor(ctx context.Context, in *UnregisterExecutorRequest, opts ...grpc.CallOption) (*UnregisterExecutorResponse, error) {
+	out := new(UnregisterExecutorResponse)
+	err := c.cc.Invoke(ctx, "/rpcpb.OrchestratorService/UnregisterExecutor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OrchestratorServiceServer is the server API for OrchestratorService service.
 // All implementations must embed UnimplementedOrchestratorServiceServer
 // for forward compatibility
 type OrchestratorServiceServer interface {
 	CreateNetwork(context.Context, *CreateNetworkRequest) (*CreateNetworkResponse, error)
+	ListNetworks(context.Context, *ListNetworksRequest) (*ListNetworksResponse, error)
+	GetNetwork(context.Context, *GetNetworkRequest) (*GetNetworkResponse, error)
 	GetNodes(context.Context, *GetNodesRequest) (*GetNodesResponse, error)
 	GetNode(context.Context, *GetNodeRequest) (*GetNodeResponse, error)
 	AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error)
 	Teardown(context.Context, *TeardownRequest) (*TeardownResponse, error)
 	NodeStop(context.Context, *NodeStopRequest) (*NodeStopResponse, error)
+	NodeRestart(context.Context, *NodeRestartRequest) (*NodeRestartResponse, error)
+	NodePause(context.Context, *NodePauseRequest) (*NodePauseResponse, error)
+	NodeResume(context.Context, *NodeResumeRequest) (*NodeResumeResponse, error)
+	GetNodeLogs(context.Context, *GetNodeLogsRequest) (*GetNodeLogsResponse, error)
+	TailNodeLogs(*TailNodeLogsRequest, OrchestratorService_TailNodeLogsServer) error
+	WatchEvents(*WatchEventsRequest, OrchestratorService_WatchEventsServer) error
+	SnapshotNetwork(context.Context, *SnapshotNetworkRequest) (*SnapshotNetworkResponse, error)
+	RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*RestoreSnapshotResponse, error)
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
+	PartitionNetwork(context.Context, *PartitionNetworkRequest) (*PartitionNetworkResponse, error)
+	HealNetwork(context.Context, *HealNetworkRequest) (*HealNetworkResponse, error)
+	SetLinkFaults(context.Context, *SetLinkFaultsRequest) (*SetLinkFaultsResponse, error)
+	UpdateNode(context.Context, *UpdateNodeRequest) (*UpdateNodeResponse, error)
+	DeploySubnet(context.Context, *DeploySubnetRequest) (*DeploySubnetResponse, error)
+	RollingUpgrade(context.Context, *RollingUpgradeRequest) (*RollingUpgradeResponse, error)
+	RegisterExecutor(context.Context, *RegisterExecutorRequest) (*RegisterExecutorResponse, error)
+	DiscoverExecutors(context.Context, *DiscoverExecutorsRequest) (*DiscoverExecutorsResponse, error)
+	ListExecutors(context.Context, *ListExecutorsRequest) (*ListExecutorsResponse, error)
+	UnregisterExecutor(context.Context, *UnregisterExecutorRequest) (*UnregisterExecutorResponse, error)
 	mustEmbedUnimplementedOrchestratorServiceServer()
 }
 
@@ -198,6 +475,12 @@ type UnimplementedOrchestratorServiceServer struct {
 func (UnimplementedOrchestratorServiceServer) CreateNetwork(context.Context, *CreateNetworkRequest) (*CreateNetworkResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateNetwork not implemented")
 }
+func (UnimplementedOrchestratorServiceServer) ListNetworks(context.Context, *ListNetworksRequest) (*ListNetworksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNetworks not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) GetNetwork(context.Context, *GetNetworkRequest) (*GetNetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetwork not implemented")
+}
 func (UnimplementedOrchestratorServiceServer) GetNodes(context.Context, *GetNodesRequest) (*GetNodesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetNodes not implemented")
 }
@@ -213,6 +496,63 @@ func (UnimplementedOrchestratorServiceServer) Teardown(context.Context, *Teardow
 func (UnimplementedOrchestratorServiceServer) NodeStop(context.Context, *NodeStopRequest) (*NodeStopResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NodeStop not implemented")
 }
+func (UnimplementedOrchestratorServiceServer) NodeRestart(context.Context, *NodeRestartRequest) (*NodeRestartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NodeRestart not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) NodePause(context.Context, *NodePauseRequest) (*NodePauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NodePause not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) NodeResume(context.Context, *NodeResumeRequest) (*NodeResumeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NodeResume not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) GetNodeLogs(context.Context, *GetNodeLogsRequest) (*GetNodeLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeLogs not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) TailNodeLogs(*TailNodeLogsRequest, OrchestratorService_TailNodeLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method TailNodeLogs not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) WatchEvents(*WatchEventsRequest, OrchestratorService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) SnapshotNetwork(context.Context, *SnapshotNetworkRequest) (*SnapshotNetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SnapshotNetwork not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*RestoreSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreSnapshot not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) PartitionNetwork(context.Context, *PartitionNetworkRequest) (*PartitionNetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PartitionNetwork not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) HealNetwork(context.Context, *HealNetworkRequest) (*HealNetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealNetwork not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) SetLinkFaults(context.Context, *SetLinkFaultsRequest) (*SetLinkFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkFaults not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) UpdateNode(context.Context, *UpdateNodeRequest) (*UpdateNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNode not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) DeploySubnet(context.Context, *DeploySubnetRequest) (*DeploySubnetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeploySubnet not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) RollingUpgrade(context.Context, *RollingUpgradeRequest) (*RollingUpgradeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollingUpgrade not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) RegisterExecutor(context.Context, *RegisterExecutorRequest) (*RegisterExecutorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterExecutor not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) DiscoverExecutors(context.Context, *DiscoverExecutorsRequest) (*DiscoverExecutorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscoverExecutors not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) ListExecutors(context.Context, *ListExecutorsRequest) (*ListExecutorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExecutors not implemented")
+}
+func (UnimplementedOrchestratorServiceServer) UnregisterExecutor(context.Context, *UnregisterExecutorRequest) (*UnregisterExecutorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnregisterExecutor not implemented")
+}
 func (UnimplementedOrchestratorServiceServer) mustEmbedUnimplementedOrchestratorServiceServer() {}
 
 // UnsafeOrchestratorServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -244,6 +584,42 @@ func _OrchestratorService_CreateNetwork_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrchestratorService_ListNetworks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNetworksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).ListNetworks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/ListNetworks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).ListNetworks(ctx, req.(*ListNetworksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_GetNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).GetNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/GetNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).GetNetwork(ctx, req.(*GetNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _OrchestratorService_GetNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetNodesRequest)
 	if err := dec(in); err != nil {
@@ -334,6 +710,354 @@ func _OrchestratorService_NodeStop_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrchestratorService_NodeRestart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeRestartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).NodeRestart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/NodeRestart",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).NodeRestart(ctx, req.(*NodeRestartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_NodePause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodePauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).NodePause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/NodePause",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).NodePause(ctx, req.(*NodePauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_NodeResume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).NodeResume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/NodeResume",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).NodeResume(ctx, req.(*NodeResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_GetNodeLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNodeLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).GetNodeLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/GetNodeLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).GetNodeLogs(ctx, req.(*GetNodeLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_TailNodeLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailNodeLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrchestratorServiceServer).TailNodeLogs(m, &orchestratorServiceTailNodeLogsServer{stream})
+}
+
+type OrchestratorService_TailNodeLogsServer interface {
+	Send(*TailNodeLogsResponse) error
+	grpc.ServerStream
+}
+
+type orchestratorServiceTailNodeLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *orchestratorServiceTailNodeLogsServer) Send(m *TailNodeLogsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OrchestratorService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrchestratorServiceServer).WatchEvents(m, &orchestratorServiceWatchEventsServer{stream})
+}
+
+type OrchestratorService_WatchEventsServer interface {
+	Send(*WatchEventsResponse) error
+	grpc.ServerStream
+}
+
+type orchestratorServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *orchestratorServiceWatchEventsServer) Send(m *WatchEventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OrchestratorService_SnapshotNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).SnapshotNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/SnapshotNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).SnapshotNetwork(ctx, req.(*SnapshotNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_RestoreSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).RestoreSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/RestoreSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).RestoreSnapshot(ctx, req.(*RestoreSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/ListSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_PartitionNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartitionNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).PartitionNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/PartitionNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).PartitionNetwork(ctx, req.(*PartitionNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_HealNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).HealNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/HealNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).HealNetwork(ctx, req.(*HealNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_SetLinkFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).SetLinkFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/SetLinkFaults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).SetLinkFaults(ctx, req.(*SetLinkFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_UpdateNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).UpdateNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/UpdateNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).UpdateNode(ctx, req.(*UpdateNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_DeploySubnet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeploySubnetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).DeploySubnet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/DeploySubnet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).DeploySubnet(ctx, req.(*DeploySubnetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_RollingUpgrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollingUpgradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).RollingUpgrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/RollingUpgrade",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).RollingUpgrade(ctx, req.(*RollingUpgradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_RegisterExecutor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterExecutorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).RegisterExecutor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/RegisterExecutor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).RegisterExecutor(ctx, req.(*RegisterExecutorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_DiscoverExecutors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscoverExecutorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).DiscoverExecutors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/DiscoverExecutors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).DiscoverExecutors(ctx, req.(*DiscoverExecutorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_ListExecutors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExecutorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).ListExecutors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/ListExecutors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).ListExecutors(ctx, req.(*ListExecutorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrchestratorService_UnregisterExecutor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterExecutorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrchestratorServiceServer).UnregisterExecutor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcpb.OrchestratorService/UnregisterExecutor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrchestratorServiceServer).UnregisterExecutor(ctx, req.(*UnregisterExecutorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // OrchestratorService_ServiceDesc is the grpc.ServiceDesc for OrchestratorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -345,6 +1069,14 @@ var OrchestratorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateNetwork",
 			Handler:    _OrchestratorService_CreateNetwork_Handler,
 		},
+		{
+			MethodName: "ListNetworks",
+			Handler:    _OrchestratorService_ListNetworks_Handler,
+		},
+		{
+			MethodName: "GetNetwork",
+			Handler:    _OrchestratorService_GetNetwork_Handler,
+		},
 		{
 			MethodName: "GetNodes",
 			Handler:    _OrchestratorService_GetNodes_Handler,
@@ -365,7 +1097,86 @@ var OrchestratorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NodeStop",
 			Handler:    _OrchestratorService_NodeStop_Handler,
 		},
+		{
+			MethodName: "NodeRestart",
+			Handler:    _OrchestratorService_NodeRestart_Handler,
+		},
+		{
+			MethodName: "NodePause",
+			Handler:    _OrchestratorService_NodePause_Handler,
+		},
+		{
+			MethodName: "NodeResume",
+			Handler:    _OrchestratorService_NodeResume_Handler,
+		},
+		{
+			MethodName: "GetNodeLogs",
+			Handler:    _OrchestratorService_GetNodeLogs_Handler,
+		},
+		{
+			MethodName: "SnapshotNetwork",
+			Handler:    _OrchestratorService_SnapshotNetwork_Handler,
+		},
+		{
+			MethodName: "RestoreSnapshot",
+			Handler:    _OrchestratorService_RestoreSnapshot_Handler,
+		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _OrchestratorService_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "PartitionNetwork",
+			Handler:    _OrchestratorService_PartitionNetwork_Handler,
+		},
+		{
+			MethodName: "HealNetwork",
+			Handler:    _OrchestratorService_HealNetwork_Handler,
+		},
+		{
+			MethodName: "SetLinkFaults",
+			Handler:    _OrchestratorService_SetLinkFaults_Handler,
+		},
+		{
+			MethodName: "UpdateNode",
+			Handler:    _OrchestratorService_UpdateNode_Handler,
+		},
+		{
+			MethodName: "DeploySubnet",
+			Handler:    _OrchestratorService_DeploySubnet_Handler,
+		},
+		{
+			MethodName: "RollingUpgrade",
+			Handler:    _OrchestratorService_RollingUpgrade_Handler,
+		},
+		{
+			MethodName: "RegisterExecutor",
+			Handler:    _OrchestratorService_RegisterExecutor_Handler,
+		},
+		{
+			MethodName: "DiscoverExecutors",
+			Handler:    _OrchestratorService_DiscoverExecutors_Handler,
+		},
+		{
+			MethodName: "ListExecutors",
+			Handler:    _OrchestratorService_ListExecutors_Handler,
+		},
+		{
+			MethodName: "UnregisterExecutor",
+			Handler:    _OrchestratorService_UnregisterExecutor_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailNodeLogs",
+			Handler:       _OrchestratorService_TailNodeLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _OrchestratorService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpcpb/rpc.proto",
 }
@@ -0,0 +1,287 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+const (
+	// DefaultPortRangeStart and DefaultPortRangeEnd bound the ports handed out to nodes by default. The range sits below
+	// the ephemeral port range of common operating systems, so that allocated ports do not collide with outbound
+	// connections.
+	DefaultPortRangeStart = 20_000
+	DefaultPortRangeEnd   = 30_000
+	// DefaultNetworkPortRangeSize is the number of ports reserved for each network by default, which is enough for
+	// 100 nodes that each need an HTTP and a staking port.
+	DefaultNetworkPortRangeSize = 200
+)
+
+var errPortAllocatorClosed = errors.New("port allocator is closed")
+
+// PortsConfig configures the ports that an orchestrator allocates to the nodes of its networks.
+type PortsConfig struct {
+	// RangeStart and RangeEnd bound the ports allocated to nodes. Default to DefaultPortRangeStart and
+	// DefaultPortRangeEnd if 0.
+	RangeStart int `json:"rangeStart"`
+	RangeEnd   int `json:"rangeEnd"`
+	// NetworkRangeSize is the number of ports reserved for each network. Defaults to DefaultNetworkPortRangeSize if 0.
+	NetworkRangeSize int `json:"networkRangeSize"`
+}
+
+// NewPortAllocator returns an allocator for the port range of [c]
+func (c PortsConfig) NewPortAllocator() (*PortAllocator, error) {
+	start, end := c.RangeStart, c.RangeEnd
+	if start == 0 {
+		start = DefaultPortRangeStart
+	}
+	if end == 0 {
+		end = DefaultPortRangeEnd
+	}
+	return NewPortAllocator(start, end)
+}
+
+// GetNetworkRangeSize returns the number of ports to reserve for each network
+func (c PortsConfig) GetNetworkRangeSize() int {
+	if c.NetworkRangeSize == 0 {
+		return DefaultNetworkPortRangeSize
+	}
+	return c.NetworkRangeSize
+}
+
+// PortAllocator hands out ports from the range [start, end) and tracks the ports that are in use, so that nodes started
+// concurrently by the same process are never given the same port. Ports are additionally checked to be free on the host
+// before they are handed out, so that ports in use by other processes are skipped.
+//
+// An allocator can reserve a sub-range for each network with AllocateRange. Ports reserved through the sub-range
+// outside of its own range, such as ports set explicitly in the config of a node, are tracked by the parent, so that
+// they conflict with the ports used by every other network.
+type PortAllocator struct {
+	parent     *PortAllocator
+	start, end int
+
+	lock sync.Mutex
+	// next is the port to start searching from, so that recently released ports are not immediately reused
+	next int
+	// reserved maps each reserved port to the allocator that reserved it, which is either this allocator or a sub-range
+	reserved map[int]*PortAllocator
+	closed   bool
+}
+
+// NewPortAllocator returns an allocator that hands out ports from the range [start, end)
+func NewPortAllocator(start int, end int) (*PortAllocator, error) {
+	if start <= 0 || end > 65536 || start >= end {
+		return nil, fmt.Errorf("invalid port range [%d, %d)", start, end)
+	}
+	return newPortAllocator(nil, start, end), nil
+}
+
+func newPortAllocator(parent *PortAllocator, start int, end int) *PortAllocator {
+	return &PortAllocator{
+		parent:   parent,
+		start:    start,
+		end:      end,
+		next:     start,
+		reserved: make(map[int]*PortAllocator),
+	}
+}
+
+// AllocateRange reserves [size] contiguous ports and returns an allocator for them. The ports are returned to [a] when
+// the returned allocator is closed.
+func (a *PortAllocator) AllocateRange(size int) (*PortAllocator, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return nil, errPortAllocatorClosed
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid port range size %d", size)
+	}
+
+	for start := a.start; start+size <= a.end; start++ {
+		free := true
+		for port := start; port < start+size; port++ {
+			if _, reserved := a.reserved[port]; reserved {
+				// Skip past the reserved port, since no range containing it is free.
+				start, free = port, false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+		return a.reserveRange(start, size), nil
+	}
+	return nil, fmt.Errorf("no range of %d free ports left in [%d, %d)", size, a.start, a.end)
+}
+
+// ReserveRange reserves the ports [start, start+size) and returns an allocator for them, so that a network can be given
+// the same range again ie. when it is restored. Fails if any of the ports is already reserved.
+func (a *PortAllocator) ReserveRange(start int, size int) (*PortAllocator, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return nil, errPortAllocatorClosed
+	}
+	if size <= 0 || start < a.start || start+size > a.end {
+		return nil, fmt.Errorf("invalid port range [%d, %d) in [%d, %d)", start, start+size, a.start, a.end)
+	}
+	for port := start; port < start+size; port++ {
+		if _, reserved := a.reserved[port]; reserved {
+			return nil, fmt.Errorf("port %d is already in use", port)
+		}
+	}
+	return a.reserveRange(start, size), nil
+}
+
+// reserveRange reserves the free ports [start, start+size) for a new sub-range. Assumes the lock is held.
+func (a *PortAllocator) reserveRange(start int, size int) *PortAllocator {
+	child := newPortAllocator(a, start, start+size)
+	for port := start; port < start+size; port++ {
+		a.reserved[port] = child
+	}
+	return child
+}
+
+// Range returns the first port and the end (exclusive) of the range of the allocator
+func (a *PortAllocator) Range() (int, int) {
+	return a.start, a.end
+}
+
+// Allocate reserves [n] ports that are not reserved and are currently free on the host.
+func (a *PortAllocator) Allocate(n int) ([]int, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return nil, errPortAllocatorClosed
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	ports := make([]int, 0, n)
+	size := a.end - a.start
+	for i := 0; i < size && len(ports) < n; i++ {
+		port := a.start + (a.next-a.start+i)%size
+		if _, reserved := a.reserved[port]; reserved || !isPortFree(port) {
+			continue
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) < n {
+		return nil, fmt.Errorf("failed to allocate %d ports: only %d free ports left in [%d, %d)", n, len(ports), a.start, a.end)
+	}
+
+	for _, port := range ports {
+		a.reserved[port] = a
+	}
+	a.next = ports[len(ports)-1] + 1
+	if a.next >= a.end {
+		a.next = a.start
+	}
+	return ports, nil
+}
+
+// Reserve reserves each of [ports], which may be outside of the range of the allocator. Either every port is reserved
+// or, if any of them is already reserved, none of them are.
+func (a *PortAllocator) Reserve(ports ...int) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return errPortAllocatorClosed
+	}
+	for i, port := range ports {
+		if err := a.reserve(port, a); err != nil {
+			for _, reservedPort := range ports[:i] {
+				a.release(reservedPort, a)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// reserve reserves [port] on behalf of [owner]. Ports outside of the range of a sub-range are reserved by its parent.
+// Assumes the lock is held.
+func (a *PortAllocator) reserve(port int, owner *PortAllocator) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %d", port)
+	}
+	if a.parent != nil && (port < a.start || port >= a.end) {
+		a.parent.lock.Lock()
+		defer a.parent.lock.Unlock()
+
+		return a.parent.reserve(port, owner)
+	}
+	if _, reserved := a.reserved[port]; reserved {
+		return fmt.Errorf("port %d is already in use", port)
+	}
+	a.reserved[port] = owner
+	return nil
+}
+
+// Release returns each of [ports] to the allocator, so that they can be allocated again.
+func (a *PortAllocator) Release(ports ...int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, port := range ports {
+		a.release(port, a)
+	}
+}
+
+// release releases [port] if it is reserved by [owner]. Assumes the lock is held.
+func (a *PortAllocator) release(port int, owner *PortAllocator) {
+	if a.parent != nil && (port < a.start || port >= a.end) {
+		a.parent.lock.Lock()
+		defer a.parent.lock.Unlock()
+
+		a.parent.release(port, owner)
+		return
+	}
+	if a.reserved[port] == owner {
+		delete(a.reserved, port)
+	}
+}
+
+// Close releases every port reserved through the allocator, including its range if it was allocated with
+// AllocateRange. The allocator cannot be used afterwards.
+func (a *PortAllocator) Close() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.closed {
+		return
+	}
+	a.closed = true
+	a.reserved = make(map[int]*PortAllocator)
+	if a.parent == nil {
+		return
+	}
+
+	a.parent.lock.Lock()
+	defer a.parent.lock.Unlock()
+	for port, owner := range a.parent.reserved {
+		if owner == a {
+			delete(a.parent.reserved, port)
+		}
+	}
+}
+
+// isPortFree returns true if [port] can currently be bound on every interface of the host
+func isPortFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortAllocator(t *testing.T) {
+	assert := assert.New(t)
+
+	allocator, err := NewPortAllocator(DefaultPortRangeStart, DefaultPortRangeEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network0, err := allocator.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network1, err := allocator.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Allocate every port of a network concurrently and check that no port is handed out twice.
+	allocated := make(chan int, 10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ports, err := network0.Allocate(2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for _, port := range ports {
+				allocated <- port
+			}
+		}()
+	}
+	wg.Wait()
+	close(allocated)
+	seen := make(map[int]struct{})
+	for port := range allocated {
+		assert.NotContains(seen, port, "port %d allocated twice", port)
+		assert.True(port >= network0.start && port < network0.end, "port %d outside of the network range", port)
+		seen[port] = struct{}{}
+	}
+	assert.Len(seen, 10)
+	_, err = network0.Allocate(1)
+	assert.Error(err, "expected allocating from an exhausted range to fail")
+
+	// Released ports can be allocated again.
+	network0.Release(network0.start)
+	ports, err := network0.Allocate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal([]int{network0.start}, ports)
+
+	// Ports outside of a network's range are tracked across networks.
+	if err := network0.Reserve(9650, 9651); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(network1.Reserve(9652, 9651), "expected reserving a port used by another network to fail")
+	assert.NoError(network1.Reserve(9652), "expected a failed reservation to reserve none of its ports")
+	assert.Error(network1.Reserve(network1.start, network1.start))
+
+	// Closing a network returns its range and every port it reserved.
+	network0.Close()
+	assert.NoError(network1.Reserve(9650, 9651))
+	network2, err := allocator.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(network0.start, network2.start)
+	_, err = network0.Allocate(1)
+	assert.ErrorIs(err, errPortAllocatorClosed)
+}
+
+func TestPortAllocatorSkipsPortsInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	allocator, err := NewPortAllocator(port, port+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = allocator.Allocate(1)
+	assert.Error(t, err, fmt.Sprintf("expected port %d to be skipped while it is bound", port))
+}
+
+func TestPortAllocatorRangeExhausted(t *testing.T) {
+	assert := assert.New(t)
+
+	allocator, err := NewPortAllocator(DefaultPortRangeStart, DefaultPortRangeStart+25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges := make([]*PortAllocator, 0, 2)
+	for i := 0; i < 2; i++ {
+		r, err := allocator.AllocateRange(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ranges = append(ranges, r)
+	}
+	_, err = allocator.AllocateRange(10)
+	assert.Error(err)
+
+	// A port reserved in the middle of the free space prevents ranges from overlapping it.
+	ranges[0].Close()
+	if err := allocator.Reserve(DefaultPortRangeStart + 5); err != nil {
+		t.Fatal(err)
+	}
+	_, err = allocator.AllocateRange(10)
+	assert.Error(err)
+	r, err := allocator.AllocateRange(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(DefaultPortRangeStart, r.start)
+}
+
+func TestPortAllocatorReserveRange(t *testing.T) {
+	assert := assert.New(t)
+
+	allocator, err := NewPortAllocator(DefaultPortRangeStart, DefaultPortRangeStart+30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network0, err := allocator.ReserveRange(DefaultPortRangeStart+10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := network0.Range()
+	assert.Equal(DefaultPortRangeStart+10, start)
+	assert.Equal(DefaultPortRangeStart+20, end)
+
+	// Reserved ranges cannot overlap and must lie within the range of the allocator.
+	_, err = allocator.ReserveRange(DefaultPortRangeStart+15, 10)
+	assert.Error(err)
+	_, err = allocator.ReserveRange(DefaultPortRangeStart+25, 10)
+	assert.Error(err)
+	_, err = allocator.AllocateRange(11)
+	assert.Error(err)
+	network1, err := allocator.AllocateRange(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(DefaultPortRangeStart, network1.start)
+
+	network0.Close()
+	_, err = allocator.ReserveRange(DefaultPortRangeStart+10, 10)
+	assert.NoError(err)
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var _ io.WriteCloser = &RotatingFile{}
+
+// RotatingFile is a thread safe writer that appends to the file at [path] and rotates it once it grows beyond
+// [maxSize] bytes. Rotated files are renamed to path.1, path.2, ... with path.1 being the most recent, and at
+// most [maxBackups] of them are retained.
+type RotatingFile struct {
+	lock sync.Mutex
+
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile creates the parent directory of [path] and returns a RotatingFile that appends to it.
+// The file is opened lazily on the first write, so a closed RotatingFile may be written to again.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid max size %d for log file %s", maxSize, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the underlying file. Writing to a closed RotatingFile re-opens the file.
+func (r *RotatingFile) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Paths returns the paths of the files that currently hold data, ordered from oldest to newest.
+func (r *RotatingFile) Paths() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	paths := make([]string, 0, r.maxBackups+1)
+	for i := r.maxBackups; i > 0; i-- {
+		backup := r.backupPath(i)
+		if _, err := os.Stat(backup); err == nil {
+			paths = append(paths, backup)
+		}
+	}
+	if _, err := os.Stat(r.path); err == nil {
+		paths = append(paths, r.path)
+	}
+	return paths
+}
+
+// open assumes the lock is held.
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts each backup up by one, dropping the oldest, moves the current file to the first backup and
+// opens a new file in its place. Assumes the lock is held.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+
+	if err := r.shiftBackups(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, r.backupPath(1)); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Path returns the path of the current file
+func (r *RotatingFile) Path() string { return r.path }
+
+// CopyTruncate rotates the current file by copying it to the first backup and truncating it in place, rather than
+// renaming it. This rotates a file that another process keeps open for appending, at the cost of losing anything the
+// other process writes between the copy and the truncation.
+func (r *RotatingFile) CopyTruncate() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.maxBackups > 0 {
+		if err := r.shiftBackups(); err != nil {
+			return err
+		}
+		if err := copyFile(r.path, r.backupPath(1)); err != nil {
+			return err
+		}
+	}
+	if err := os.Truncate(r.path, 0); err != nil {
+		return err
+	}
+	r.size = 0
+	return nil
+}
+
+// shiftBackups shifts each backup up by one, dropping the oldest. Assumes the lock is held.
+func (r *RotatingFile) shiftBackups() error {
+	if err := os.Remove(r.backupPath(r.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(r.backupPath(i), r.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (r *RotatingFile) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "node", "stdout.log")
+	file, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.NoError(file.Close())
+
+	// Each write exceeds the max size when combined with the previous one, so every line ends up in its own file
+	// and the oldest line is dropped once there are more than 2 backups.
+	paths := file.Paths()
+	assert.Equal([]string{path + ".2", path + ".1", path}, paths)
+	expected := []string{"second\n", "third\n", "fourth\n"}
+	for i, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(expected[i], string(b))
+	}
+
+	// Writing after Close re-opens the current file and appends to it.
+	if _, err := file.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(file.Close())
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("fourth\na\n", string(b))
+}
+
+func TestRotatingFileCopyTruncate(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "node", "stdout.log")
+	file, err := NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is written by another process, which keeps it open for appending across rotations.
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := writer.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(file.CopyTruncate())
+	}
+
+	assert.Equal([]string{path + ".1", path}, file.Paths())
+	b, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("third\n", string(b))
+	b, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(b)
+}
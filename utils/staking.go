@@ -0,0 +1,35 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/network/peer"
+	"github.com/ava-labs/avalanchego/staking"
+	avagoconstants "github.com/ava-labs/avalanchego/utils/constants"
+)
+
+// ToNodeID returns the NodeID (ie. NodeID-...) of a node staking with the PEM encoded [keyBytes] and [certBytes].
+func ToNodeID(keyBytes []byte, certBytes []byte) (string, error) {
+	cert, err := staking.LoadTLSCertFromBytes(keyBytes, certBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to load staking certificate: %w", err)
+	}
+	return peer.CertToID(cert.Leaf).PrefixedString(avagoconstants.NodeIDPrefix), nil
+}
+
+// NewStakingCredentials generates a new PEM encoded staking key and certificate and returns them along with the
+// NodeID they correspond to.
+func NewStakingCredentials() ([]byte, []byte, string, error) {
+	certBytes, keyBytes, err := staking.NewCertAndKeyBytes()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to generate staking credentials: %w", err)
+	}
+	nodeID, err := ToNodeID(keyBytes, certBytes)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return keyBytes, certBytes, nodeID, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (C) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/aaronbuchwald/avalanche-network-runner/utils/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToNodeID(t *testing.T) {
+	for i, expectedNodeID := range constants.LocalNetworkStakerIDs {
+		nodeID, err := ToNodeID([]byte(constants.LocalNetworkStakerKeys[i]), []byte(constants.LocalNetworkStakerCerts[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expectedNodeID, nodeID)
+	}
+
+	_, err := ToNodeID([]byte(constants.LocalNetworkStakerKeys[0]), []byte(constants.LocalNetworkStakerCerts[1]))
+	assert.Error(t, err, "expected mismatched key and certificate to fail")
+}
+
+func TestNewStakingCredentials(t *testing.T) {
+	keyBytes, certBytes, nodeID, err := NewStakingCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	derivedNodeID, err := ToNodeID(keyBytes, certBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, derivedNodeID, nodeID)
+}